@@ -1,37 +1,210 @@
 package config
 
 import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"io"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 	"tp-project-db/consts"
 )
 
-func Load() {
-	setEnvVar("SERVER_HOST", "0.0.0.0")
-	setEnvVar("SERVER_PORT", "5000")
+const (
+	PostgresStorage = "postgres"
+	MemoryStorage   = "memory"
+)
+
+const (
+	ConfigFileFlag = "config"
+	ConfigFileEnv  = "CONFIG_FILE"
+)
 
-	setEnvVar("PGHOST", "127.0.0.1")
-	setEnvVar("PGPORT", "5432")
-	setEnvVar("PGDATABASE", "forum")
-	setEnvVar("PGUSER", "postgres")
-	setEnvVar("PGPASSWORD", consts.EmptyString)
+type Config struct {
+	Server   ServerConfig   `json:"server"`
+	Database DatabaseConfig `json:"database"`
+	Log      LogConfig      `json:"log"`
+	Features FeaturesConfig `json:"features"`
+}
+
+type ServerConfig struct {
+	Host                 string              `json:"host"`
+	Port                 int                 `json:"port"`
+	ShutdownTimeout      Duration            `json:"shutdownTimeout"`
+	SlowRequestThreshold Duration            `json:"slowRequestThreshold"`
+	RequestTimeout       Duration            `json:"requestTimeout"`
+	RouteTimeouts        map[string]Duration `json:"routeTimeouts"`
+}
 
-	//setEnvVar("PGHOST", "127.0.0.1")
-	//setEnvVar("PGPORT", "5432")
-	//setEnvVar("PGDATABASE", "forum")
-	//setEnvVar("PGUSER", "user")
-	//setEnvVar("PGPASSWORD", "password")
+type DatabaseConfig struct {
+	Host               string   `json:"host"`
+	Port               int      `json:"port"`
+	Name               string   `json:"name"`
+	User               string   `json:"user"`
+	Password           string   `json:"password"`
+	MaxConnections     int      `json:"maxConnections"`
+	AcquireTimeout     Duration `json:"acquireTimeout"`
+	SlowQueryThreshold Duration `json:"slowQueryThreshold"`
 }
 
-func getEnvVar(name, defaultValue string) string {
-	if v := os.Getenv(name); v != consts.EmptyString {
-		return v
+type LogConfig struct {
+	Level  string `json:"level"`
+	Output string `json:"output"`
+}
+
+type FeaturesConfig struct {
+	Storage     string `json:"storage"`
+	AutoMigrate bool   `json:"autoMigrate"`
+	Metrics     bool   `json:"metrics"`
+	AccessLog   bool   `json:"accessLog"`
+}
+
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Host:                 "0.0.0.0",
+			Port:                 5000,
+			ShutdownTimeout:      Duration(10 * time.Second),
+			SlowRequestThreshold: Duration(100 * time.Millisecond),
+			RequestTimeout:       Duration(5 * time.Second),
+			RouteTimeouts:        map[string]Duration{},
+		},
+		Database: DatabaseConfig{
+			Host:               "127.0.0.1",
+			Port:               5432,
+			Name:               "forum",
+			User:               "postgres",
+			Password:           consts.EmptyString,
+			MaxConnections:     32,
+			AcquireTimeout:     Duration(0),
+			SlowQueryThreshold: Duration(100 * time.Millisecond),
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Output: "stderr",
+		},
+		Features: FeaturesConfig{
+			Storage:     PostgresStorage,
+			AutoMigrate: true,
+			Metrics:     true,
+			AccessLog:   true,
+		},
 	}
-	return defaultValue
 }
 
-func setEnvVar(name, defaultValue string) {
-	if os.Getenv(name) != consts.EmptyString {
-		return
+// Load builds the effective configuration from the defaults, the config file,
+// the environment and the command-line flags, each overriding the previous one.
+// It returns the arguments left after the flags.
+func Load(args []string) (*Config, []string, error) {
+	fs := flag.NewFlagSet(filepath.Base(os.Args[0]), flag.ContinueOnError)
+	file := fs.String(ConfigFileFlag, consts.EmptyString, "path to a JSON or TOML config file (env "+ConfigFileEnv+")")
+
+	flags := make(map[string]*flagValue, len(options))
+	for _, opt := range options {
+		flags[opt.name] = &flagValue{}
+		fs.Var(flags[opt.name], opt.name, opt.usage+" (env "+opt.env+")")
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	cfg := Default()
+
+	path := *file
+	if path == consts.EmptyString {
+		path = os.Getenv(ConfigFileEnv)
+	}
+	if path != consts.EmptyString {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	for _, opt := range options {
+		v := os.Getenv(opt.env)
+		if v == consts.EmptyString {
+			continue
+		}
+		if err := opt.value(cfg).Set(v); err != nil {
+			return nil, nil, fmt.Errorf("env %s: %v", opt.env, err)
+		}
+	}
+
+	for _, opt := range options {
+		f := flags[opt.name]
+		if !f.set {
+			continue
+		}
+		if err := opt.value(cfg).Set(f.value); err != nil {
+			return nil, nil, fmt.Errorf("flag -%s: %v", opt.name, err)
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, err
+	}
+	return cfg, fs.Args(), nil
+}
+
+func (cfg *Config) loadFile(path string) error {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext != ".json" && ext != ".toml" {
+		return fmt.Errorf("config file %s: unsupported format %q (expected .json or .toml)", path, ext)
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config file: %v", err)
+	}
+
+	if ext == ".toml" {
+		err = cfg.decodeTOML(b)
+	} else {
+		err = cfg.decodeJSON(b)
+	}
+	if err != nil {
+		return fmt.Errorf("config file %s: %v", path, err)
+	}
+	return nil
+}
+
+func (cfg *Config) decodeJSON(b []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	return dec.Decode(cfg)
+}
+
+// decodeTOML goes through JSON so both formats share field names, duration
+// parsing and the unknown-field check.
+func (cfg *Config) decodeTOML(b []byte) error {
+	var doc map[string]interface{}
+	if _, err := toml.Decode(string(b), &doc); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return cfg.decodeJSON(b)
+}
+
+func (cfg *Config) Print(w io.Writer) error {
+	masked := *cfg
+	if masked.Database.Password != consts.EmptyString {
+		masked.Database.Password = "******"
+	}
+
+	b, err := json.MarshalIndent(&masked, "", "  ")
+	if err != nil {
+		return err
 	}
-	_ = os.Setenv(name, defaultValue)
+	_, err = fmt.Fprintln(w, string(b))
+	return err
 }
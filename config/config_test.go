@@ -0,0 +1,327 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// clearEnv unsets every variable Load reads and returns a function that puts
+// them back.
+func clearEnv(t *testing.T) func() {
+	t.Helper()
+
+	names := []string{ConfigFileEnv}
+	for _, opt := range options {
+		names = append(names, opt.env)
+	}
+
+	saved := make(map[string]string)
+	for _, name := range names {
+		if v, ok := os.LookupEnv(name); ok {
+			saved[name] = v
+		}
+		os.Unsetenv(name)
+	}
+
+	return func() {
+		for _, name := range names {
+			if v, ok := saved[name]; ok {
+				os.Setenv(name, v)
+			} else {
+				os.Unsetenv(name)
+			}
+		}
+	}
+}
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func tempDir(t *testing.T) (string, func()) {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir, func() { os.RemoveAll(dir) }
+}
+
+func TestLoadDefaults(t *testing.T) {
+	defer clearEnv(t)()
+
+	cfg, rest, err := Load([]string{"extra"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(cfg, Default()) {
+		t.Errorf("Load() = %+v, want defaults %+v", cfg, Default())
+	}
+	if !reflect.DeepEqual(rest, []string{"extra"}) {
+		t.Errorf("remaining args = %v, want [extra]", rest)
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	defer clearEnv(t)()
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	path := writeFile(t, dir, "forum.json", `{
+		"server": {"port": 6000, "requestTimeout": "2s"},
+		"database": {"name": "from_file", "user": "from_file"}
+	}`)
+
+	os.Setenv(ConfigFileEnv, path)
+	os.Setenv("PGDATABASE", "from_env")
+	os.Setenv("PGUSER", "from_env")
+
+	cfg, _, err := Load([]string{"-database.user", "from_flag"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Server.Port != 6000 {
+		t.Errorf("server.port = %d, want 6000 from file", cfg.Server.Port)
+	}
+	if cfg.Server.RequestTimeout.Duration() != 2*time.Second {
+		t.Errorf("server.requestTimeout = %v, want 2s from file", cfg.Server.RequestTimeout)
+	}
+	if cfg.Database.Name != "from_env" {
+		t.Errorf("database.name = %q, want env to override file", cfg.Database.Name)
+	}
+	if cfg.Database.User != "from_flag" {
+		t.Errorf("database.user = %q, want flag to override env", cfg.Database.User)
+	}
+	if cfg.Database.Host != Default().Database.Host {
+		t.Errorf("database.host = %q, want default", cfg.Database.Host)
+	}
+}
+
+func TestLoadConfigFlagOverridesEnv(t *testing.T) {
+	defer clearEnv(t)()
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	os.Setenv(ConfigFileEnv, writeFile(t, dir, "env.json", `{"server": {"port": 6001}}`))
+	flagPath := writeFile(t, dir, "flag.json", `{"server": {"port": 6002}}`)
+
+	cfg, _, err := Load([]string{"-config", flagPath})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Server.Port != 6002 {
+		t.Errorf("server.port = %d, want 6002 from -config", cfg.Server.Port)
+	}
+}
+
+func TestLoadTOML(t *testing.T) {
+	defer clearEnv(t)()
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	path := writeFile(t, dir, "forum.toml", `
+[server]
+port = 6100
+requestTimeout = "3s"
+
+[server.routeTimeouts]
+"/api/service/status" = "250ms"
+
+[database]
+maxConnections = 8
+
+[features]
+storage = "memory"
+metrics = false
+`)
+
+	cfg, _, err := Load([]string{"-config", path})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Server.Port != 6100 {
+		t.Errorf("server.port = %d, want 6100", cfg.Server.Port)
+	}
+	if cfg.Server.RequestTimeout.Duration() != 3*time.Second {
+		t.Errorf("server.requestTimeout = %v, want 3s", cfg.Server.RequestTimeout)
+	}
+	if got := cfg.Server.RouteTimeouts["/api/service/status"].Duration(); got != 250*time.Millisecond {
+		t.Errorf("server.routeTimeouts[/api/service/status] = %v, want 250ms", got)
+	}
+	if cfg.Database.MaxConnections != 8 {
+		t.Errorf("database.maxConnections = %d, want 8", cfg.Database.MaxConnections)
+	}
+	if cfg.Features.Storage != MemoryStorage || cfg.Features.Metrics {
+		t.Errorf("features = %+v, want memory storage without metrics", cfg.Features)
+	}
+}
+
+func TestLoadFileErrors(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    string
+	}{
+		{"unsupported extension", "forum.yaml", "server: {}", "unsupported format"},
+		{"unknown JSON field", "forum.json", `{"server": {"prot": 1}}`, "unknown field"},
+		{"unknown TOML field", "forum.toml", "[server]\nprot = 1\n", "unknown field"},
+		{"bad duration", "forum.json", `{"server": {"requestTimeout": 5}}`, "duration must be a string"},
+		{"malformed TOML", "forum.toml", "[server\n", "forum.toml"},
+		{"missing file", "missing.json", "", "config file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer clearEnv(t)()
+
+			path := filepath.Join(dir, tt.file)
+			if tt.content != "" {
+				path = writeFile(t, dir, tt.file, tt.content)
+			}
+
+			_, _, err := Load([]string{"-config", path})
+			if err == nil {
+				t.Fatal("Load() succeeded, want an error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Load() error = %q, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadBadEnvAndFlags(t *testing.T) {
+	defer clearEnv(t)()
+
+	os.Setenv("SERVER_PORT", "five")
+	if _, _, err := Load(nil); err == nil || !strings.Contains(err.Error(), "env SERVER_PORT") {
+		t.Errorf("Load() error = %v, want it to name SERVER_PORT", err)
+	}
+	os.Unsetenv("SERVER_PORT")
+
+	if _, _, err := Load([]string{"-features.metrics", "maybe"}); err == nil || !strings.Contains(err.Error(), "flag -features.metrics") {
+		t.Errorf("Load() error = %v, want it to name -features.metrics", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(cfg *Config)
+		want   []string
+	}{
+		{"defaults", func(cfg *Config) {}, nil},
+		{"bad ports", func(cfg *Config) {
+			cfg.Server.Port = 0
+			cfg.Database.Port = 70000
+		}, []string{"server.port: must be between 1 and 65535", "database.port: must be between 1 and 65535"}},
+		{"non-positive timeouts", func(cfg *Config) {
+			cfg.Server.RequestTimeout = 0
+			cfg.Server.RouteTimeouts["/api/user/:nickname/profile"] = Duration(-time.Second)
+		}, []string{"server.requestTimeout: must be positive", "server.routeTimeouts[/api/user/:nickname/profile]: must be positive"}},
+		{"single connection", func(cfg *Config) {
+			cfg.Database.MaxConnections = 1
+		}, []string{"database.maxConnections: must be at least 2"}},
+		{"negative acquire timeout", func(cfg *Config) {
+			cfg.Database.AcquireTimeout = Duration(-time.Second)
+		}, []string{"database.acquireTimeout: must not be negative"}},
+		{"bad log level", func(cfg *Config) {
+			cfg.Log.Level = "verbose"
+		}, []string{"log.level: must be one of debug, info, warn, error"}},
+		{"bad storage", func(cfg *Config) {
+			cfg.Features.Storage = "sqlite"
+		}, []string{"features.storage: must be postgres or memory"}},
+		{"memory storage skips database connection settings", func(cfg *Config) {
+			cfg.Features.Storage = MemoryStorage
+			cfg.Database.Host = ""
+			cfg.Database.Name = ""
+		}, nil},
+		{"postgres storage needs database connection settings", func(cfg *Config) {
+			cfg.Database.Host = ""
+			cfg.Database.User = ""
+		}, []string{"database.host: must not be empty", "database.user: must not be empty"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			tt.modify(cfg)
+
+			err := cfg.Validate()
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+
+			verr, ok := err.(*ValidationError)
+			if !ok {
+				t.Fatalf("Validate() = %v, want a *ValidationError", err)
+			}
+			if !reflect.DeepEqual(verr.Problems, tt.want) {
+				t.Errorf("Validate() problems = %q, want %q", verr.Problems, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateRoutes(t *testing.T) {
+	routes := []string{"/api/service/status", "/api/user/:nickname/profile"}
+
+	cfg := Default()
+	cfg.Server.RouteTimeouts["/api/service/status"] = Duration(time.Second)
+	if err := cfg.ValidateRoutes(routes); err != nil {
+		t.Errorf("ValidateRoutes() = %v, want nil", err)
+	}
+
+	cfg.Server.RouteTimeouts["/api/users/:nickname"] = Duration(time.Second)
+	cfg.Server.RouteTimeouts["/api/forum"] = Duration(time.Second)
+
+	err := cfg.ValidateRoutes(routes)
+	verr, ok := err.(*ValidationError)
+	if !ok {
+		t.Fatalf("ValidateRoutes() = %v, want a *ValidationError", err)
+	}
+	want := []string{
+		"server.routeTimeouts[/api/forum]: unknown route",
+		"server.routeTimeouts[/api/users/:nickname]: unknown route",
+	}
+	if !reflect.DeepEqual(verr.Problems, want) {
+		t.Errorf("ValidateRoutes() problems = %q, want %q", verr.Problems, want)
+	}
+}
+
+func TestPrintMasksPassword(t *testing.T) {
+	cfg := Default()
+	cfg.Database.Password = "hunter2"
+
+	var b strings.Builder
+	if err := cfg.Print(&b); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(b.String(), "hunter2") || !strings.Contains(b.String(), `"password": "******"`) {
+		t.Errorf("Print() = %s, want the password masked", b.String())
+	}
+	if cfg.Database.Password != "hunter2" {
+		t.Error("Print() modified the configuration")
+	}
+}
@@ -0,0 +1,149 @@
+package config
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+type Duration time.Duration
+
+func (d Duration) Duration() time.Duration {
+	return time.Duration(d)
+}
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+func (d *Duration) Set(s string) error {
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"10s\": %v", err)
+	}
+	return d.Set(s)
+}
+
+type stringValue string
+
+func (v *stringValue) String() string {
+	return string(*v)
+}
+
+func (v *stringValue) Set(s string) error {
+	*v = stringValue(s)
+	return nil
+}
+
+type intValue int
+
+func (v *intValue) String() string {
+	return strconv.Itoa(int(*v))
+}
+
+func (v *intValue) Set(s string) error {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return fmt.Errorf("%q is not an integer", s)
+	}
+	*v = intValue(n)
+	return nil
+}
+
+type boolValue bool
+
+func (v *boolValue) String() string {
+	return strconv.FormatBool(bool(*v))
+}
+
+func (v *boolValue) Set(s string) error {
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return fmt.Errorf("%q is not a boolean", s)
+	}
+	*v = boolValue(b)
+	return nil
+}
+
+type flagValue struct {
+	value string
+	set   bool
+}
+
+func (v *flagValue) String() string {
+	return v.value
+}
+
+func (v *flagValue) Set(s string) error {
+	v.value = s
+	v.set = true
+	return nil
+}
+
+type option struct {
+	name  string
+	env   string
+	usage string
+	value func(cfg *Config) flag.Value
+}
+
+var (
+	options = []option{
+		{"server.host", "SERVER_HOST", "address to listen on",
+			func(cfg *Config) flag.Value { return (*stringValue)(&cfg.Server.Host) }},
+		{"server.port", "SERVER_PORT", "port to listen on",
+			func(cfg *Config) flag.Value { return (*intValue)(&cfg.Server.Port) }},
+		{"server.shutdownTimeout", "SHUTDOWN_TIMEOUT", "time to drain in-flight requests on shutdown",
+			func(cfg *Config) flag.Value { return &cfg.Server.ShutdownTimeout }},
+		{"server.slowRequestThreshold", "SLOW_REQUEST_THRESHOLD", "latency above which a request is logged as slow",
+			func(cfg *Config) flag.Value { return &cfg.Server.SlowRequestThreshold }},
+		{"server.requestTimeout", "REQUEST_TIMEOUT", "deadline for routes without an entry in server.routeTimeouts",
+			func(cfg *Config) flag.Value { return &cfg.Server.RequestTimeout }},
+
+		{"database.host", "PGHOST", "database host",
+			func(cfg *Config) flag.Value { return (*stringValue)(&cfg.Database.Host) }},
+		{"database.port", "PGPORT", "database port",
+			func(cfg *Config) flag.Value { return (*intValue)(&cfg.Database.Port) }},
+		{"database.name", "PGDATABASE", "database name",
+			func(cfg *Config) flag.Value { return (*stringValue)(&cfg.Database.Name) }},
+		{"database.user", "PGUSER", "database user",
+			func(cfg *Config) flag.Value { return (*stringValue)(&cfg.Database.User) }},
+		{"database.password", "PGPASSWORD", "database password",
+			func(cfg *Config) flag.Value { return (*stringValue)(&cfg.Database.Password) }},
+		{"database.maxConnections", "DB_MAX_CONNECTIONS", "connection pool size",
+			func(cfg *Config) flag.Value { return (*intValue)(&cfg.Database.MaxConnections) }},
+		{"database.acquireTimeout", "DB_ACQUIRE_TIMEOUT", "time to wait for a pool connection, 0 waits forever",
+			func(cfg *Config) flag.Value { return &cfg.Database.AcquireTimeout }},
+		{"database.slowQueryThreshold", "SLOW_QUERY_THRESHOLD", "duration above which a query is logged as slow",
+			func(cfg *Config) flag.Value { return &cfg.Database.SlowQueryThreshold }},
+
+		{"log.level", "LOG_LEVEL", "log level: debug, info, warn or error",
+			func(cfg *Config) flag.Value { return (*stringValue)(&cfg.Log.Level) }},
+		{"log.output", "LOG_OUTPUT", "log sink: stdout, stderr or a file path",
+			func(cfg *Config) flag.Value { return (*stringValue)(&cfg.Log.Output) }},
+
+		{"features.storage", "STORAGE", "storage backend: postgres or memory",
+			func(cfg *Config) flag.Value { return (*stringValue)(&cfg.Features.Storage) }},
+		{"features.autoMigrate", "AUTO_MIGRATE", "apply pending migrations on startup",
+			func(cfg *Config) flag.Value { return (*boolValue)(&cfg.Features.AutoMigrate) }},
+		{"features.metrics", "METRICS_ENABLED", "serve Prometheus metrics at /metrics",
+			func(cfg *Config) flag.Value { return (*boolValue)(&cfg.Features.Metrics) }},
+		{"features.accessLog", "ACCESS_LOG_ENABLED", "log every request",
+			func(cfg *Config) flag.Value { return (*boolValue)(&cfg.Features.AccessLog) }},
+	}
+)
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"sort"
+	"strings"
+	"tp-project-db/consts"
+	"tp-project-db/logging"
+)
+
+type ValidationError struct {
+	Problems []string
+}
+
+func (err *ValidationError) Error() string {
+	return "invalid configuration:\n  " + strings.Join(err.Problems, "\n  ")
+}
+
+func (cfg *Config) Validate() error {
+	err := &ValidationError{}
+	check := func(ok bool, problem string) {
+		if !ok {
+			err.Problems = append(err.Problems, problem)
+		}
+	}
+
+	check(validPort(cfg.Server.Port), "server.port: must be between 1 and 65535")
+	check(cfg.Server.ShutdownTimeout > 0, "server.shutdownTimeout: must be positive")
+	check(cfg.Server.SlowRequestThreshold > 0, "server.slowRequestThreshold: must be positive")
+	check(cfg.Server.RequestTimeout > 0, "server.requestTimeout: must be positive")
+	for route, timeout := range cfg.Server.RouteTimeouts {
+		check(timeout > 0, "server.routeTimeouts["+route+"]: must be positive")
+	}
+
+	if cfg.Features.Storage == PostgresStorage {
+		check(cfg.Database.Host != consts.EmptyString, "database.host: must not be empty")
+		check(validPort(cfg.Database.Port), "database.port: must be between 1 and 65535")
+		check(cfg.Database.Name != consts.EmptyString, "database.name: must not be empty")
+		check(cfg.Database.User != consts.EmptyString, "database.user: must not be empty")
+	}
+	check(cfg.Database.MaxConnections >= 2, "database.maxConnections: must be at least 2")
+	check(cfg.Database.AcquireTimeout >= 0, "database.acquireTimeout: must not be negative")
+	check(cfg.Database.SlowQueryThreshold > 0, "database.slowQueryThreshold: must be positive")
+
+	_, levelErr := logging.ParseLevel(cfg.Log.Level)
+	check(levelErr == nil, "log.level: must be one of debug, info, warn, error")
+	check(cfg.Log.Output != consts.EmptyString, "log.output: must be stdout, stderr or a file path")
+
+	check(cfg.Features.Storage == PostgresStorage || cfg.Features.Storage == MemoryStorage,
+		"features.storage: must be "+PostgresStorage+" or "+MemoryStorage)
+
+	if len(err.Problems) > 0 {
+		return err
+	}
+	return nil
+}
+
+// ValidateRoutes checks the server.routeTimeouts keys against the routes the
+// server actually registers, which are only known once it is built.
+func (cfg *Config) ValidateRoutes(routes []string) error {
+	known := make(map[string]bool, len(routes))
+	for _, route := range routes {
+		known[route] = true
+	}
+
+	err := &ValidationError{}
+	for route := range cfg.Server.RouteTimeouts {
+		if !known[route] {
+			err.Problems = append(err.Problems, "server.routeTimeouts["+route+"]: unknown route")
+		}
+	}
+
+	if len(err.Problems) > 0 {
+		sort.Strings(err.Problems)
+		return err
+	}
+	return nil
+}
+
+func validPort(port int) bool {
+	return port > 0 && port <= 65535
+}
@@ -0,0 +1,73 @@
+package diff
+
+import (
+	"strings"
+)
+
+const (
+	OpEqual  = "equal"
+	OpInsert = "insert"
+	OpDelete = "delete"
+)
+
+type Change struct {
+	Op   string
+	Text string
+}
+
+// Lines returns the line edits turning a into b, based on their longest
+// common subsequence of lines.
+func Lines(a, b string) []Change {
+	return compute(split(a), split(b))
+}
+
+func split(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+func compute(a, b []string) []Change {
+	n, m := len(a), len(b)
+
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	changes := make([]Change, 0, n+m)
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case a[i] == b[j]:
+			changes = append(changes, Change{OpEqual, a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			changes = append(changes, Change{OpDelete, a[i]})
+			i++
+		default:
+			changes = append(changes, Change{OpInsert, b[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		changes = append(changes, Change{OpDelete, a[i]})
+	}
+	for ; j < m; j++ {
+		changes = append(changes, Change{OpInsert, b[j]})
+	}
+	return changes
+}
@@ -0,0 +1,59 @@
+package diff
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want []Change
+	}{
+		{"both empty", "", "", []Change{}},
+		{"insert all", "", "x\ny", []Change{{OpInsert, "x"}, {OpInsert, "y"}}},
+		{"delete all", "x\ny\n", "", []Change{{OpDelete, "x"}, {OpDelete, "y"}}},
+		{"equal", "x\ny", "x\ny\n", []Change{{OpEqual, "x"}, {OpEqual, "y"}}},
+		{
+			"replace middle line", "a\nb\nc", "a\nB\nc",
+			[]Change{{OpEqual, "a"}, {OpDelete, "b"}, {OpInsert, "B"}, {OpEqual, "c"}},
+		},
+		{
+			"append and drop", "a\nb", "b\nc",
+			[]Change{{OpDelete, "a"}, {OpEqual, "b"}, {OpInsert, "c"}},
+		},
+	}
+
+	for _, tt := range tests {
+		got := Lines(tt.a, tt.b)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Lines() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLinesRoundTrip(t *testing.T) {
+	a := "one\ntwo\nthree\nfour\nfive"
+	b := "zero\none\nthree\nfour!\nfive\nsix"
+
+	var gotA, gotB []string
+	for _, c := range Lines(a, b) {
+		switch c.Op {
+		case OpEqual:
+			gotA = append(gotA, c.Text)
+			gotB = append(gotB, c.Text)
+		case OpDelete:
+			gotA = append(gotA, c.Text)
+		case OpInsert:
+			gotB = append(gotB, c.Text)
+		}
+	}
+
+	if !reflect.DeepEqual(gotA, split(a)) {
+		t.Errorf("old side = %v, want %v", gotA, split(a))
+	}
+	if !reflect.DeepEqual(gotB, split(b)) {
+		t.Errorf("new side = %v, want %v", gotB, split(b))
+	}
+}
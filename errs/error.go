@@ -4,15 +4,42 @@ import "net/http"
 
 //go:generate easyjson
 
+type Category string
+
+const (
+	CategoryInternal      Category = "internal"
+	CategoryNotFound      Category = "not_found"
+	CategoryForbidden     Category = "forbidden"
+	CategoryConflict      Category = "conflict"
+	CategoryInvalidFormat Category = "invalid_format"
+	CategoryUnavailable   Category = "unavailable"
+	CategoryTimeout       Category = "timeout"
+)
+
+//easyjson:json
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
 //easyjson:json
 type Error struct {
-	HttpStatus int    `json:"-"`
-	Message    string `json:"message"`
+	HttpStatus int          `json:"-"`
+	Category   Category     `json:"-"`
+	Code       string       `json:"code"`
+	Message    string       `json:"message"`
+	Entity     string       `json:"entity,omitempty"`
+	Field      string       `json:"field,omitempty"`
+	Reason     string       `json:"reason,omitempty"`
+	Details    []FieldError `json:"details,omitempty"`
 }
 
 func NewError(status int, message string) *Error {
+	category := categoryByStatus(status)
 	return &Error{
 		HttpStatus: status,
+		Category:   category,
+		Code:       string(category),
 		Message:    message,
 	}
 }
@@ -29,10 +56,66 @@ func NewInvalidFormatError(message string) *Error {
 	return NewError(http.StatusUnprocessableEntity, message)
 }
 
+func NewBadRequestError(message string) *Error {
+	return NewError(http.StatusBadRequest, message)
+}
+
+func NewForbiddenError(message string) *Error {
+	return NewError(http.StatusForbidden, message)
+}
+
 func NewConflictError(message string) *Error {
 	return NewError(http.StatusConflict, message)
 }
 
+func NewUnavailableError(message string) *Error {
+	return NewError(http.StatusServiceUnavailable, message)
+}
+
+func NewTimeoutError(message string) *Error {
+	return NewError(http.StatusGatewayTimeout, message)
+}
+
+func (err *Error) WithCode(code string) *Error {
+	err.Code = code
+	return err
+}
+
+func (err *Error) WithEntity(entity, field string) *Error {
+	err.Entity = entity
+	err.Field = field
+	return err
+}
+
+func (err *Error) WithReason(reason string) *Error {
+	err.Reason = reason
+	return err
+}
+
+func (err *Error) WithDetails(details []FieldError) *Error {
+	err.Details = details
+	return err
+}
+
 func (err Error) Error() string {
 	return err.Message
 }
+
+func categoryByStatus(status int) Category {
+	switch status {
+	case http.StatusNotFound:
+		return CategoryNotFound
+	case http.StatusForbidden:
+		return CategoryForbidden
+	case http.StatusConflict:
+		return CategoryConflict
+	case http.StatusUnprocessableEntity, http.StatusBadRequest:
+		return CategoryInvalidFormat
+	case http.StatusServiceUnavailable:
+		return CategoryUnavailable
+	case http.StatusGatewayTimeout:
+		return CategoryTimeout
+	default:
+		return CategoryInternal
+	}
+}
@@ -0,0 +1,71 @@
+package errs
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestNewErrorCategory(t *testing.T) {
+	tests := []struct {
+		err      *Error
+		status   int
+		category Category
+	}{
+		{NewInternalError("m"), http.StatusInternalServerError, CategoryInternal},
+		{NewNotFoundError("m"), http.StatusNotFound, CategoryNotFound},
+		{NewInvalidFormatError("m"), http.StatusUnprocessableEntity, CategoryInvalidFormat},
+		{NewBadRequestError("m"), http.StatusBadRequest, CategoryInvalidFormat},
+		{NewForbiddenError("m"), http.StatusForbidden, CategoryForbidden},
+		{NewConflictError("m"), http.StatusConflict, CategoryConflict},
+		{NewUnavailableError("m"), http.StatusServiceUnavailable, CategoryUnavailable},
+		{NewTimeoutError("m"), http.StatusGatewayTimeout, CategoryTimeout},
+		{NewError(http.StatusTeapot, "m"), http.StatusTeapot, CategoryInternal},
+	}
+
+	for _, tt := range tests {
+		if tt.err.HttpStatus != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.category, tt.err.HttpStatus, tt.status)
+		}
+		if tt.err.Category != tt.category {
+			t.Errorf("status %d: category = %s, want %s", tt.status, tt.err.Category, tt.category)
+		}
+		if tt.err.Code != string(tt.category) {
+			t.Errorf("status %d: default code = %s, want %s", tt.status, tt.err.Code, tt.category)
+		}
+	}
+}
+
+func TestErrorBody(t *testing.T) {
+	err := NewNotFoundError("post not found").
+		WithCode("post_not_found").
+		WithEntity("post", "id").
+		WithReason("gone")
+
+	b, marshalErr := err.MarshalJSON()
+	if marshalErr != nil {
+		t.Fatal(marshalErr)
+	}
+
+	want := `{"code":"post_not_found","message":"post not found","entity":"post","field":"id","reason":"gone"}`
+	if string(b) != want {
+		t.Errorf("body = %s, want %s", b, want)
+	}
+	if err.Error() != "post not found" {
+		t.Errorf("Error() = %q", err.Error())
+	}
+}
+
+func TestErrorBodyDetails(t *testing.T) {
+	err := NewInvalidFormatError("validation failed").
+		WithDetails([]FieldError{{Field: "email", Message: "must be a valid email"}})
+
+	b, marshalErr := err.MarshalJSON()
+	if marshalErr != nil {
+		t.Fatal(marshalErr)
+	}
+
+	want := `{"code":"invalid_format","message":"validation failed","details":[{"field":"email","message":"must be a valid email"}]}`
+	if string(b) != want {
+		t.Errorf("body = %s, want %s", b, want)
+	}
+}
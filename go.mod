@@ -1,6 +1,8 @@
 module tp-project-db
 
 require (
+	github.com/BurntSushi/toml v0.3.1
+	github.com/asaskevich/govalidator v0.0.0-20180720115003-f9ffefc3facf
 	github.com/fasthttp/router v0.2.0
 	github.com/go-openapi/strfmt v0.18.0
 	github.com/jackc/pgx v3.3.0+incompatible
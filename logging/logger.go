@@ -0,0 +1,133 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var (
+	levelNames = map[Level]string{
+		LevelDebug: "debug",
+		LevelInfo:  "info",
+		LevelWarn:  "warn",
+		LevelError: "error",
+	}
+)
+
+func (l Level) String() string {
+	return levelNames[l]
+}
+
+func ParseLevel(s string) (Level, error) {
+	for level, name := range levelNames {
+		if strings.EqualFold(s, name) {
+			return level, nil
+		}
+	}
+	return LevelInfo, fmt.Errorf("unknown log level %q", s)
+}
+
+const (
+	StdoutOutput = "stdout"
+	StderrOutput = "stderr"
+)
+
+func OpenOutput(output string) (io.Writer, error) {
+	switch output {
+	case StdoutOutput:
+		return os.Stdout, nil
+	case StderrOutput:
+		return os.Stderr, nil
+	}
+	return os.OpenFile(output, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+}
+
+type Fields map[string]interface{}
+
+type Logger struct {
+	mtx   *sync.Mutex
+	w     io.Writer
+	level Level
+}
+
+var (
+	Default = New(os.Stderr, LevelInfo)
+)
+
+func New(w io.Writer, level Level) *Logger {
+	return &Logger{
+		mtx:   &sync.Mutex{},
+		w:     w,
+		level: level,
+	}
+}
+
+func (l *Logger) Configure(w io.Writer, level Level) {
+	l.mtx.Lock()
+	l.w = w
+	l.level = level
+	l.mtx.Unlock()
+}
+
+func (l *Logger) Enabled(level Level) bool {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return level >= l.level
+}
+
+func (l *Logger) Log(level Level, msg string, fields Fields) {
+	if !l.Enabled(level) {
+		return
+	}
+
+	entry := make(Fields, len(fields)+3)
+	for k, v := range fields {
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+		entry[k] = v
+	}
+	entry["time"] = time.Now().UTC().Format(time.RFC3339Nano)
+	entry["level"] = level.String()
+	entry["msg"] = msg
+
+	b, err := json.Marshal(entry)
+	if err != nil {
+		b, _ = json.Marshal(Fields{"level": LevelError.String(), "msg": "log entry not encoded", "error": err.Error()})
+	}
+	b = append(b, '\n')
+
+	l.mtx.Lock()
+	_, _ = l.w.Write(b)
+	l.mtx.Unlock()
+}
+
+func (l *Logger) Debug(msg string, fields Fields) {
+	l.Log(LevelDebug, msg, fields)
+}
+
+func (l *Logger) Info(msg string, fields Fields) {
+	l.Log(LevelInfo, msg, fields)
+}
+
+func (l *Logger) Warn(msg string, fields Fields) {
+	l.Log(LevelWarn, msg, fields)
+}
+
+func (l *Logger) Error(msg string, fields Fields) {
+	l.Log(LevelError, msg, fields)
+}
@@ -0,0 +1,97 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Level
+	}{
+		{"debug", LevelDebug},
+		{"INFO", LevelInfo},
+		{"Warn", LevelWarn},
+		{"error", LevelError},
+	}
+	for _, tt := range tests {
+		got, err := ParseLevel(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseLevel(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("ParseLevel(verbose) succeeded, want an error")
+	}
+}
+
+func TestLoggerEntry(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, LevelInfo)
+
+	l.Warn("slow request", Fields{"path": "/api/forum/create", "error": errors.New("boom")})
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("entry %q is not JSON: %v", buf.String(), err)
+	}
+	if buf.Bytes()[buf.Len()-1] != '\n' {
+		t.Error("entry is not newline-terminated")
+	}
+
+	want := map[string]string{"level": "warn", "msg": "slow request", "path": "/api/forum/create", "error": "boom"}
+	for k, v := range want {
+		if entry[k] != v {
+			t.Errorf("entry[%q] = %v, want %q", k, entry[k], v)
+		}
+	}
+	ts, _ := entry["time"].(string)
+	if _, err := time.Parse(time.RFC3339Nano, ts); err != nil {
+		t.Errorf("entry time %q is not RFC 3339: %v", ts, err)
+	}
+}
+
+func TestLoggerLevel(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, LevelWarn)
+
+	l.Debug("debug", nil)
+	l.Info("info", nil)
+	if buf.Len() != 0 {
+		t.Errorf("entries below the level were written: %q", buf.String())
+	}
+
+	l.Error("error", nil)
+	if buf.Len() == 0 {
+		t.Error("error entry was not written")
+	}
+
+	buf.Reset()
+	l.Configure(&buf, LevelDebug)
+	l.Debug("debug", nil)
+	if buf.Len() == 0 {
+		t.Error("Configure() did not lower the level")
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	if id := RequestID(context.Background()); id != "" {
+		t.Errorf("RequestID() = %q without an ID, want empty", id)
+	}
+
+	ctx := WithRequestID(context.Background(), "abc")
+	if id := RequestID(ctx); id != "abc" {
+		t.Errorf("RequestID() = %q, want abc", id)
+	}
+
+	a, b := NewRequestID(), NewRequestID()
+	if len(a) != 32 || a == b {
+		t.Errorf("NewRequestID() = %q, %q, want distinct 32-character IDs", a, b)
+	}
+}
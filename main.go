@@ -1,71 +1,257 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
 	"os"
 	"os/signal"
 	"runtime/debug"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
 	"tp-project-db/config"
+	"tp-project-db/logging"
 	"tp-project-db/repositories"
+	"tp-project-db/repositories/memory"
 	"tp-project-db/services"
 )
 
 func main() {
-	config.Load()
+	cfg, args, err := config.Load(os.Args[1:])
+	if err == flag.ErrHelp {
+		return
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	if len(args) > 0 && args[0] == "config" {
+		handleErr(configCommand(cfg, args[1:]))
+		return
+	}
+
+	handleErr(configureLogging(cfg.Log))
+
+	if cfg.Features.Storage == config.MemoryStorage {
+		run(cfg, newMemoryComponents(), nil)
+		return
+	}
 
-	conn := repositories.NewConnection()
+	conn := repositories.NewConnection(repositories.ConnectionConfig{
+		Host:               cfg.Database.Host,
+		Port:               uint16(cfg.Database.Port),
+		Database:           cfg.Database.Name,
+		User:               cfg.Database.User,
+		Password:           cfg.Database.Password,
+		MaxConnections:     cfg.Database.MaxConnections,
+		AcquireTimeout:     cfg.Database.AcquireTimeout.Duration(),
+		SlowQueryThreshold: cfg.Database.SlowQueryThreshold.Duration(),
+	})
 	handleErr(conn.Open())
 	defer func() {
 		handleErr(conn.Close())
 	}()
-	handleErr(conn.Init())
 
-	userRepository := repositories.NewUserRepository(conn)
-	handleErr(userRepository.Init())
+	migrator := repositories.NewMigrator(conn)
+	if len(args) > 0 && args[0] == "migrate" {
+		handleErr(migrate(migrator, args[1:]))
+		return
+	}
 
-	forumRepository := repositories.NewForumRepository(conn)
-	handleErr(forumRepository.Init())
+	components, initStorage := newPostgresComponents(conn, migrator, cfg.Features.AutoMigrate)
+	run(cfg, components, initStorage)
+}
 
-	threadRepository := repositories.NewThreadRepository(conn)
-	handleErr(threadRepository.Init())
+func configCommand(cfg *config.Config, args []string) error {
+	if len(args) == 0 || args[0] != "print" {
+		return fmt.Errorf("unknown config command: %s (expected print)", strings.Join(args, " "))
+	}
+	return cfg.Print(os.Stdout)
+}
 
-	postRepository := repositories.NewPostRepository(conn)
-	handleErr(postRepository.Init())
+func configureLogging(cfg config.LogConfig) error {
+	level, err := logging.ParseLevel(cfg.Level)
+	if err != nil {
+		return err
+	}
 
-	voteRepository := repositories.NewVoteRepository(conn)
-	handleErr(voteRepository.Init())
+	w, err := logging.OpenOutput(cfg.Output)
+	if err != nil {
+		return err
+	}
 
+	logging.Default.Configure(w, level)
+	log.SetOutput(w)
+	return nil
+}
+
+func newPostgresComponents(conn *repositories.Connection, migrator *repositories.Migrator, autoMigrate bool) (services.ServerComponents, func() error) {
+	userRepository := repositories.NewUserRepository(conn)
+	forumRepository := repositories.NewForumRepository(conn)
+	threadRepository := repositories.NewThreadRepository(conn)
+	postRepository := repositories.NewPostRepository(conn)
+	searchRepository := repositories.NewSearchRepository(conn)
+	voteRepository := repositories.NewVoteRepository(conn)
 	statusRepository := repositories.NewStatusRepository(conn)
-	handleErr(statusRepository.Init())
+	healthRepository := repositories.NewHealthRepository(conn, migrator)
 
+	initStorage := func() error {
+		if autoMigrate {
+			if err := migrator.Up(); err != nil {
+				return err
+			}
+		}
+
+		inits := []func() error{
+			userRepository.Init,
+			forumRepository.Init,
+			threadRepository.Init,
+			postRepository.Init,
+			voteRepository.Init,
+			statusRepository.Init,
+		}
+		for _, init := range inits {
+			if err := init(); err != nil {
+				return err
+			}
+		}
+
+		healthRepository.MarkPrepared()
+		return nil
+	}
+
+	return services.ServerComponents{
+		UserRepository:   userRepository,
+		ForumRepository:  forumRepository,
+		ThreadRepository: threadRepository,
+		PostRepository:   postRepository,
+		SearchRepository: searchRepository,
+		VoteRepository:   voteRepository,
+		StatusRepository: statusRepository,
+		HealthRepository: healthRepository,
+	}, initStorage
+}
+
+func newMemoryComponents() services.ServerComponents {
+	storage := memory.NewStorage()
+
+	return services.ServerComponents{
+		UserRepository:   memory.NewUserRepository(storage),
+		ForumRepository:  memory.NewForumRepository(storage),
+		ThreadRepository: memory.NewThreadRepository(storage),
+		PostRepository:   memory.NewPostRepository(storage),
+		SearchRepository: memory.NewSearchRepository(storage),
+		VoteRepository:   memory.NewVoteRepository(storage),
+		StatusRepository: memory.NewStatusRepository(storage),
+		HealthRepository: memory.NewHealthRepository(storage),
+	}
+}
+
+func run(cfg *config.Config, components services.ServerComponents, initStorage func() error) {
 	srv := services.NewServer(
 		services.ServerConfig{
-			Host: os.Getenv("SERVER_HOST"),
-			Port: os.Getenv("SERVER_PORT"),
-		},
-		services.ServerComponents{
-			UserRepository:   userRepository,
-			ForumRepository:  forumRepository,
-			ThreadRepository: threadRepository,
-			PostRepository:   postRepository,
-			VoteRepository:   voteRepository,
-			StatusRepository: statusRepository,
+			Host:                 cfg.Server.Host,
+			Port:                 strconv.Itoa(cfg.Server.Port),
+			ShutdownTimeout:      cfg.Server.ShutdownTimeout.Duration(),
+			SlowRequestThreshold: cfg.Server.SlowRequestThreshold.Duration(),
+			RequestTimeout:       cfg.Server.RequestTimeout.Duration(),
+			RouteTimeouts:        routeTimeouts(cfg.Server.RouteTimeouts),
+			EnableMetrics:        cfg.Features.Metrics,
+			EnableAccessLog:      cfg.Features.AccessLog,
 		},
+		components,
 	)
+	if err := cfg.ValidateRoutes(srv.Routes()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
 
-	ch := make(chan os.Signal)
-	signal.Notify(ch, os.Interrupt)
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 
+	errCh := make(chan error, 2)
 	go func() {
-		handleErr(srv.Run())
-		ch <- os.Kill
+		errCh <- srv.Run()
 	}()
+	if initStorage != nil {
+		go func() {
+			if err := initStorage(); err != nil {
+				errCh <- err
+				return
+			}
+			srv.MarkReady()
+			log.Println("storage initialized")
+		}()
+	} else {
+		srv.MarkReady()
+	}
 
 	log.Println("server started...")
-	<-ch
+	select {
+	case sig := <-sigCh:
+		log.Println("shutting down:", sig)
+	case err := <-errCh:
+		handleErr(err)
+		return
+	}
+
+	if err := srv.Shutdown(); err != nil {
+		log.Println("shutdown:", err)
+	}
+	log.Println("server stopped")
+}
+
+func routeTimeouts(timeouts map[string]config.Duration) map[string]time.Duration {
+	m := make(map[string]time.Duration, len(timeouts))
+	for route, timeout := range timeouts {
+		m[route] = timeout.Duration()
+	}
+	return m
+}
+
+func migrate(migrator *repositories.Migrator, args []string) error {
+	cmd := "up"
+	if len(args) > 0 {
+		cmd = args[0]
+	}
+
+	switch cmd {
+	case "up":
+		return migrator.Up()
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid number of steps: %s", args[1])
+			}
+			steps = n
+		}
+		return migrator.Down(steps)
+
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, st := range statuses {
+			applied := "pending"
+			if st.Applied {
+				applied = st.AppliedTimestamp.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", st.Version, st.Name, applied)
+		}
+		return w.Flush()
+	}
 
-	handleErr(srv.Shutdown())
+	return fmt.Errorf("unknown migrate command: %s (expected up|down|status)", cmd)
 }
 
 func handleErr(err error) {
@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRegistryWrite(t *testing.T) {
+	r := NewRegistry()
+
+	requests := r.NewCounterVec("http_requests_total", "Requests served.", "route", "status")
+	requests.Inc("/api/forum/:slug/details", "200")
+	requests.Inc("/api/forum/:slug/details", "200")
+	requests.Add(3, "/api/forum/create", "409")
+
+	latency := r.NewHistogramVec("http_request_duration_seconds", "Request latency.", []float64{.1, 1}, "route")
+	latency.Observe(.05, "/api/forum/create")
+	latency.Observe(.1, "/api/forum/create")
+	latency.Observe(2, "/api/forum/create")
+
+	r.NewGaugeFunc("db_pool_waiting", "Goroutines waiting for a connection.", func() float64 { return 4 })
+
+	want := `# HELP http_requests_total Requests served.
+# TYPE http_requests_total counter
+http_requests_total{route="/api/forum/:slug/details",status="200"} 2
+http_requests_total{route="/api/forum/create",status="409"} 3
+# HELP http_request_duration_seconds Request latency.
+# TYPE http_request_duration_seconds histogram
+http_request_duration_seconds_bucket{route="/api/forum/create",le="0.1"} 2
+http_request_duration_seconds_bucket{route="/api/forum/create",le="1"} 2
+http_request_duration_seconds_bucket{route="/api/forum/create",le="+Inf"} 3
+http_request_duration_seconds_sum{route="/api/forum/create"} 2.15
+http_request_duration_seconds_count{route="/api/forum/create"} 3
+# HELP db_pool_waiting Goroutines waiting for a connection.
+# TYPE db_pool_waiting gauge
+db_pool_waiting 4
+`
+
+	var buf bytes.Buffer
+	if err := r.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != want {
+		t.Errorf("Write() =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestEscapeLabelValue(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"plain", "plain"},
+		{`a"b`, `a\"b`},
+		{`a\b`, `a\\b`},
+		{"a\nb", `a\nb`},
+	}
+	for _, tt := range tests {
+		if got := escapeLabelValue(tt.in); got != tt.want {
+			t.Errorf("escapeLabelValue(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
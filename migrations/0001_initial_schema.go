@@ -0,0 +1,425 @@
+package migrations
+
+const (
+	InitialSchemaUp = `
+        CREATE EXTENSION IF NOT EXISTS "citext";
+
+        DO $$ BEGIN
+            IF NOT EXISTS (SELECT * FROM "pg_type" WHERE "typname" = 'query_result') THEN
+                CREATE TYPE "query_result" AS ("status" INTEGER, "result" JSON);
+            END IF;
+        END$$;
+
+        CREATE OR REPLACE FUNCTION replace_if_empty(_value_ TEXT, _default_ TEXT)
+        RETURNS TEXT
+        AS $$
+            SELECT CASE
+                WHEN _value_ = '' THEN _default_
+                ELSE _value_
+            END;
+        $$ LANGUAGE SQL;
+
+        CREATE TABLE IF NOT EXISTS "user" (
+            "nickname" CITEXT COLLATE "ucs_basic"
+                CONSTRAINT "user_nickname_pk" PRIMARY KEY,
+            "email" CITEXT COLLATE "ucs_basic"
+                CONSTRAINT "user_email_not_null" NOT NULL,
+            "fullname" TEXT
+                CONSTRAINT "user_fullname_not_null" NOT NULL,
+            "about" TEXT
+                CONSTRAINT "user_about_not_null" NOT NULL
+        );
+
+        CREATE UNIQUE INDEX IF NOT EXISTS "user_email_idx" ON "user"("email");
+
+        CREATE OR REPLACE FUNCTION insert_user(
+             _nickname_ CITEXT, _email_ CITEXT, _full_name_ TEXT, _about_ TEXT
+        )
+        RETURNS "query_result"
+        AS $$
+        DECLARE _existing_ JSON;
+        BEGIN
+            SELECT json_agg(json_build_object(
+                'nickname', u."nickname",
+                'email', u."email",
+                'fullname', u."fullname",
+                'about', u."about"
+            ))
+            FROM (
+                SELECT u.*
+                FROM "user" u
+                WHERE u."nickname" = _nickname_
+                UNION
+                SELECT u.*
+                FROM "user" u
+                WHERE u."email" = _email_
+            ) u
+            INTO _existing_;
+
+            IF _existing_ IS NOT NULL THEN
+                RETURN (409, _existing_);
+            END IF;
+
+            INSERT INTO "user"("nickname","email","fullname","about")
+            VALUES(_nickname_,_email_,_full_name_,_about_)
+            RETURNING json_build_object(
+                'nickname', "nickname", 'email', "email",
+                'fullname', "fullname", 'about', "about"
+            ) INTO _existing_;
+
+            RETURN (201, _existing_);
+        END;
+        $$ LANGUAGE PLPGSQL;
+
+        CREATE OR REPLACE FUNCTION update_user(
+             _nickname_ CITEXT, _email_ CITEXT, _full_name_ TEXT, _about_ TEXT
+        )
+        RETURNS "query_result"
+        AS $$
+        DECLARE _existing_ JSON;
+        BEGIN
+            SELECT json_build_object(
+                'nickname', u."nickname",
+                'email', u."email",
+                'fullname', u."fullname",
+                'about', u."about"
+            )
+            FROM (
+                SELECT u.*
+                FROM "user" u
+                WHERE u."email" = _email_
+            ) u
+            INTO _existing_;
+
+            IF _existing_ IS NOT NULL THEN
+                RETURN (409, _existing_);
+            END IF;
+
+            UPDATE "user" SET
+                "email" = CASE
+                              WHEN _email_ = '' THEN "email"
+                              ELSE _email_
+                          END,
+                "fullname" = replace_if_empty(_full_name_,"fullname"),
+                "about" = replace_if_empty(_about_,"about")
+            WHERE "nickname" = _nickname_
+            RETURNING json_build_object(
+                'nickname', "nickname", 'email', "email",
+                'fullname', "fullname", 'about', "about"
+            ) INTO _existing_;
+
+            IF _existing_ IS NULL THEN
+                RETURN (404, _existing_);
+            END IF;
+
+            RETURN (200, _existing_);
+        END;
+        $$ LANGUAGE PLPGSQL;
+
+        CREATE TABLE IF NOT EXISTS "forum" (
+            "slug" CITEXT
+                CONSTRAINT "forum_slug_pk" PRIMARY KEY,
+            "admin" CITEXT
+                CONSTRAINT "forum_admin_not_null" NOT NULL
+                CONSTRAINT "forum_admin_fk" REFERENCES "user"("nickname"),
+            "title" TEXT
+                CONSTRAINT "forum_title_not_null" NOT NULL,
+            "num_threads" INTEGER
+                DEFAULT(0)
+                CONSTRAINT "forum_num_threads_not_null" NOT NULL,
+            "num_posts" BIGINT
+                DEFAULT(0)
+                CONSTRAINT "forum_num_posts_not_null" NOT NULL
+        );
+
+        CREATE INDEX IF NOT EXISTS "forum_admin_idx" ON "forum"("admin");
+
+        CREATE TABLE IF NOT EXISTS "forum_user" (
+            "forum" CITEXT COLLATE "ucs_basic"
+                CONSTRAINT "forum_user_forum_not_null" NOT NULL
+                CONSTRAINT "forum_user_forum_fk" REFERENCES "forum"("slug"),
+            "user" CITEXT COLLATE "ucs_basic"
+                CONSTRAINT "forum_user_user_not_null" NOT NULL
+                CONSTRAINT "forum_user_user_fk" REFERENCES "user"("nickname"),
+            CONSTRAINT "forum_user_pk" PRIMARY KEY("user","forum")
+        );
+
+        CREATE INDEX IF NOT EXISTS "forum_user_forum_idx" ON "forum_user"("forum");
+
+        CREATE OR REPLACE FUNCTION insert_forum(
+            _slug_ CITEXT, _admin_ CITEXT, _title_ TEXT
+        )
+        RETURNS "query_result"
+        AS $$
+        DECLARE _user_ CITEXT;
+        DECLARE _existing_ JSON;
+        BEGIN
+            SELECT u."nickname"
+            FROM "user" u
+            WHERE u."nickname" = _admin_
+            INTO _user_;
+
+            IF _user_ IS NULL THEN
+                RETURN (404, _existing_);
+            END IF;
+
+            SELECT json_build_object(
+                'slug', f."slug",
+                'user', f."admin",
+                'title', f."title",
+                'threads', f."num_threads",
+                'posts', f."num_posts"
+            )
+            FROM "forum" f
+            WHERE f."slug" = _slug_
+            INTO _existing_;
+
+            IF _existing_ IS NOT NULL THEN
+                RETURN (409, _existing_);
+            END IF;
+
+            INSERT INTO "forum"("slug","admin","title")
+            VALUES(_slug_,_user_,_title_)
+            RETURNING json_build_object(
+                'slug', "slug",
+                'user', "admin",
+                'title', "title",
+                'threads', "num_threads",
+                'posts', "num_posts"
+            ) INTO _existing_;
+
+            RETURN (201, _existing_);
+        END;
+        $$ LANGUAGE PLPGSQL;
+
+        CREATE TABLE IF NOT EXISTS "thread" (
+            "id" SERIAL
+                CONSTRAINT "thread_id_pk" PRIMARY KEY,
+            "slug" CITEXT
+                CONSTRAINT "thread_slug_nullable" NULL,
+            "title" TEXT
+                CONSTRAINT "thread_title_not_null" NOT NULL,
+            "forum" CITEXT
+                CONSTRAINT "thread_forum_not_null" NOT NULL
+                CONSTRAINT "thread_forum_fk" REFERENCES "forum"("slug"),
+            "author" CITEXT
+                CONSTRAINT "thread_author_not_null" NOT NULL
+                CONSTRAINT "thread_author_fk" REFERENCES "user"("nickname"),
+            "created_timestamp" TIMESTAMPTZ
+                CONSTRAINT "thread_created_timestamp_nullable" NULL,
+            "message" TEXT
+                CONSTRAINT "thread_message_not_null" NOT NULL,
+            "num_votes" INTEGER
+                DEFAULT(0)
+                CONSTRAINT "thread_num_votes_not_null" NOT NULL
+        );
+
+        CREATE INDEX IF NOT EXISTS "thread_forum_idx" ON "thread"("forum");
+        CREATE INDEX IF NOT EXISTS "thread_author_idx" ON "thread"("author");
+        CREATE UNIQUE INDEX IF NOT EXISTS "thread_slug_idx" ON "thread"("slug");
+
+        CREATE OR REPLACE FUNCTION insert_thread(
+            _slug_ CITEXT, _title_ TEXT, _forum_ CITEXT, _author_ CITEXT,
+            _created_timestamp_ TIMESTAMPTZ, _message_ TEXT
+        )
+        RETURNS "query_result"
+        AS $$
+        DECLARE _forum_slug_ CITEXT;
+        DECLARE _author_nickname_ CITEXT;
+        DECLARE _existing_ JSON;
+        BEGIN
+            SELECT u."nickname"
+            FROM "user" u
+            WHERE u."nickname" = _author_
+            INTO _author_nickname_;
+
+            IF _author_nickname_ IS NULL THEN
+                RETURN (404, _existing_);
+            END IF;
+
+            SELECT f."slug"
+            FROM "forum" f
+            WHERE f."slug" = _forum_
+            INTO _forum_slug_;
+
+            IF _forum_slug_ IS NULL THEN
+                 RETURN (404, _existing_);
+            END IF;
+
+            SELECT json_build_object(
+                'id', th."id", 'slug', th."slug",
+                'title', th."title", 'forum', th."forum",
+                'author', th."author",
+                'created', th."created_timestamp",
+                'message', th."message", 'votes', th."num_votes"
+            )
+            FROM "thread" th
+            WHERE th."slug" = _slug_
+            INTO _existing_;
+
+            IF _existing_ IS NOT NULL THEN
+                RETURN (409, _existing_);
+            END IF;
+
+            INSERT INTO "thread"("slug","title","forum","author","created_timestamp","message")
+            VALUES(_slug_,_title_,_forum_slug_,_author_nickname_,_created_timestamp_, _message_)
+            RETURNING json_build_object(
+                'id', "id", 'slug', "slug",
+                'title', "title", 'forum', "forum",
+                'author', "author",
+                'created', "created_timestamp",
+                'message', "message", 'votes', "num_votes"
+            ) INTO _existing_;
+
+            UPDATE "forum" SET
+                "num_threads" = "num_threads" + 1
+            WHERE "slug" = _forum_slug_;
+
+            INSERT INTO "forum_user"("forum","user")
+            VALUES(_forum_slug_,_author_nickname_)
+            ON CONFLICT DO NOTHING;
+
+            RETURN (201, _existing_);
+        END;
+        $$ LANGUAGE PLPGSQL;
+
+        CREATE TABLE IF NOT EXISTS "post" (
+            "id" BIGINT
+                CONSTRAINT "post_id_pk" PRIMARY KEY,
+            "parent_id" BIGINT
+                DEFAULT(0)
+                CONSTRAINT "post_parent_id_nullable" NULL,
+            "author" CITEXT COLLATE "ucs_basic"
+                CONSTRAINT "post_author_not_null" NOT NULL
+                CONSTRAINT "post_author_fk" REFERENCES "user"("nickname"),
+            "forum" CITEXT COLLATE "ucs_basic"
+                CONSTRAINT "post_forum_not_null" NOT NULL
+                CONSTRAINT "post_forum_fk" REFERENCES "forum"("slug"),
+            "thread" INTEGER
+                CONSTRAINT "post_thread_not_null" NOT NULL
+                CONSTRAINT "post_thread_fk" REFERENCES "thread"("id"),
+            "message" TEXT
+                CONSTRAINT "post_message_not_null" NOT NULL,
+            "created_timestamp" TIMESTAMPTZ
+                CONSTRAINT "post_created_timestamp_nullable" NULL,
+            "is_edited" BOOLEAN
+                DEFAULT(FALSE)
+                CONSTRAINT "post_is_edited_not_null" NOT NULL,
+            "path" BIGINT ARRAY,
+            "path_root" BIGINT
+                CONSTRAINT "post_parent_root_nullable" NULL
+        );
+
+        CREATE SEQUENCE IF NOT EXISTS "post_id_seq" START 1;
+
+        CREATE INDEX IF NOT EXISTS "post_author_idx" ON "post"("author");
+        CREATE INDEX IF NOT EXISTS "post_forum_idx" ON "post"("forum");
+        CREATE INDEX IF NOT EXISTS "post_thread_idx" ON "post"("thread");
+        CREATE INDEX IF NOT EXISTS "post_path_root_idx" ON "post"("path_root");
+
+        CREATE TABLE IF NOT EXISTS "vote" (
+            "user" CITEXT COLLATE "ucs_basic"
+                CONSTRAINT "vote_user_not_null" NOT NULL
+                CONSTRAINT "vote_user_fk" REFERENCES "user"("nickname"),
+            "thread" INTEGER
+                CONSTRAINT "vote_thread_not_null" NOT NULL
+                CONSTRAINT "vote_thread_fk" REFERENCES "thread"("id"),
+            "voice" INTEGER
+                CONSTRAINT "vote_voice_not_null" NOT NULL,
+            CONSTRAINT "vote_user_thread_pk" PRIMARY KEY("user","thread")
+        );
+
+        CREATE OR REPLACE FUNCTION add_vote(
+            _user_ CITEXT, _voice_ INTEGER,
+            _thread_id_ INTEGER, _thread_slug_ CITEXT
+        ) RETURNS "query_result"
+        AS $$
+        DECLARE _prev_ INTEGER;
+        DECLARE _thread_ JSON;
+        BEGIN
+            IF _thread_id_ IS NULL THEN
+                SELECT th."id" FROM "thread" th
+                WHERE th."slug" = _thread_slug_
+                INTO _thread_id_;
+
+                IF _thread_id_ IS NULL THEN
+                    RETURN (404,_thread_);
+                END IF;
+            ELSE
+                IF NOT EXISTS (SELECT * FROM "thread" WHERE "id" = _thread_id_) THEN
+                    RETURN (404,_thread_);
+                END IF;
+            END IF;
+
+            IF NOT EXISTS (SELECT * FROM "user" WHERE "nickname" = _user_) THEN
+                RETURN (404,_thread_);
+            END IF;
+
+            SELECT v."voice"
+            FROM "vote" v
+            WHERE v."user" = _user_ AND
+                  v."thread" = _thread_id_
+            INTO _prev_;
+
+            IF _prev_ IS NULL THEN
+                INSERT INTO "vote"("user","thread","voice")
+                VALUES(_user_,_thread_id_,_voice_);
+
+                UPDATE "thread" SET
+                    "num_votes" = "num_votes" + _voice_
+                WHERE "id" = _thread_id_
+                RETURNING json_build_object(
+                    'id', "id",'slug', "slug",'title', "title",
+                    'forum', "forum",'author', "author",'created',"created_timestamp",
+                    'message',"message", 'votes', "num_votes")
+                INTO _thread_;
+            ELSE
+                IF _prev_ = _voice_ THEN
+                    SELECT json_build_object(
+                        'id', "id",'slug', "slug",'title', "title",
+                        'forum', "forum",'author', "author",'created',"created_timestamp",
+                        'message',"message", 'votes', "num_votes")
+                    FROM "thread" WHERE "id" = _thread_id_
+                    INTO _thread_;
+                ELSE
+                    UPDATE "vote" SET "voice" = _voice_
+                    WHERE "user" = _user_ AND "thread" = _thread_id_;
+
+                    UPDATE "thread" SET
+                        "num_votes" = "num_votes" + (2 * _voice_)
+                    WHERE "id" = _thread_id_
+                    RETURNING json_build_object(
+                        'id', "id",'slug', "slug",'title', "title",
+                        'forum', "forum",'author', "author",'created',"created_timestamp",
+                        'message',"message", 'votes', "num_votes")
+                    INTO _thread_;
+                END IF;
+            END IF;
+
+            RETURN (200,_thread_);
+        END;
+        $$ LANGUAGE PLPGSQL;
+    `
+
+	InitialSchemaDown = `
+        DROP TABLE IF EXISTS "vote";
+        DROP FUNCTION IF EXISTS add_vote(CITEXT, INTEGER, INTEGER, CITEXT);
+
+        DROP TABLE IF EXISTS "post";
+        DROP SEQUENCE IF EXISTS "post_id_seq";
+
+        DROP FUNCTION IF EXISTS insert_thread(CITEXT, TEXT, CITEXT, CITEXT, TIMESTAMPTZ, TEXT);
+        DROP TABLE IF EXISTS "thread";
+
+        DROP FUNCTION IF EXISTS insert_forum(CITEXT, CITEXT, TEXT);
+        DROP TABLE IF EXISTS "forum_user";
+        DROP TABLE IF EXISTS "forum";
+
+        DROP FUNCTION IF EXISTS update_user(CITEXT, CITEXT, TEXT, TEXT);
+        DROP FUNCTION IF EXISTS insert_user(CITEXT, CITEXT, TEXT, TEXT);
+        DROP TABLE IF EXISTS "user";
+
+        DROP FUNCTION IF EXISTS replace_if_empty(TEXT, TEXT);
+        DROP TYPE IF EXISTS "query_result";
+    `
+)
@@ -0,0 +1,51 @@
+package migrations
+
+const (
+	// The user count is sharded over 16 rows so that concurrent sign-ups
+	// don't all wait on one row; readers sum the shards.
+	StatusCountersUp = `
+        CREATE TABLE IF NOT EXISTS "status" (
+            "shard" SMALLINT
+                CONSTRAINT "status_shard_pk" PRIMARY KEY,
+            "num_users" INTEGER
+                DEFAULT(0)
+                CONSTRAINT "status_num_users_not_null" NOT NULL
+        );
+
+        INSERT INTO "status"("shard", "num_users")
+        SELECT s, CASE WHEN s = 0 THEN (SELECT COUNT(*) FROM "user") ELSE 0 END
+        FROM generate_series(0, 15) s;
+
+        CREATE OR REPLACE FUNCTION update_status_num_users()
+        RETURNS TRIGGER
+        AS $$
+        DECLARE
+            target_shard SMALLINT := floor(random() * 16);
+        BEGIN
+            IF TG_OP = 'INSERT' THEN
+                UPDATE "status" SET "num_users" = "num_users" + 1 WHERE "shard" = target_shard;
+            ELSIF TG_OP = 'DELETE' THEN
+                UPDATE "status" SET "num_users" = "num_users" - 1 WHERE "shard" = target_shard;
+            ELSE
+                UPDATE "status" SET "num_users" = 0;
+            END IF;
+            RETURN NULL;
+        END;
+        $$ LANGUAGE PLPGSQL;
+
+        CREATE TRIGGER "user_status_trigger"
+        AFTER INSERT OR DELETE ON "user"
+        FOR EACH ROW EXECUTE PROCEDURE update_status_num_users();
+
+        CREATE TRIGGER "user_status_truncate_trigger"
+        AFTER TRUNCATE ON "user"
+        FOR EACH STATEMENT EXECUTE PROCEDURE update_status_num_users();
+    `
+
+	StatusCountersDown = `
+        DROP TRIGGER IF EXISTS "user_status_truncate_trigger" ON "user";
+        DROP TRIGGER IF EXISTS "user_status_trigger" ON "user";
+        DROP FUNCTION IF EXISTS update_status_num_users();
+        DROP TABLE IF EXISTS "status";
+    `
+)
@@ -0,0 +1,19 @@
+package migrations
+
+const (
+	PostTombstonesUp = `
+        ALTER TABLE "post"
+            ADD COLUMN IF NOT EXISTS "is_deleted" BOOLEAN
+                DEFAULT(FALSE)
+                CONSTRAINT "post_is_deleted_not_null" NOT NULL;
+
+        CREATE INDEX IF NOT EXISTS "post_forum_author_idx" ON "post"("forum","author");
+        CREATE INDEX IF NOT EXISTS "thread_forum_author_idx" ON "thread"("forum","author");
+    `
+
+	PostTombstonesDown = `
+        DROP INDEX IF EXISTS "thread_forum_author_idx";
+        DROP INDEX IF EXISTS "post_forum_author_idx";
+        ALTER TABLE "post" DROP COLUMN IF EXISTS "is_deleted";
+    `
+)
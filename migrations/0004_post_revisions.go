@@ -0,0 +1,25 @@
+package migrations
+
+const (
+	PostRevisionsUp = `
+        CREATE TABLE IF NOT EXISTS "post_revision" (
+            "post" BIGINT
+                CONSTRAINT "post_revision_post_not_null" NOT NULL
+                CONSTRAINT "post_revision_post_fk" REFERENCES "post"("id") ON DELETE CASCADE,
+            "revision" INTEGER
+                CONSTRAINT "post_revision_revision_not_null" NOT NULL,
+            "message" TEXT
+                CONSTRAINT "post_revision_message_not_null" NOT NULL,
+            "editor" CITEXT COLLATE "ucs_basic"
+                CONSTRAINT "post_revision_editor_not_null" NOT NULL
+                CONSTRAINT "post_revision_editor_fk" REFERENCES "user"("nickname"),
+            "created_timestamp" TIMESTAMPTZ
+                CONSTRAINT "post_revision_created_timestamp_nullable" NULL,
+            CONSTRAINT "post_revision_pk" PRIMARY KEY("post","revision")
+        );
+    `
+
+	PostRevisionsDown = `
+        DROP TABLE IF EXISTS "post_revision";
+    `
+)
@@ -0,0 +1,295 @@
+package migrations
+
+const (
+	ThreadStatesUp = `
+        ALTER TABLE "thread"
+            ADD COLUMN IF NOT EXISTS "is_deleted" BOOLEAN
+                DEFAULT(FALSE)
+                CONSTRAINT "thread_is_deleted_not_null" NOT NULL,
+            ADD COLUMN IF NOT EXISTS "is_archived" BOOLEAN
+                DEFAULT(FALSE)
+                CONSTRAINT "thread_is_archived_not_null" NOT NULL;
+
+        CREATE INDEX IF NOT EXISTS "thread_forum_created_live_idx"
+            ON "thread"("forum","created_timestamp") WHERE NOT "is_deleted";
+
+        CREATE OR REPLACE FUNCTION thread_json(th "thread")
+        RETURNS JSON
+        AS $$
+            SELECT json_build_object(
+                'id', th."id", 'slug', th."slug",
+                'title', th."title", 'forum', th."forum",
+                'author', th."author",
+                'created', th."created_timestamp",
+                'message', th."message", 'votes', th."num_votes",
+                'archived', th."is_archived", 'deleted', th."is_deleted"
+            );
+        $$ LANGUAGE SQL STABLE;
+
+        CREATE OR REPLACE FUNCTION insert_thread(
+            _slug_ CITEXT, _title_ TEXT, _forum_ CITEXT, _author_ CITEXT,
+            _created_timestamp_ TIMESTAMPTZ, _message_ TEXT
+        )
+        RETURNS "query_result"
+        AS $$
+        DECLARE _forum_slug_ CITEXT;
+        DECLARE _author_nickname_ CITEXT;
+        DECLARE _existing_ JSON;
+        BEGIN
+            SELECT u."nickname"
+            FROM "user" u
+            WHERE u."nickname" = _author_
+            INTO _author_nickname_;
+
+            IF _author_nickname_ IS NULL THEN
+                RETURN (404, _existing_);
+            END IF;
+
+            SELECT f."slug"
+            FROM "forum" f
+            WHERE f."slug" = _forum_
+            INTO _forum_slug_;
+
+            IF _forum_slug_ IS NULL THEN
+                 RETURN (404, _existing_);
+            END IF;
+
+            SELECT thread_json(th)
+            FROM "thread" th
+            WHERE th."slug" = _slug_
+            INTO _existing_;
+
+            IF _existing_ IS NOT NULL THEN
+                RETURN (409, _existing_);
+            END IF;
+
+            INSERT INTO "thread" AS th ("slug","title","forum","author","created_timestamp","message")
+            VALUES(_slug_,_title_,_forum_slug_,_author_nickname_,_created_timestamp_, _message_)
+            RETURNING thread_json(th) INTO _existing_;
+
+            UPDATE "forum" SET
+                "num_threads" = "num_threads" + 1
+            WHERE "slug" = _forum_slug_;
+
+            INSERT INTO "forum_user"("forum","user")
+            VALUES(_forum_slug_,_author_nickname_)
+            ON CONFLICT DO NOTHING;
+
+            RETURN (201, _existing_);
+        END;
+        $$ LANGUAGE PLPGSQL;
+
+        CREATE OR REPLACE FUNCTION add_vote(
+            _user_ CITEXT, _voice_ INTEGER,
+            _thread_id_ INTEGER, _thread_slug_ CITEXT
+        ) RETURNS "query_result"
+        AS $$
+        DECLARE _prev_ INTEGER;
+        DECLARE _thread_ JSON;
+        BEGIN
+            IF _thread_id_ IS NULL THEN
+                SELECT th."id" FROM "thread" th
+                WHERE th."slug" = _thread_slug_
+                INTO _thread_id_;
+
+                IF _thread_id_ IS NULL THEN
+                    RETURN (404,_thread_);
+                END IF;
+            ELSE
+                IF NOT EXISTS (SELECT * FROM "thread" WHERE "id" = _thread_id_) THEN
+                    RETURN (404,_thread_);
+                END IF;
+            END IF;
+
+            IF NOT EXISTS (SELECT * FROM "user" WHERE "nickname" = _user_) THEN
+                RETURN (404,_thread_);
+            END IF;
+
+            IF EXISTS (
+                SELECT * FROM "thread"
+                WHERE "id" = _thread_id_ AND ("is_deleted" OR "is_archived")
+            ) THEN
+                RETURN (409,_thread_);
+            END IF;
+
+            SELECT v."voice"
+            FROM "vote" v
+            WHERE v."user" = _user_ AND
+                  v."thread" = _thread_id_
+            INTO _prev_;
+
+            IF _prev_ IS NULL THEN
+                INSERT INTO "vote"("user","thread","voice")
+                VALUES(_user_,_thread_id_,_voice_);
+
+                UPDATE "thread" th SET
+                    "num_votes" = "num_votes" + _voice_
+                WHERE "id" = _thread_id_
+                RETURNING thread_json(th)
+                INTO _thread_;
+            ELSE
+                IF _prev_ = _voice_ THEN
+                    SELECT thread_json(th)
+                    FROM "thread" th WHERE th."id" = _thread_id_
+                    INTO _thread_;
+                ELSE
+                    UPDATE "vote" SET "voice" = _voice_
+                    WHERE "user" = _user_ AND "thread" = _thread_id_;
+
+                    UPDATE "thread" th SET
+                        "num_votes" = "num_votes" + (2 * _voice_)
+                    WHERE "id" = _thread_id_
+                    RETURNING thread_json(th)
+                    INTO _thread_;
+                END IF;
+            END IF;
+
+            RETURN (200,_thread_);
+        END;
+        $$ LANGUAGE PLPGSQL;
+    `
+
+	ThreadStatesDown = `
+        CREATE OR REPLACE FUNCTION add_vote(
+            _user_ CITEXT, _voice_ INTEGER,
+            _thread_id_ INTEGER, _thread_slug_ CITEXT
+        ) RETURNS "query_result"
+        AS $$
+        DECLARE _prev_ INTEGER;
+        DECLARE _thread_ JSON;
+        BEGIN
+            IF _thread_id_ IS NULL THEN
+                SELECT th."id" FROM "thread" th
+                WHERE th."slug" = _thread_slug_
+                INTO _thread_id_;
+
+                IF _thread_id_ IS NULL THEN
+                    RETURN (404,_thread_);
+                END IF;
+            ELSE
+                IF NOT EXISTS (SELECT * FROM "thread" WHERE "id" = _thread_id_) THEN
+                    RETURN (404,_thread_);
+                END IF;
+            END IF;
+
+            IF NOT EXISTS (SELECT * FROM "user" WHERE "nickname" = _user_) THEN
+                RETURN (404,_thread_);
+            END IF;
+
+            SELECT v."voice"
+            FROM "vote" v
+            WHERE v."user" = _user_ AND
+                  v."thread" = _thread_id_
+            INTO _prev_;
+
+            IF _prev_ IS NULL THEN
+                INSERT INTO "vote"("user","thread","voice")
+                VALUES(_user_,_thread_id_,_voice_);
+
+                UPDATE "thread" SET
+                    "num_votes" = "num_votes" + _voice_
+                WHERE "id" = _thread_id_
+                RETURNING json_build_object(
+                    'id', "id",'slug', "slug",'title', "title",
+                    'forum', "forum",'author', "author",'created',"created_timestamp",
+                    'message',"message", 'votes', "num_votes")
+                INTO _thread_;
+            ELSE
+                IF _prev_ = _voice_ THEN
+                    SELECT json_build_object(
+                        'id', "id",'slug', "slug",'title', "title",
+                        'forum', "forum",'author', "author",'created',"created_timestamp",
+                        'message',"message", 'votes', "num_votes")
+                    FROM "thread" WHERE "id" = _thread_id_
+                    INTO _thread_;
+                ELSE
+                    UPDATE "vote" SET "voice" = _voice_
+                    WHERE "user" = _user_ AND "thread" = _thread_id_;
+
+                    UPDATE "thread" SET
+                        "num_votes" = "num_votes" + (2 * _voice_)
+                    WHERE "id" = _thread_id_
+                    RETURNING json_build_object(
+                        'id', "id",'slug', "slug",'title', "title",
+                        'forum', "forum",'author', "author",'created',"created_timestamp",
+                        'message',"message", 'votes', "num_votes")
+                    INTO _thread_;
+                END IF;
+            END IF;
+
+            RETURN (200,_thread_);
+        END;
+        $$ LANGUAGE PLPGSQL;
+
+        CREATE OR REPLACE FUNCTION insert_thread(
+            _slug_ CITEXT, _title_ TEXT, _forum_ CITEXT, _author_ CITEXT,
+            _created_timestamp_ TIMESTAMPTZ, _message_ TEXT
+        )
+        RETURNS "query_result"
+        AS $$
+        DECLARE _forum_slug_ CITEXT;
+        DECLARE _author_nickname_ CITEXT;
+        DECLARE _existing_ JSON;
+        BEGIN
+            SELECT u."nickname"
+            FROM "user" u
+            WHERE u."nickname" = _author_
+            INTO _author_nickname_;
+
+            IF _author_nickname_ IS NULL THEN
+                RETURN (404, _existing_);
+            END IF;
+
+            SELECT f."slug"
+            FROM "forum" f
+            WHERE f."slug" = _forum_
+            INTO _forum_slug_;
+
+            IF _forum_slug_ IS NULL THEN
+                 RETURN (404, _existing_);
+            END IF;
+
+            SELECT json_build_object(
+                'id', th."id", 'slug', th."slug",
+                'title', th."title", 'forum', th."forum",
+                'author', th."author",
+                'created', th."created_timestamp",
+                'message', th."message", 'votes', th."num_votes"
+            )
+            FROM "thread" th
+            WHERE th."slug" = _slug_
+            INTO _existing_;
+
+            IF _existing_ IS NOT NULL THEN
+                RETURN (409, _existing_);
+            END IF;
+
+            INSERT INTO "thread"("slug","title","forum","author","created_timestamp","message")
+            VALUES(_slug_,_title_,_forum_slug_,_author_nickname_,_created_timestamp_, _message_)
+            RETURNING json_build_object(
+                'id', "id", 'slug', "slug",
+                'title', "title", 'forum', "forum",
+                'author', "author",
+                'created', "created_timestamp",
+                'message', "message", 'votes', "num_votes"
+            ) INTO _existing_;
+
+            UPDATE "forum" SET
+                "num_threads" = "num_threads" + 1
+            WHERE "slug" = _forum_slug_;
+
+            INSERT INTO "forum_user"("forum","user")
+            VALUES(_forum_slug_,_author_nickname_)
+            ON CONFLICT DO NOTHING;
+
+            RETURN (201, _existing_);
+        END;
+        $$ LANGUAGE PLPGSQL;
+
+        DROP FUNCTION IF EXISTS thread_json("thread");
+        DROP INDEX IF EXISTS "thread_forum_created_live_idx";
+        ALTER TABLE "thread"
+            DROP COLUMN IF EXISTS "is_archived",
+            DROP COLUMN IF EXISTS "is_deleted";
+    `
+)
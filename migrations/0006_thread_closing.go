@@ -0,0 +1,202 @@
+package migrations
+
+const (
+	ThreadClosingUp = `
+        ALTER TABLE "thread"
+            ADD COLUMN IF NOT EXISTS "is_closed" BOOLEAN
+                DEFAULT(FALSE)
+                CONSTRAINT "thread_is_closed_not_null" NOT NULL,
+            ADD COLUMN IF NOT EXISTS "closed_by" CITEXT
+                CONSTRAINT "thread_closed_by_nullable" NULL
+                CONSTRAINT "thread_closed_by_fk" REFERENCES "user"("nickname"),
+            ADD COLUMN IF NOT EXISTS "closed_at" TIMESTAMPTZ
+                CONSTRAINT "thread_closed_at_nullable" NULL,
+            ADD COLUMN IF NOT EXISTS "close_reason" TEXT
+                CONSTRAINT "thread_close_reason_nullable" NULL;
+    ` + threadClosingThreadJSON + threadClosingAddVote
+
+	threadClosingThreadJSON = `
+        CREATE OR REPLACE FUNCTION thread_json(th "thread")
+        RETURNS JSON
+        AS $$
+            SELECT json_build_object(
+                'id', th."id", 'slug', th."slug",
+                'title', th."title", 'forum', th."forum",
+                'author', th."author",
+                'created', th."created_timestamp",
+                'message', th."message", 'votes', th."num_votes",
+                'archived', th."is_archived", 'deleted', th."is_deleted",
+                'closed', th."is_closed", 'closedBy', th."closed_by",
+                'closedAt', th."closed_at"
+            );
+        $$ LANGUAGE SQL STABLE;
+    `
+
+	threadClosingAddVote = `
+        CREATE OR REPLACE FUNCTION add_vote(
+            _user_ CITEXT, _voice_ INTEGER,
+            _thread_id_ INTEGER, _thread_slug_ CITEXT
+        ) RETURNS "query_result"
+        AS $$
+        DECLARE _prev_ INTEGER;
+        DECLARE _thread_ JSON;
+        BEGIN
+            IF _thread_id_ IS NULL THEN
+                SELECT th."id" FROM "thread" th
+                WHERE th."slug" = _thread_slug_
+                INTO _thread_id_;
+
+                IF _thread_id_ IS NULL THEN
+                    RETURN (404,_thread_);
+                END IF;
+            ELSE
+                IF NOT EXISTS (SELECT * FROM "thread" WHERE "id" = _thread_id_) THEN
+                    RETURN (404,_thread_);
+                END IF;
+            END IF;
+
+            IF NOT EXISTS (SELECT * FROM "user" WHERE "nickname" = _user_) THEN
+                RETURN (404,_thread_);
+            END IF;
+
+            IF EXISTS (
+                SELECT * FROM "thread"
+                WHERE "id" = _thread_id_ AND ("is_deleted" OR "is_archived")
+            ) THEN
+                RETURN (409,_thread_);
+            END IF;
+
+            IF EXISTS (
+                SELECT * FROM "thread"
+                WHERE "id" = _thread_id_ AND "is_closed"
+            ) THEN
+                RETURN (403,_thread_);
+            END IF;
+
+            SELECT v."voice"
+            FROM "vote" v
+            WHERE v."user" = _user_ AND
+                  v."thread" = _thread_id_
+            INTO _prev_;
+
+            IF _prev_ IS NULL THEN
+                INSERT INTO "vote"("user","thread","voice")
+                VALUES(_user_,_thread_id_,_voice_);
+
+                UPDATE "thread" th SET
+                    "num_votes" = "num_votes" + _voice_
+                WHERE "id" = _thread_id_
+                RETURNING thread_json(th)
+                INTO _thread_;
+            ELSE
+                IF _prev_ = _voice_ THEN
+                    SELECT thread_json(th)
+                    FROM "thread" th WHERE th."id" = _thread_id_
+                    INTO _thread_;
+                ELSE
+                    UPDATE "vote" SET "voice" = _voice_
+                    WHERE "user" = _user_ AND "thread" = _thread_id_;
+
+                    UPDATE "thread" th SET
+                        "num_votes" = "num_votes" + (2 * _voice_)
+                    WHERE "id" = _thread_id_
+                    RETURNING thread_json(th)
+                    INTO _thread_;
+                END IF;
+            END IF;
+
+            RETURN (200,_thread_);
+        END;
+        $$ LANGUAGE PLPGSQL;
+    `
+
+	ThreadClosingDown = `
+        CREATE OR REPLACE FUNCTION thread_json(th "thread")
+        RETURNS JSON
+        AS $$
+            SELECT json_build_object(
+                'id', th."id", 'slug', th."slug",
+                'title', th."title", 'forum', th."forum",
+                'author', th."author",
+                'created', th."created_timestamp",
+                'message', th."message", 'votes', th."num_votes",
+                'archived', th."is_archived", 'deleted', th."is_deleted"
+            );
+        $$ LANGUAGE SQL STABLE;
+
+        CREATE OR REPLACE FUNCTION add_vote(
+            _user_ CITEXT, _voice_ INTEGER,
+            _thread_id_ INTEGER, _thread_slug_ CITEXT
+        ) RETURNS "query_result"
+        AS $$
+        DECLARE _prev_ INTEGER;
+        DECLARE _thread_ JSON;
+        BEGIN
+            IF _thread_id_ IS NULL THEN
+                SELECT th."id" FROM "thread" th
+                WHERE th."slug" = _thread_slug_
+                INTO _thread_id_;
+
+                IF _thread_id_ IS NULL THEN
+                    RETURN (404,_thread_);
+                END IF;
+            ELSE
+                IF NOT EXISTS (SELECT * FROM "thread" WHERE "id" = _thread_id_) THEN
+                    RETURN (404,_thread_);
+                END IF;
+            END IF;
+
+            IF NOT EXISTS (SELECT * FROM "user" WHERE "nickname" = _user_) THEN
+                RETURN (404,_thread_);
+            END IF;
+
+            IF EXISTS (
+                SELECT * FROM "thread"
+                WHERE "id" = _thread_id_ AND ("is_deleted" OR "is_archived")
+            ) THEN
+                RETURN (409,_thread_);
+            END IF;
+
+            SELECT v."voice"
+            FROM "vote" v
+            WHERE v."user" = _user_ AND
+                  v."thread" = _thread_id_
+            INTO _prev_;
+
+            IF _prev_ IS NULL THEN
+                INSERT INTO "vote"("user","thread","voice")
+                VALUES(_user_,_thread_id_,_voice_);
+
+                UPDATE "thread" th SET
+                    "num_votes" = "num_votes" + _voice_
+                WHERE "id" = _thread_id_
+                RETURNING thread_json(th)
+                INTO _thread_;
+            ELSE
+                IF _prev_ = _voice_ THEN
+                    SELECT thread_json(th)
+                    FROM "thread" th WHERE th."id" = _thread_id_
+                    INTO _thread_;
+                ELSE
+                    UPDATE "vote" SET "voice" = _voice_
+                    WHERE "user" = _user_ AND "thread" = _thread_id_;
+
+                    UPDATE "thread" th SET
+                        "num_votes" = "num_votes" + (2 * _voice_)
+                    WHERE "id" = _thread_id_
+                    RETURNING thread_json(th)
+                    INTO _thread_;
+                END IF;
+            END IF;
+
+            RETURN (200,_thread_);
+        END;
+        $$ LANGUAGE PLPGSQL;
+
+        ALTER TABLE "thread"
+            DROP COLUMN IF EXISTS "close_reason",
+            DROP COLUMN IF EXISTS "closed_at",
+            DROP COLUMN IF EXISTS "closed_by",
+            DROP COLUMN IF EXISTS "is_closed";
+    `
+)
@@ -0,0 +1,39 @@
+package migrations
+
+const (
+	ThreadPinsUp = `
+        ALTER TABLE "thread"
+            ADD COLUMN IF NOT EXISTS "is_pinned" BOOLEAN
+                DEFAULT(FALSE)
+                CONSTRAINT "thread_is_pinned_not_null" NOT NULL,
+            ADD COLUMN IF NOT EXISTS "pinned_until" TIMESTAMPTZ
+                CONSTRAINT "thread_pinned_until_nullable" NULL;
+
+        CREATE INDEX IF NOT EXISTS "thread_forum_pinned_idx"
+            ON "thread"("forum","created_timestamp") WHERE "is_pinned" AND NOT "is_deleted";
+
+        CREATE OR REPLACE FUNCTION thread_json(th "thread")
+        RETURNS JSON
+        AS $$
+            SELECT json_build_object(
+                'id', th."id", 'slug', th."slug",
+                'title', th."title", 'forum', th."forum",
+                'author', th."author",
+                'created', th."created_timestamp",
+                'message', th."message", 'votes', th."num_votes",
+                'archived', th."is_archived", 'deleted', th."is_deleted",
+                'closed', th."is_closed", 'closedBy', th."closed_by",
+                'closedAt', th."closed_at",
+                'pinned', th."is_pinned" AND (th."pinned_until" IS NULL OR th."pinned_until" > now()),
+                'pinnedUntil', th."pinned_until"
+            );
+        $$ LANGUAGE SQL STABLE;
+    `
+
+	ThreadPinsDown = threadClosingThreadJSON + `
+        DROP INDEX IF EXISTS "thread_forum_pinned_idx";
+        ALTER TABLE "thread"
+            DROP COLUMN IF EXISTS "pinned_until",
+            DROP COLUMN IF EXISTS "is_pinned";
+    `
+)
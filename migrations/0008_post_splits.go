@@ -0,0 +1,13 @@
+package migrations
+
+const (
+	PostSplitsUp = `
+        ALTER TABLE "post"
+            ADD COLUMN IF NOT EXISTS "split_to" INTEGER
+                CONSTRAINT "post_split_to_fkey" REFERENCES "thread"("id") ON DELETE SET NULL;
+    `
+
+	PostSplitsDown = `
+        ALTER TABLE "post" DROP COLUMN IF EXISTS "split_to";
+    `
+)
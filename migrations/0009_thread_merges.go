@@ -0,0 +1,108 @@
+package migrations
+
+const (
+	ThreadMergesUp = `
+        CREATE TABLE IF NOT EXISTS "thread_redirect" (
+            "slug" CITEXT
+                CONSTRAINT "thread_redirect_slug_pk" PRIMARY KEY,
+            "thread" INTEGER
+                CONSTRAINT "thread_redirect_thread_not_null" NOT NULL
+                CONSTRAINT "thread_redirect_thread_fk" REFERENCES "thread"("id") ON DELETE CASCADE
+        );
+
+        CREATE INDEX IF NOT EXISTS "thread_redirect_thread_idx" ON "thread_redirect"("thread");
+        CREATE INDEX IF NOT EXISTS "post_split_to_idx" ON "post"("split_to") WHERE "split_to" IS NOT NULL;
+
+        CREATE OR REPLACE FUNCTION thread_id_by_slug(_slug_ CITEXT)
+        RETURNS INTEGER
+        AS $$
+            SELECT COALESCE(
+                (SELECT th."id" FROM "thread" th WHERE th."slug" = _slug_),
+                (SELECT tr."thread" FROM "thread_redirect" tr WHERE tr."slug" = _slug_)
+            );
+        $$ LANGUAGE SQL STABLE;
+    ` + threadMergesAddVote
+
+	threadMergesAddVote = `
+        CREATE OR REPLACE FUNCTION add_vote(
+            _user_ CITEXT, _voice_ INTEGER,
+            _thread_id_ INTEGER, _thread_slug_ CITEXT
+        ) RETURNS "query_result"
+        AS $$
+        DECLARE _prev_ INTEGER;
+        DECLARE _thread_ JSON;
+        BEGIN
+            IF _thread_id_ IS NULL THEN
+                SELECT thread_id_by_slug(_thread_slug_)
+                INTO _thread_id_;
+
+                IF _thread_id_ IS NULL THEN
+                    RETURN (404,_thread_);
+                END IF;
+            ELSE
+                IF NOT EXISTS (SELECT * FROM "thread" WHERE "id" = _thread_id_) THEN
+                    RETURN (404,_thread_);
+                END IF;
+            END IF;
+
+            IF NOT EXISTS (SELECT * FROM "user" WHERE "nickname" = _user_) THEN
+                RETURN (404,_thread_);
+            END IF;
+
+            IF EXISTS (
+                SELECT * FROM "thread"
+                WHERE "id" = _thread_id_ AND ("is_deleted" OR "is_archived")
+            ) THEN
+                RETURN (409,_thread_);
+            END IF;
+
+            IF EXISTS (
+                SELECT * FROM "thread"
+                WHERE "id" = _thread_id_ AND "is_closed"
+            ) THEN
+                RETURN (403,_thread_);
+            END IF;
+
+            SELECT v."voice"
+            FROM "vote" v
+            WHERE v."user" = _user_ AND
+                  v."thread" = _thread_id_
+            INTO _prev_;
+
+            IF _prev_ IS NULL THEN
+                INSERT INTO "vote"("user","thread","voice")
+                VALUES(_user_,_thread_id_,_voice_);
+
+                UPDATE "thread" th SET
+                    "num_votes" = "num_votes" + _voice_
+                WHERE "id" = _thread_id_
+                RETURNING thread_json(th)
+                INTO _thread_;
+            ELSE
+                IF _prev_ = _voice_ THEN
+                    SELECT thread_json(th)
+                    FROM "thread" th WHERE th."id" = _thread_id_
+                    INTO _thread_;
+                ELSE
+                    UPDATE "vote" SET "voice" = _voice_
+                    WHERE "user" = _user_ AND "thread" = _thread_id_;
+
+                    UPDATE "thread" th SET
+                        "num_votes" = "num_votes" + (2 * _voice_)
+                    WHERE "id" = _thread_id_
+                    RETURNING thread_json(th)
+                    INTO _thread_;
+                END IF;
+            END IF;
+
+            RETURN (200,_thread_);
+        END;
+        $$ LANGUAGE PLPGSQL;
+    `
+
+	ThreadMergesDown = threadClosingAddVote + `
+        DROP FUNCTION IF EXISTS thread_id_by_slug(CITEXT);
+        DROP INDEX IF EXISTS "post_split_to_idx";
+        DROP TABLE IF EXISTS "thread_redirect";
+    `
+)
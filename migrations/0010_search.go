@@ -0,0 +1,91 @@
+package migrations
+
+const (
+	SearchUp = `
+        ALTER TABLE "forum"
+            ADD COLUMN IF NOT EXISTS "search_config" TEXT
+                DEFAULT('english')
+                CONSTRAINT "forum_search_config_not_null" NOT NULL
+                CONSTRAINT "forum_search_config_check" CHECK ("search_config" IN ('english','russian'));
+
+        ALTER TABLE "post"
+            ADD COLUMN IF NOT EXISTS "search_vector" TSVECTOR;
+
+        ALTER TABLE "thread"
+            ADD COLUMN IF NOT EXISTS "search_vector" TSVECTOR;
+
+        CREATE OR REPLACE FUNCTION forum_search_config(_forum_ CITEXT)
+        RETURNS REGCONFIG
+        AS $$
+            SELECT COALESCE(
+                (SELECT f."search_config"::REGCONFIG FROM "forum" f WHERE f."slug" = _forum_),
+                'english'::REGCONFIG
+            );
+        $$ LANGUAGE SQL STABLE;
+
+        CREATE OR REPLACE FUNCTION post_search_vector(_config_ REGCONFIG, _message_ TEXT)
+        RETURNS TSVECTOR
+        AS $$
+            SELECT to_tsvector(_config_, COALESCE(_message_, ''));
+        $$ LANGUAGE SQL IMMUTABLE;
+
+        CREATE OR REPLACE FUNCTION thread_search_vector(_config_ REGCONFIG, _title_ TEXT, _message_ TEXT)
+        RETURNS TSVECTOR
+        AS $$
+            SELECT setweight(to_tsvector(_config_, COALESCE(_title_, '')), 'A') ||
+                setweight(to_tsvector(_config_, COALESCE(_message_, '')), 'B');
+        $$ LANGUAGE SQL IMMUTABLE;
+
+        CREATE OR REPLACE FUNCTION update_post_search_vector()
+        RETURNS TRIGGER
+        AS $$
+        BEGIN
+            NEW."search_vector" := post_search_vector(forum_search_config(NEW."forum"), NEW."message");
+            RETURN NEW;
+        END;
+        $$ LANGUAGE PLPGSQL;
+
+        CREATE OR REPLACE FUNCTION update_thread_search_vector()
+        RETURNS TRIGGER
+        AS $$
+        BEGIN
+            NEW."search_vector" := thread_search_vector(forum_search_config(NEW."forum"), NEW."title", NEW."message");
+            RETURN NEW;
+        END;
+        $$ LANGUAGE PLPGSQL;
+
+        DROP TRIGGER IF EXISTS "post_search_vector_trigger" ON "post";
+        CREATE TRIGGER "post_search_vector_trigger"
+            BEFORE INSERT OR UPDATE OF "message","forum" ON "post"
+            FOR EACH ROW EXECUTE PROCEDURE update_post_search_vector();
+
+        DROP TRIGGER IF EXISTS "thread_search_vector_trigger" ON "thread";
+        CREATE TRIGGER "thread_search_vector_trigger"
+            BEFORE INSERT OR UPDATE OF "title","message","forum" ON "thread"
+            FOR EACH ROW EXECUTE PROCEDURE update_thread_search_vector();
+
+        UPDATE "post" p SET
+            "search_vector" = post_search_vector('english', p."message");
+
+        UPDATE "thread" th SET
+            "search_vector" = thread_search_vector('english', th."title", th."message");
+
+        CREATE INDEX IF NOT EXISTS "post_search_vector_idx" ON "post" USING GIN("search_vector");
+        CREATE INDEX IF NOT EXISTS "thread_search_vector_idx" ON "thread" USING GIN("search_vector");
+    `
+
+	SearchDown = `
+        DROP INDEX IF EXISTS "thread_search_vector_idx";
+        DROP INDEX IF EXISTS "post_search_vector_idx";
+        DROP TRIGGER IF EXISTS "thread_search_vector_trigger" ON "thread";
+        DROP TRIGGER IF EXISTS "post_search_vector_trigger" ON "post";
+        DROP FUNCTION IF EXISTS update_thread_search_vector();
+        DROP FUNCTION IF EXISTS update_post_search_vector();
+        DROP FUNCTION IF EXISTS thread_search_vector(REGCONFIG, TEXT, TEXT);
+        DROP FUNCTION IF EXISTS post_search_vector(REGCONFIG, TEXT);
+        DROP FUNCTION IF EXISTS forum_search_config(CITEXT);
+        ALTER TABLE "thread" DROP COLUMN IF EXISTS "search_vector";
+        ALTER TABLE "post" DROP COLUMN IF EXISTS "search_vector";
+        ALTER TABLE "forum" DROP COLUMN IF EXISTS "search_config";
+    `
+)
@@ -0,0 +1,19 @@
+package migrations
+
+const (
+	AuthorListingsUp = `
+        CREATE INDEX IF NOT EXISTS "post_author_id_idx" ON "post"("author","id");
+        CREATE INDEX IF NOT EXISTS "thread_author_id_idx" ON "thread"("author","id");
+
+        DROP INDEX IF EXISTS "post_author_idx";
+        DROP INDEX IF EXISTS "thread_author_idx";
+    `
+
+	AuthorListingsDown = `
+        CREATE INDEX IF NOT EXISTS "thread_author_idx" ON "thread"("author");
+        CREATE INDEX IF NOT EXISTS "post_author_idx" ON "post"("author");
+
+        DROP INDEX IF EXISTS "thread_author_id_idx";
+        DROP INDEX IF EXISTS "post_author_id_idx";
+    `
+)
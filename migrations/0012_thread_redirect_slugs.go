@@ -0,0 +1,113 @@
+package migrations
+
+const (
+	ThreadRedirectSlugsUp = `
+        CREATE OR REPLACE FUNCTION insert_thread(
+            _slug_ CITEXT, _title_ TEXT, _forum_ CITEXT, _author_ CITEXT,
+            _created_timestamp_ TIMESTAMPTZ, _message_ TEXT
+        )
+        RETURNS "query_result"
+        AS $$
+        DECLARE _forum_slug_ CITEXT;
+        DECLARE _author_nickname_ CITEXT;
+        DECLARE _existing_ JSON;
+        BEGIN
+            SELECT u."nickname"
+            FROM "user" u
+            WHERE u."nickname" = _author_
+            INTO _author_nickname_;
+
+            IF _author_nickname_ IS NULL THEN
+                RETURN (404, _existing_);
+            END IF;
+
+            SELECT f."slug"
+            FROM "forum" f
+            WHERE f."slug" = _forum_
+            INTO _forum_slug_;
+
+            IF _forum_slug_ IS NULL THEN
+                 RETURN (404, _existing_);
+            END IF;
+
+            SELECT thread_json(th)
+            FROM "thread" th
+            WHERE th."id" = thread_id_by_slug(_slug_)
+            INTO _existing_;
+
+            IF _existing_ IS NOT NULL THEN
+                RETURN (409, _existing_);
+            END IF;
+
+            INSERT INTO "thread" AS th ("slug","title","forum","author","created_timestamp","message")
+            VALUES(_slug_,_title_,_forum_slug_,_author_nickname_,_created_timestamp_, _message_)
+            RETURNING thread_json(th) INTO _existing_;
+
+            UPDATE "forum" SET
+                "num_threads" = "num_threads" + 1
+            WHERE "slug" = _forum_slug_;
+
+            INSERT INTO "forum_user"("forum","user")
+            VALUES(_forum_slug_,_author_nickname_)
+            ON CONFLICT DO NOTHING;
+
+            RETURN (201, _existing_);
+        END;
+        $$ LANGUAGE PLPGSQL;
+    `
+
+	ThreadRedirectSlugsDown = `
+        CREATE OR REPLACE FUNCTION insert_thread(
+            _slug_ CITEXT, _title_ TEXT, _forum_ CITEXT, _author_ CITEXT,
+            _created_timestamp_ TIMESTAMPTZ, _message_ TEXT
+        )
+        RETURNS "query_result"
+        AS $$
+        DECLARE _forum_slug_ CITEXT;
+        DECLARE _author_nickname_ CITEXT;
+        DECLARE _existing_ JSON;
+        BEGIN
+            SELECT u."nickname"
+            FROM "user" u
+            WHERE u."nickname" = _author_
+            INTO _author_nickname_;
+
+            IF _author_nickname_ IS NULL THEN
+                RETURN (404, _existing_);
+            END IF;
+
+            SELECT f."slug"
+            FROM "forum" f
+            WHERE f."slug" = _forum_
+            INTO _forum_slug_;
+
+            IF _forum_slug_ IS NULL THEN
+                 RETURN (404, _existing_);
+            END IF;
+
+            SELECT thread_json(th)
+            FROM "thread" th
+            WHERE th."slug" = _slug_
+            INTO _existing_;
+
+            IF _existing_ IS NOT NULL THEN
+                RETURN (409, _existing_);
+            END IF;
+
+            INSERT INTO "thread" AS th ("slug","title","forum","author","created_timestamp","message")
+            VALUES(_slug_,_title_,_forum_slug_,_author_nickname_,_created_timestamp_, _message_)
+            RETURNING thread_json(th) INTO _existing_;
+
+            UPDATE "forum" SET
+                "num_threads" = "num_threads" + 1
+            WHERE "slug" = _forum_slug_;
+
+            INSERT INTO "forum_user"("forum","user")
+            VALUES(_forum_slug_,_author_nickname_)
+            ON CONFLICT DO NOTHING;
+
+            RETURN (201, _existing_);
+        END;
+        $$ LANGUAGE PLPGSQL;
+    `
+)
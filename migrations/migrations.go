@@ -0,0 +1,23 @@
+package migrations
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+var All = []Migration{
+	{Version: 1, Name: "initial_schema", Up: InitialSchemaUp, Down: InitialSchemaDown},
+	{Version: 2, Name: "status_counters", Up: StatusCountersUp, Down: StatusCountersDown},
+	{Version: 3, Name: "post_tombstones", Up: PostTombstonesUp, Down: PostTombstonesDown},
+	{Version: 4, Name: "post_revisions", Up: PostRevisionsUp, Down: PostRevisionsDown},
+	{Version: 5, Name: "thread_states", Up: ThreadStatesUp, Down: ThreadStatesDown},
+	{Version: 6, Name: "thread_closing", Up: ThreadClosingUp, Down: ThreadClosingDown},
+	{Version: 7, Name: "thread_pins", Up: ThreadPinsUp, Down: ThreadPinsDown},
+	{Version: 8, Name: "post_splits", Up: PostSplitsUp, Down: PostSplitsDown},
+	{Version: 9, Name: "thread_merges", Up: ThreadMergesUp, Down: ThreadMergesDown},
+	{Version: 10, Name: "search", Up: SearchUp, Down: SearchDown},
+	{Version: 11, Name: "author_listings", Up: AuthorListingsUp, Down: AuthorListingsDown},
+	{Version: 12, Name: "thread_redirect_slugs", Up: ThreadRedirectSlugsUp, Down: ThreadRedirectSlugsDown},
+}
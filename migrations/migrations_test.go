@@ -0,0 +1,30 @@
+package migrations
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAllOrdered(t *testing.T) {
+	if len(All) == 0 {
+		t.Fatal("no migrations registered")
+	}
+
+	names := make(map[string]int, len(All))
+	for i, m := range All {
+		if m.Version != i+1 {
+			t.Errorf("migration %q has version %d, want %d: versions must be sequential", m.Name, m.Version, i+1)
+		}
+		if prev, ok := names[m.Name]; ok {
+			t.Errorf("migrations %d and %d share the name %q", prev, m.Version, m.Name)
+		}
+		names[m.Name] = m.Version
+
+		if strings.TrimSpace(m.Up) == "" {
+			t.Errorf("migration %d has no up SQL", m.Version)
+		}
+		if strings.TrimSpace(m.Down) == "" {
+			t.Errorf("migration %d has no down SQL", m.Version)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"bytes"
 	"database/sql"
 	"encoding/json"
 	"github.com/go-openapi/strfmt"
@@ -43,15 +44,22 @@ func (t *NullTimestamp) MarshalJSON() ([]byte, error) {
 }
 
 func (t *NullTimestamp) UnmarshalJSON(b []byte) error {
-	var tStr string
-	_ = json.Unmarshal(b, &tStr)
-
-	if tStr == "null" {
+	if bytes.Equal(b, nullSlice) {
 		t.Valid = false
 		return nil
 	}
 
-	t.Timestamp, _ = strfmt.ParseDateTime(tStr) //TODO
+	var tStr string
+	if err := json.Unmarshal(b, &tStr); err != nil {
+		return err
+	}
+
+	ts, err := strfmt.ParseDateTime(tStr)
+	if err != nil {
+		return err
+	}
+
+	t.Timestamp = ts
 	t.Valid = true
 
 	return nil
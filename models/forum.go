@@ -10,3 +10,10 @@ type Forum struct {
 	NumThreads int32  `json:"threads"`
 	NumPosts   int64  `json:"posts"`
 }
+
+//easyjson:json
+type ForumSearchConfig struct {
+	Forum     string `json:"forum"`
+	Moderator string `json:"moderator,omitempty"`
+	Config    string `json:"config"`
+}
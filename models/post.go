@@ -16,15 +16,67 @@ type Post struct {
 	Message          string          `json:"message"`
 	CreatedTimestamp strfmt.DateTime `json:"created"`
 	IsEdited         bool            `json:"isEdited"`
+	IsDeleted        bool            `json:"isDeleted,omitempty"`
+	SplitTo          int32           `json:"splitTo,omitempty"`
 }
 
 //easyjson:json
 type Posts []Post
 
+//easyjson:json
+type PostNode struct {
+	Post
+	Depth       int32     `json:"depth"`
+	ChildCount  int32     `json:"childCount"`
+	MoreReplies bool      `json:"moreReplies,omitempty"`
+	Children    PostNodes `json:"children"`
+}
+
+//easyjson:json
+type PostNodes []*PostNode
+
 //easyjson:json
 type PostFull map[string]interface{}
 
+//easyjson:json
+type PostDeletion struct {
+	ID      int64 `json:"id"`
+	Deleted int64 `json:"deleted"`
+}
+
+//easyjson:json
+type PostSplit struct {
+	Moderator string     `json:"moderator"`
+	Title     string     `json:"title"`
+	Slug      NullString `json:"slug"`
+}
+
 //easyjson:json
 type PostUpdate struct {
 	Message string `json:"message"`
+	Editor  string `json:"editor,omitempty"`
+}
+
+//easyjson:json
+type PostRevision struct {
+	Revision         int32           `json:"revision"`
+	Message          string          `json:"message"`
+	Editor           string          `json:"editor"`
+	CreatedTimestamp strfmt.DateTime `json:"created"`
+}
+
+//easyjson:json
+type PostRevisions []PostRevision
+
+//easyjson:json
+type PostRevisionDiff struct {
+	Post    int64        `json:"post"`
+	From    int32        `json:"from"`
+	To      int32        `json:"to"`
+	Changes []DiffChange `json:"changes"`
+}
+
+type DiffChange struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
 }
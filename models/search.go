@@ -0,0 +1,36 @@
+package models
+
+import (
+	"github.com/go-openapi/strfmt"
+)
+
+//go:generate easyjson
+
+//easyjson:json
+type SearchResult struct {
+	Kind             string          `json:"type"`
+	ID               int64           `json:"id"`
+	Thread           int32           `json:"thread"`
+	Forum            string          `json:"forum"`
+	Author           string          `json:"author"`
+	Title            string          `json:"title"`
+	Snippet          string          `json:"snippet"`
+	CreatedTimestamp strfmt.DateTime `json:"created"`
+	Rank             float32         `json:"rank"`
+}
+
+//easyjson:json
+type SearchResults struct {
+	Results []SearchResult `json:"results"`
+	Next    NullString     `json:"next"`
+}
+
+const (
+	SearchKindPost   = "post"
+	SearchKindThread = "thread"
+)
+
+const (
+	SearchConfigEnglish = "english"
+	SearchConfigRussian = "russian"
+)
@@ -12,6 +12,14 @@ type Thread struct {
 	Message          string        `json:"message"`
 	CreatedTimestamp NullTimestamp `json:"created"`
 	NumVotes         int32         `json:"votes"`
+	IsArchived       bool          `json:"archived"`
+	IsDeleted        bool          `json:"deleted"`
+	IsClosed         bool          `json:"closed"`
+	ClosedBy         NullString    `json:"closedBy"`
+	ClosedAt         NullTimestamp `json:"closedAt"`
+	CloseReason      string        `json:"-"`
+	IsPinned         bool          `json:"pinned"`
+	PinnedUntil      NullTimestamp `json:"pinnedUntil"`
 }
 
 //easyjson:json
@@ -22,3 +30,37 @@ type ThreadUpdate struct {
 
 //easyjson:json
 type Threads []Thread
+
+//easyjson:json
+type ThreadModeration struct {
+	Moderator string `json:"moderator"`
+	Closed    bool   `json:"closed"`
+	Reason    string `json:"reason"`
+}
+
+//easyjson:json
+type ThreadPin struct {
+	Moderator string        `json:"moderator"`
+	Pinned    bool          `json:"pinned"`
+	Until     NullTimestamp `json:"until"`
+}
+
+//easyjson:json
+type ThreadMove struct {
+	Moderator string `json:"moderator"`
+	Forum     string `json:"forum"`
+}
+
+//easyjson:json
+type ThreadMerge struct {
+	Moderator string `json:"moderator"`
+	Target    string `json:"target"`
+	Parent    int64  `json:"parent"`
+}
+
+//easyjson:json
+type ThreadDeletion struct {
+	ID    int32      `json:"id"`
+	Slug  NullString `json:"slug"`
+	Posts int64      `json:"posts"`
+}
@@ -166,7 +166,474 @@ func (v *ThreadUpdate) UnmarshalJSON(data []byte) error {
 func (v *ThreadUpdate) UnmarshalEasyJSON(l *jlexer.Lexer) {
 	easyjson2d00218DecodeTpProjectDbModels1(l, v)
 }
-func easyjson2d00218DecodeTpProjectDbModels2(in *jlexer.Lexer, out *Thread) {
+func easyjson2d00218DecodeTpProjectDbModels2(in *jlexer.Lexer, out *ThreadPin) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeString()
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "moderator":
+			out.Moderator = string(in.String())
+		case "pinned":
+			out.Pinned = bool(in.Bool())
+		case "until":
+			if data := in.Raw(); in.Ok() {
+				in.AddError((out.Until).UnmarshalJSON(data))
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson2d00218EncodeTpProjectDbModels2(out *jwriter.Writer, in ThreadPin) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"moderator\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Moderator))
+	}
+	{
+		const prefix string = ",\"pinned\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Bool(bool(in.Pinned))
+	}
+	{
+		const prefix string = ",\"until\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Raw((in.Until).MarshalJSON())
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v ThreadPin) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjson2d00218EncodeTpProjectDbModels2(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v ThreadPin) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson2d00218EncodeTpProjectDbModels2(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *ThreadPin) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjson2d00218DecodeTpProjectDbModels2(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *ThreadPin) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson2d00218DecodeTpProjectDbModels2(l, v)
+}
+func easyjson2d00218DecodeTpProjectDbModels3(in *jlexer.Lexer, out *ThreadMove) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeString()
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "moderator":
+			out.Moderator = string(in.String())
+		case "forum":
+			out.Forum = string(in.String())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson2d00218EncodeTpProjectDbModels3(out *jwriter.Writer, in ThreadMove) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"moderator\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Moderator))
+	}
+	{
+		const prefix string = ",\"forum\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Forum))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v ThreadMove) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjson2d00218EncodeTpProjectDbModels3(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v ThreadMove) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson2d00218EncodeTpProjectDbModels3(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *ThreadMove) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjson2d00218DecodeTpProjectDbModels3(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *ThreadMove) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson2d00218DecodeTpProjectDbModels3(l, v)
+}
+func easyjson2d00218DecodeTpProjectDbModels4(in *jlexer.Lexer, out *ThreadModeration) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeString()
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "moderator":
+			out.Moderator = string(in.String())
+		case "closed":
+			out.Closed = bool(in.Bool())
+		case "reason":
+			out.Reason = string(in.String())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson2d00218EncodeTpProjectDbModels4(out *jwriter.Writer, in ThreadModeration) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"moderator\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Moderator))
+	}
+	{
+		const prefix string = ",\"closed\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Bool(bool(in.Closed))
+	}
+	{
+		const prefix string = ",\"reason\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Reason))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v ThreadModeration) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjson2d00218EncodeTpProjectDbModels4(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v ThreadModeration) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson2d00218EncodeTpProjectDbModels4(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *ThreadModeration) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjson2d00218DecodeTpProjectDbModels4(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *ThreadModeration) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson2d00218DecodeTpProjectDbModels4(l, v)
+}
+func easyjson2d00218DecodeTpProjectDbModels5(in *jlexer.Lexer, out *ThreadMerge) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeString()
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "moderator":
+			out.Moderator = string(in.String())
+		case "target":
+			out.Target = string(in.String())
+		case "parent":
+			out.Parent = int64(in.Int64())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson2d00218EncodeTpProjectDbModels5(out *jwriter.Writer, in ThreadMerge) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"moderator\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Moderator))
+	}
+	{
+		const prefix string = ",\"target\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Target))
+	}
+	{
+		const prefix string = ",\"parent\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Int64(int64(in.Parent))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v ThreadMerge) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjson2d00218EncodeTpProjectDbModels5(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v ThreadMerge) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson2d00218EncodeTpProjectDbModels5(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *ThreadMerge) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjson2d00218DecodeTpProjectDbModels5(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *ThreadMerge) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson2d00218DecodeTpProjectDbModels5(l, v)
+}
+func easyjson2d00218DecodeTpProjectDbModels6(in *jlexer.Lexer, out *ThreadDeletion) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeString()
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "id":
+			out.ID = int32(in.Int32())
+		case "slug":
+			if data := in.Raw(); in.Ok() {
+				in.AddError((out.Slug).UnmarshalJSON(data))
+			}
+		case "posts":
+			out.Posts = int64(in.Int64())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson2d00218EncodeTpProjectDbModels6(out *jwriter.Writer, in ThreadDeletion) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"id\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Int32(int32(in.ID))
+	}
+	{
+		const prefix string = ",\"slug\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Raw((in.Slug).MarshalJSON())
+	}
+	{
+		const prefix string = ",\"posts\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Int64(int64(in.Posts))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v ThreadDeletion) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjson2d00218EncodeTpProjectDbModels6(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v ThreadDeletion) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson2d00218EncodeTpProjectDbModels6(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *ThreadDeletion) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjson2d00218DecodeTpProjectDbModels6(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *ThreadDeletion) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson2d00218DecodeTpProjectDbModels6(l, v)
+}
+func easyjson2d00218DecodeTpProjectDbModels7(in *jlexer.Lexer, out *Thread) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -205,6 +672,26 @@ func easyjson2d00218DecodeTpProjectDbModels2(in *jlexer.Lexer, out *Thread) {
 			}
 		case "votes":
 			out.NumVotes = int32(in.Int32())
+		case "archived":
+			out.IsArchived = bool(in.Bool())
+		case "deleted":
+			out.IsDeleted = bool(in.Bool())
+		case "closed":
+			out.IsClosed = bool(in.Bool())
+		case "closedBy":
+			if data := in.Raw(); in.Ok() {
+				in.AddError((out.ClosedBy).UnmarshalJSON(data))
+			}
+		case "closedAt":
+			if data := in.Raw(); in.Ok() {
+				in.AddError((out.ClosedAt).UnmarshalJSON(data))
+			}
+		case "pinned":
+			out.IsPinned = bool(in.Bool())
+		case "pinnedUntil":
+			if data := in.Raw(); in.Ok() {
+				in.AddError((out.PinnedUntil).UnmarshalJSON(data))
+			}
 		default:
 			in.SkipRecursive()
 		}
@@ -215,7 +702,7 @@ func easyjson2d00218DecodeTpProjectDbModels2(in *jlexer.Lexer, out *Thread) {
 		in.Consumed()
 	}
 }
-func easyjson2d00218EncodeTpProjectDbModels2(out *jwriter.Writer, in Thread) {
+func easyjson2d00218EncodeTpProjectDbModels7(out *jwriter.Writer, in Thread) {
 	out.RawByte('{')
 	first := true
 	_ = first
@@ -299,29 +786,99 @@ func easyjson2d00218EncodeTpProjectDbModels2(out *jwriter.Writer, in Thread) {
 		}
 		out.Int32(int32(in.NumVotes))
 	}
+	{
+		const prefix string = ",\"archived\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Bool(bool(in.IsArchived))
+	}
+	{
+		const prefix string = ",\"deleted\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Bool(bool(in.IsDeleted))
+	}
+	{
+		const prefix string = ",\"closed\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Bool(bool(in.IsClosed))
+	}
+	{
+		const prefix string = ",\"closedBy\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Raw((in.ClosedBy).MarshalJSON())
+	}
+	{
+		const prefix string = ",\"closedAt\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Raw((in.ClosedAt).MarshalJSON())
+	}
+	{
+		const prefix string = ",\"pinned\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Bool(bool(in.IsPinned))
+	}
+	{
+		const prefix string = ",\"pinnedUntil\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Raw((in.PinnedUntil).MarshalJSON())
+	}
 	out.RawByte('}')
 }
 
 // MarshalJSON supports json.Marshaler interface
 func (v Thread) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
-	easyjson2d00218EncodeTpProjectDbModels2(&w, v)
+	easyjson2d00218EncodeTpProjectDbModels7(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
 func (v Thread) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjson2d00218EncodeTpProjectDbModels2(w, v)
+	easyjson2d00218EncodeTpProjectDbModels7(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
 func (v *Thread) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
-	easyjson2d00218DecodeTpProjectDbModels2(&r, v)
+	easyjson2d00218DecodeTpProjectDbModels7(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
 func (v *Thread) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjson2d00218DecodeTpProjectDbModels2(l, v)
+	easyjson2d00218DecodeTpProjectDbModels7(l, v)
 }
@@ -1,39 +1,80 @@
 package repositories
 
 import (
+	"context"
 	"github.com/jackc/pgx"
+	"io"
+	"math/rand"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 	"tp-project-db/errs"
 )
 
 const (
-	MaxConnections = 32
+	DatabaseUnavailableErrMessage = "database unavailable"
+	QueryTimeoutErrMessage        = "query deadline exceeded"
+	QueryCanceledErrMessage       = "query canceled"
 )
 
+type ConnectionConfig struct {
+	Host               string
+	Port               uint16
+	Database           string
+	User               string
+	Password           string
+	MaxConnections     int
+	AcquireTimeout     time.Duration
+	SlowQueryThreshold time.Duration
+}
+
 type Connection struct {
+	waiting int64
+
 	conn   *pgx.ConnPool
-	config pgx.ConnConfig
-}
+	config ConnectionConfig
 
-func NewConnection() *Connection {
-	config, err := pgx.ParseEnvLibpq()
-	if err != nil {
-		panic(err)
-	}
+	stmtsMtx *sync.RWMutex
+	stmts    map[string]struct{}
+}
 
+func NewConnection(config ConnectionConfig) *Connection {
 	return &Connection{
 		config: config,
+
+		stmtsMtx: &sync.RWMutex{},
+		stmts:    make(map[string]struct{}),
 	}
 }
 
 func (c *Connection) Open() error {
-	var err error
+	connConfig, err := pgx.ParseEnvLibpq()
+	if err != nil {
+		return err
+	}
+	connConfig.Host = c.config.Host
+	connConfig.Port = c.config.Port
+	connConfig.Database = c.config.Database
+	connConfig.User = c.config.User
+	connConfig.Password = c.config.Password
+	connConfig.Logger = c
+	connConfig.LogLevel = pgx.LogLevelInfo
+
 	c.conn, err = pgx.NewConnPool(
 		pgx.ConnPoolConfig{
-			ConnConfig:     c.config,
-			MaxConnections: MaxConnections,
+			ConnConfig:     connConfig,
+			MaxConnections: c.config.MaxConnections,
+			AcquireTimeout: c.config.AcquireTimeout,
 		},
 	)
-	return err
+	if err != nil {
+		return err
+	}
+
+	c.registerPoolMetrics()
+	return nil
 }
 
 func (c *Connection) Close() error {
@@ -41,77 +82,294 @@ func (c *Connection) Close() error {
 	return nil
 }
 
-const (
-	CreateExtensionsQuery = `
-        CREATE EXTENSION IF NOT EXISTS "citext";
-    `
-	CreateTypesQuery = `
-        DO $$ BEGIN
-            IF NOT EXISTS (SELECT * FROM "pg_type" WHERE "typname" = 'query_result') THEN
-                CREATE TYPE "query_result" AS ("status" INTEGER, "result" JSON);
-            END IF;
-        END$$;
-    `
-	CreateFunctionsQuery = `
-        CREATE OR REPLACE FUNCTION replace_if_empty(_value_ TEXT, _default_ TEXT)
-        RETURNS TEXT
-        AS $$
-            SELECT CASE
-                WHEN _value_ = '' THEN _default_
-                ELSE _value_
-            END;
-        $$ LANGUAGE SQL;
-    `
-)
-
-func (c *Connection) Init() error {
-	err := c.execInit(CreateExtensionsQuery)
-	if err != nil {
+func (c *Connection) prepareStmt(stmt, sql string) error {
+	if _, err := c.conn.Prepare(stmt, sql); err != nil {
 		return err
 	}
 
-	err = c.execInit(CreateTypesQuery)
+	c.stmtsMtx.Lock()
+	c.stmts[stmt] = struct{}{}
+	c.stmtsMtx.Unlock()
+	return nil
+}
+
+func (c *Connection) acquire(ctx context.Context) (*pgx.Conn, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	atomic.AddInt64(&c.waiting, 1)
+	defer atomic.AddInt64(&c.waiting, -1)
+
+	acquired := make(chan acquireResult, 1)
+	go func() {
+		conn, err := c.conn.Acquire()
+		acquired <- acquireResult{conn: conn, err: err}
+	}()
+
+	select {
+	case res := <-acquired:
+		return res.conn, res.err
+	case <-ctx.Done():
+		// pgx cannot cancel a pending Acquire, so hand the connection back once it arrives.
+		go func() {
+			if res := <-acquired; res.err == nil {
+				c.conn.Release(res.conn)
+			}
+		}()
+		return nil, ctx.Err()
+	}
+}
+
+type acquireResult struct {
+	conn *pgx.Conn
+	err  error
+}
+
+func (c *Connection) exec(ctx context.Context, sql string, args ...interface{}) (pgx.CommandTag, error) {
+	conn, err := c.acquire(ctx)
 	if err != nil {
-		return err
+		return "", err
 	}
+	defer c.conn.Release(conn)
+
+	defer c.logSlowQuery(ctx, sql, time.Now())
+	tag, err := conn.ExecEx(ctx, sql, nil, args...)
+	return tag, contextError(ctx, err)
+}
 
-	err = c.execInit(CreateFunctionsQuery)
+func (c *Connection) query(ctx context.Context, sql string, args ...interface{}) (*pooledRows, error) {
+	conn, err := c.acquire(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	start := time.Now()
+	rows, err := conn.QueryEx(ctx, sql, nil, args...)
+	if err != nil {
+		c.conn.Release(conn)
+		return nil, contextError(ctx, err)
+	}
+	return &pooledRows{Rows: rows, ctx: ctx, done: func() {
+		c.conn.Release(conn)
+		c.logSlowQuery(ctx, sql, start)
+	}}, nil
 }
 
-func (c *Connection) execInit(stmt string) error {
-	tx, err := c.conn.Begin()
+func (c *Connection) queryRow(ctx context.Context, sql string, args ...interface{}) *pooledRow {
+	conn, err := c.acquire(ctx)
 	if err != nil {
-		return err
+		return &pooledRow{ctx: ctx, err: err}
 	}
-	defer func() {
-		err = tx.Commit()
-		if err == nil {
-			c.conn.Reset()
-		}
-	}()
-	_, err = tx.Exec(stmt)
-	return err
+
+	start := time.Now()
+	return &pooledRow{row: conn.QueryRowEx(ctx, sql, nil, args...), ctx: ctx, done: func() {
+		c.conn.Release(conn)
+		c.logSlowQuery(ctx, sql, start)
+	}}
 }
 
-func (c *Connection) prepareStmt(stmt, sql string) error {
-	_, err := c.conn.Prepare(stmt, sql)
+type pooledRows struct {
+	*pgx.Rows
+	ctx  context.Context
+	done func()
+}
+
+func (r *pooledRows) Err() error {
+	return contextError(r.ctx, r.Rows.Err())
+}
+
+func (r *pooledRows) Close() {
+	r.Rows.Close()
+	if r.done != nil {
+		r.done()
+		r.done = nil
+	}
+}
+
+type pooledRow struct {
+	row  *pgx.Row
+	ctx  context.Context
+	done func()
+	err  error
+}
+
+func (r *pooledRow) Scan(dest ...interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+	defer r.done()
+	return contextError(r.ctx, r.row.Scan(dest...))
+}
+
+type Tx struct {
+	ctx  context.Context
+	conn *Connection
+	tx   *pgx.Tx
+}
+
+func (t *Tx) exec(sql string, args ...interface{}) (pgx.CommandTag, error) {
+	defer t.conn.logSlowQuery(t.ctx, sql, time.Now())
+	tag, err := t.tx.ExecEx(t.ctx, sql, nil, args...)
+	return tag, contextError(t.ctx, err)
+}
+
+func (t *Tx) queryRow(sql string, args ...interface{}) *pooledRow {
+	start := time.Now()
+	return &pooledRow{row: t.tx.QueryRowEx(t.ctx, sql, nil, args...), ctx: t.ctx, done: func() {
+		t.conn.logSlowQuery(t.ctx, sql, start)
+	}}
+}
+
+func contextError(ctx context.Context, err error) error {
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
 	return err
 }
 
-type TxOp func(tx *pgx.Tx) *errs.Error
+type TxOp func(tx *Tx) *errs.Error
+
+const (
+	MaxTxAttempts     = 5
+	TxRetryBaseDelay  = 5 * time.Millisecond
+	TxRetryMaxJitter  = 5 * time.Millisecond
+	TxConflictMessage = "transaction conflict"
+)
 
-func (c *Connection) performTxOp(txOp TxOp) *errs.Error {
-	tx, err := c.conn.Begin()
+var (
+	txConflictErr = errs.NewUnavailableError(TxConflictMessage)
+)
+
+func (c *Connection) performTxOp(ctx context.Context, level pgx.TxIsoLevel, txOp TxOp) *errs.Error {
+	return retryTx(ctx, func() *errs.Error {
+		return c.tryTxOp(ctx, level, txOp)
+	})
+}
+
+// retryTx runs attempt until it succeeds, fails with anything but a
+// serialization conflict or deadlock, or MaxTxAttempts is reached. Waiting
+// between attempts stops as soon as ctx is done.
+func retryTx(ctx context.Context, attempt func() *errs.Error) *errs.Error {
+	for n := 1; ; n++ {
+		err := attempt()
+		if err != txConflictErr || n == MaxTxAttempts || ctx.Err() != nil {
+			return err
+		}
+
+		timer := time.NewTimer(txRetryDelay(n))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return wrapError(ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// txRetryDelay is the backoff before the attempt following the given one:
+// TxRetryBaseDelay doubled per failed attempt plus up to TxRetryMaxJitter.
+func txRetryDelay(attempt int) time.Duration {
+	delay := TxRetryBaseDelay << uint(attempt-1)
+	return delay + time.Duration(rand.Int63n(int64(TxRetryMaxJitter)))
+}
+
+func (c *Connection) tryTxOp(ctx context.Context, level pgx.TxIsoLevel, txOp TxOp) *errs.Error {
+	conn, err := c.acquire(ctx)
 	if err != nil {
-		panic(err)
+		return wrapError(err)
 	}
+	defer c.conn.Release(conn)
+
+	tx, err := conn.BeginEx(ctx, &pgx.TxOptions{IsoLevel: level})
+	if err != nil {
+		return wrapError(contextError(ctx, err))
+	}
+
+	return runTx(ctx, tx, func() *errs.Error {
+		return txOp(&Tx{ctx: ctx, conn: c, tx: tx})
+	})
+}
+
+type txCompleter interface {
+	CommitEx(ctx context.Context) error
+	Rollback() error
+}
+
+// runTx runs op inside tx and commits it. The transaction is rolled back if
+// op fails, the commit fails or op panics.
+func runTx(ctx context.Context, tx txCompleter, op func() *errs.Error) *errs.Error {
+	committed := false
 	defer func() {
-		_ = tx.Commit()
+		if !committed {
+			_ = tx.Rollback()
+		}
 	}()
-	return txOp(tx)
+
+	if txErr := op(); txErr != nil {
+		return txErr
+	}
+
+	if err := tx.CommitEx(ctx); err != nil {
+		return wrapError(contextError(ctx, err))
+	}
+	committed = true
+
+	return nil
+}
+
+func wrapError(err error) *errs.Error {
+	if err == nil {
+		return nil
+	}
+	if e, ok := err.(*errs.Error); ok {
+		return e
+	}
+
+	switch err {
+	case pgx.ErrNoRows:
+		return errs.NewNotFoundError(err.Error())
+	case context.DeadlineExceeded:
+		return errs.NewTimeoutError(QueryTimeoutErrMessage)
+	case context.Canceled:
+		return errs.NewUnavailableError(QueryCanceledErrMessage)
+	case pgx.ErrDeadConn, pgx.ErrAcquireTimeout, pgx.ErrClosedPool, io.EOF, io.ErrUnexpectedEOF:
+		return errs.NewUnavailableError(DatabaseUnavailableErrMessage)
+	}
+
+	switch e := err.(type) {
+	case pgx.PgError:
+		return wrapPgError(e)
+	case *pgx.PgError:
+		return wrapPgError(*e)
+	case net.Error:
+		return errs.NewUnavailableError(DatabaseUnavailableErrMessage)
+	}
+
+	return errs.NewInternalError(err.Error())
+}
+
+func wrapPgError(err pgx.PgError) *errs.Error {
+	switch {
+	case err.Code == "40001" || err.Code == "40P01":
+		return txConflictErr
+	case err.Code == "57014":
+		return errs.NewTimeoutError(QueryTimeoutErrMessage)
+	case err.Code == "23503":
+		return errs.NewNotFoundError(err.Message)
+	case strings.HasPrefix(err.Code, "23"):
+		return errs.NewConflictError(err.Message)
+	case strings.HasPrefix(err.Code, "08"),
+		strings.HasPrefix(err.Code, "53"),
+		strings.HasPrefix(err.Code, "57P"):
+		return errs.NewUnavailableError(DatabaseUnavailableErrMessage)
+	}
+	return errs.NewInternalError(err.Message)
+}
+
+func wrapNotFoundError(err error, notFoundErr *errs.Error) *errs.Error {
+	if err == pgx.ErrNoRows {
+		return notFoundErr
+	}
+	return wrapError(err)
 }
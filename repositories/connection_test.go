@@ -0,0 +1,229 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx"
+	"io"
+	"net"
+	"testing"
+	"time"
+	"tp-project-db/errs"
+)
+
+func TestWrapError(t *testing.T) {
+	own := errs.NewForbiddenError("own")
+
+	tests := []struct {
+		name     string
+		err      error
+		category errs.Category
+		message  string
+	}{
+		{"no rows", pgx.ErrNoRows, errs.CategoryNotFound, pgx.ErrNoRows.Error()},
+		{"deadline", context.DeadlineExceeded, errs.CategoryTimeout, QueryTimeoutErrMessage},
+		{"canceled", context.Canceled, errs.CategoryUnavailable, QueryCanceledErrMessage},
+		{"dead connection", pgx.ErrDeadConn, errs.CategoryUnavailable, DatabaseUnavailableErrMessage},
+		{"acquire timeout", pgx.ErrAcquireTimeout, errs.CategoryUnavailable, DatabaseUnavailableErrMessage},
+		{"closed pool", pgx.ErrClosedPool, errs.CategoryUnavailable, DatabaseUnavailableErrMessage},
+		{"eof", io.ErrUnexpectedEOF, errs.CategoryUnavailable, DatabaseUnavailableErrMessage},
+		{"network", &net.OpError{Op: "dial", Err: errors.New("refused")}, errs.CategoryUnavailable, DatabaseUnavailableErrMessage},
+		{"serialization failure", pgx.PgError{Code: "40001"}, errs.CategoryUnavailable, TxConflictMessage},
+		{"deadlock", &pgx.PgError{Code: "40P01"}, errs.CategoryUnavailable, TxConflictMessage},
+		{"statement timeout", pgx.PgError{Code: "57014"}, errs.CategoryTimeout, QueryTimeoutErrMessage},
+		{"foreign key", pgx.PgError{Code: "23503", Message: "fk"}, errs.CategoryNotFound, "fk"},
+		{"unique", pgx.PgError{Code: "23505", Message: "dup"}, errs.CategoryConflict, "dup"},
+		{"connection exception", pgx.PgError{Code: "08006"}, errs.CategoryUnavailable, DatabaseUnavailableErrMessage},
+		{"too many connections", pgx.PgError{Code: "53300"}, errs.CategoryUnavailable, DatabaseUnavailableErrMessage},
+		{"admin shutdown", pgx.PgError{Code: "57P01"}, errs.CategoryUnavailable, DatabaseUnavailableErrMessage},
+		{"syntax error", pgx.PgError{Code: "42601", Message: "syntax"}, errs.CategoryInternal, "syntax"},
+		{"unknown", errors.New("boom"), errs.CategoryInternal, "boom"},
+		{"already wrapped", own, errs.CategoryForbidden, "own"},
+	}
+
+	for _, tt := range tests {
+		err := wrapError(tt.err)
+		if err == nil {
+			t.Errorf("%s: wrapError() = nil", tt.name)
+			continue
+		}
+		if err.Category != tt.category || err.Message != tt.message {
+			t.Errorf("%s: wrapError() = %s %q, want %s %q", tt.name, err.Category, err.Message, tt.category, tt.message)
+		}
+	}
+
+	if wrapError(nil) != nil {
+		t.Error("wrapError(nil) != nil")
+	}
+	if wrapError(own) != own {
+		t.Error("wrapError() did not return an *errs.Error unchanged")
+	}
+	if wrapError(pgx.PgError{Code: "40001"}) != txConflictErr {
+		t.Error("serialization failures must map to txConflictErr so performTxOp retries them")
+	}
+}
+
+func TestWrapNotFoundError(t *testing.T) {
+	notFound := errs.NewNotFoundError("thread not found")
+
+	if err := wrapNotFoundError(pgx.ErrNoRows, notFound); err != notFound {
+		t.Errorf("wrapNotFoundError(ErrNoRows) = %v, want the given error", err)
+	}
+	if err := wrapNotFoundError(context.DeadlineExceeded, notFound); err == nil || err.Category != errs.CategoryTimeout {
+		t.Errorf("wrapNotFoundError(DeadlineExceeded) = %v, want a timeout", err)
+	}
+}
+
+func TestContextError(t *testing.T) {
+	queryErr := errors.New("conn closed")
+
+	if err := contextError(context.Background(), queryErr); err != queryErr {
+		t.Errorf("contextError() = %v, want the query error while ctx is live", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := contextError(ctx, queryErr); err != context.Canceled {
+		t.Errorf("contextError() = %v, want context.Canceled", err)
+	}
+	if err := contextError(ctx, nil); err != nil {
+		t.Errorf("contextError(nil) = %v, want nil", err)
+	}
+}
+
+type fakeTx struct {
+	commitErr  error
+	committed  bool
+	rolledBack bool
+}
+
+func (tx *fakeTx) CommitEx(ctx context.Context) error {
+	if tx.commitErr != nil {
+		return tx.commitErr
+	}
+	tx.committed = true
+	return nil
+}
+
+func (tx *fakeTx) Rollback() error {
+	tx.rolledBack = true
+	return nil
+}
+
+func TestRunTx(t *testing.T) {
+	opErr := errs.NewConflictError("op")
+
+	tests := []struct {
+		name         string
+		op           func() *errs.Error
+		commitErr    error
+		want         *errs.Error
+		wantCommit   bool
+		wantRollback bool
+	}{
+		{"success", func() *errs.Error { return nil }, nil, nil, true, false},
+		{"op error", func() *errs.Error { return opErr }, nil, opErr, false, true},
+		{"commit conflict", func() *errs.Error { return nil }, pgx.PgError{Code: "40001"}, txConflictErr, false, true},
+	}
+	for _, tt := range tests {
+		tx := &fakeTx{commitErr: tt.commitErr}
+		if err := runTx(context.Background(), tx, tt.op); err != tt.want {
+			t.Errorf("%s: runTx() = %v, want %v", tt.name, err, tt.want)
+		}
+		if tx.committed != tt.wantCommit || tx.rolledBack != tt.wantRollback {
+			t.Errorf("%s: committed %v, rolled back %v, want %v and %v",
+				tt.name, tx.committed, tx.rolledBack, tt.wantCommit, tt.wantRollback)
+		}
+	}
+}
+
+func TestRunTxRollsBackOnPanic(t *testing.T) {
+	tx := &fakeTx{}
+	defer func() {
+		if r := recover(); r != "boom" {
+			t.Errorf("recovered %v, want the panic to propagate", r)
+		}
+		if tx.committed || !tx.rolledBack {
+			t.Errorf("committed %v, rolled back %v after a panic", tx.committed, tx.rolledBack)
+		}
+	}()
+
+	runTx(context.Background(), tx, func() *errs.Error {
+		panic("boom")
+	})
+}
+
+func TestRetryTx(t *testing.T) {
+	other := errs.NewConflictError("duplicate")
+
+	tests := []struct {
+		name     string
+		results  []*errs.Error
+		want     *errs.Error
+		attempts int
+	}{
+		{"success", []*errs.Error{nil}, nil, 1},
+		{"other errors are not retried", []*errs.Error{other}, other, 1},
+		{"conflict then success", []*errs.Error{txConflictErr, txConflictErr, nil}, nil, 3},
+		{"conflict then other error", []*errs.Error{txConflictErr, other}, other, 2},
+		{"gives up after the limit", nil, txConflictErr, MaxTxAttempts},
+	}
+	for _, tt := range tests {
+		attempts := 0
+		err := retryTx(context.Background(), func() *errs.Error {
+			attempts++
+			if attempts <= len(tt.results) {
+				return tt.results[attempts-1]
+			}
+			return txConflictErr
+		})
+		if err != tt.want || attempts != tt.attempts {
+			t.Errorf("%s: retryTx() = %v after %d attempts, want %v after %d", tt.name, err, attempts, tt.want, tt.attempts)
+		}
+	}
+}
+
+func TestRetryTxStopsWhenContextIsDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	attempts := 0
+	failed := make(chan struct{})
+	done := make(chan *errs.Error, 1)
+	go func() {
+		done <- retryTx(ctx, func() *errs.Error {
+			if attempts++; attempts == 1 {
+				close(failed)
+			}
+			return txConflictErr
+		})
+	}()
+
+	// Cancel once the first attempt has failed and retryTx is backing off.
+	<-failed
+	cancel()
+
+	select {
+	case err := <-done:
+		// The cancellation may also land before the first attempt returns.
+		if err == nil || err != txConflictErr && err.Message != QueryCanceledErrMessage {
+			t.Errorf("retryTx() = %v, want %s", err, QueryCanceledErrMessage)
+		}
+		if attempts != 1 {
+			t.Errorf("retryTx() made %d attempts, want it to stop after the first", attempts)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("retryTx() kept retrying after the context was canceled")
+	}
+}
+
+func TestTxRetryDelay(t *testing.T) {
+	for attempt := 1; attempt < MaxTxAttempts; attempt++ {
+		base := TxRetryBaseDelay << uint(attempt-1)
+		for i := 0; i < 10; i++ {
+			if d := txRetryDelay(attempt); d < base || d >= base+TxRetryMaxJitter {
+				t.Errorf("txRetryDelay(%d) = %v, want [%v, %v)", attempt, d, base, base+TxRetryMaxJitter)
+			}
+		}
+	}
+}
@@ -1,7 +1,10 @@
 package repositories
 
 import (
+	"context"
 	"database/sql"
+	"github.com/jackc/pgx"
+	"net/http"
 	"tp-project-db/errs"
 	"tp-project-db/models"
 )
@@ -10,90 +13,28 @@ const (
 	ForumNotFoundErrMessage           = "forum not found"
 	ForumAdminNotFoundErrMessage      = "forum admin not found"
 	ForumAttributeDuplicateErrMessage = "forum attribute duplicate"
+	ForumNotModeratorErrMessage       = "only the forum admin can change forum settings"
 )
 
 const (
-	CreateForumTableQuery = `
-	    CREATE TABLE IF NOT EXISTS "forum" (
-            "slug" CITEXT
-                CONSTRAINT "forum_slug_pk" PRIMARY KEY,
-            "admin" CITEXT
-                CONSTRAINT "forum_admin_not_null" NOT NULL
-                CONSTRAINT "forum_admin_fk" REFERENCES "user"("nickname"),
-            "title" TEXT
-                CONSTRAINT "forum_title_not_null" NOT NULL,
-            "num_threads" INTEGER
-                DEFAULT(0)
-                CONSTRAINT "forum_num_threads_not_null" NOT NULL,
-            "num_posts" BIGINT
-                DEFAULT(0)
-                CONSTRAINT "forum_num_posts_not_null" NOT NULL
-        );
-
-        CREATE INDEX IF NOT EXISTS "forum_admin_idx" ON "forum"("admin");
-
-        CREATE TABLE IF NOT EXISTS "forum_user" (
-            "forum" CITEXT COLLATE "ucs_basic"
-                CONSTRAINT "forum_user_forum_not_null" NOT NULL
-                CONSTRAINT "forum_user_forum_fk" REFERENCES "forum"("slug"),
-            "user" CITEXT COLLATE "ucs_basic"
-                CONSTRAINT "forum_user_user_not_null" NOT NULL
-                CONSTRAINT "forum_user_user_fk" REFERENCES "user"("nickname"),
-            CONSTRAINT "forum_user_pk" PRIMARY KEY("user","forum")
-        );
-
-        CREATE INDEX IF NOT EXISTS "forum_user_forum_idx" ON "forum_user"("forum");
-
-        CREATE OR REPLACE FUNCTION insert_forum(
-            _slug_ CITEXT, _admin_ CITEXT, _title_ TEXT
-        )
-        RETURNS "query_result"
-        AS $$
-        DECLARE _user_ CITEXT;
-        DECLARE _existing_ JSON;
-        BEGIN
-            SELECT u."nickname"
-            FROM "user" u
-            WHERE u."nickname" = _admin_
-            INTO _user_;
-
-            IF _user_ IS NULL THEN
-                RETURN (404, _existing_);
-            END IF;
-
-            SELECT json_build_object(
-                'slug', f."slug",
-                'user', f."admin",
-                'title', f."title",
-                'threads', f."num_threads",
-                'posts', f."num_posts"
-            )
-            FROM "forum" f
-            WHERE f."slug" = _slug_
-            INTO _existing_;
-
-            IF _existing_ IS NOT NULL THEN
-                RETURN (409, _existing_);
-            END IF;
-
-            INSERT INTO "forum"("slug","admin","title")
-            VALUES(_slug_,_user_,_title_)
-            RETURNING json_build_object(
-                'slug', "slug",
-                'user', "admin",
-                'title', "title",
-                'threads', "num_threads",
-                'posts', "num_posts"
-            ) INTO _existing_;
-
-            RETURN (201, _existing_);
-        END;
-        $$ LANGUAGE PLPGSQL;
-    `
-
-	InsertForumStatement             = "insert_forum_statement"
-	SelectForumExistsBySlugStatement = "select_forum_exists_by_slug_statement"
-	SelectForumBySlugStatement       = "select_forum_by_slug_statement"
+	ForumNotFoundErrCode           = "forum_not_found"
+	ForumAdminNotFoundErrCode      = "forum_admin_not_found"
+	ForumAttributeDuplicateErrCode = "forum_attribute_duplicate"
+	ForumNotModeratorErrCode       = "not_moderator"
+)
+
+const (
+	InsertForumStatement              = "insert_forum_statement"
+	SelectForumExistsBySlugStatement  = "select_forum_exists_by_slug_statement"
+	SelectForumBySlugStatement        = "select_forum_by_slug_statement"
+	SelectForumForUpdateStatement     = "select_forum_for_update_statement"
+	UpdateForumSearchConfigStatement  = "update_forum_search_config_statement"
+	UpdateForumPostsSearchStatement   = "update_forum_posts_search_statement"
+	UpdateForumThreadsSearchStatement = "update_forum_threads_search_statement"
+)
+
+const (
+	SearchReindexBatchSize = 1000
 )
 
 type ForumRepository struct {
@@ -101,24 +42,25 @@ type ForumRepository struct {
 	notFoundErr      *errs.Error
 	conflictErr      *errs.Error
 	adminNotFoundErr *errs.Error
+	notModeratorErr  *errs.Error
 }
 
 func NewForumRepository(conn *Connection) *ForumRepository {
 	return &ForumRepository{
-		conn:             conn,
-		notFoundErr:      errs.NewNotFoundError(ForumNotFoundErrMessage),
-		conflictErr:      errs.NewConflictError(ForumAttributeDuplicateErrMessage),
-		adminNotFoundErr: errs.NewNotFoundError(ForumAdminNotFoundErrMessage),
+		conn: conn,
+		notFoundErr: errs.NewNotFoundError(ForumNotFoundErrMessage).
+			WithCode(ForumNotFoundErrCode).WithEntity("forum", "slug"),
+		conflictErr: errs.NewConflictError(ForumAttributeDuplicateErrMessage).
+			WithCode(ForumAttributeDuplicateErrCode).WithEntity("forum", "slug"),
+		adminNotFoundErr: errs.NewNotFoundError(ForumAdminNotFoundErrMessage).
+			WithCode(ForumAdminNotFoundErrCode).WithEntity("user", "user"),
+		notModeratorErr: errs.NewForbiddenError(ForumNotModeratorErrMessage).
+			WithCode(ForumNotModeratorErrCode).WithEntity("user", "moderator"),
 	}
 }
 
 func (r *ForumRepository) Init() error {
-	err := r.conn.execInit(CreateForumTableQuery)
-	if err != nil {
-		return err
-	}
-
-	err = r.conn.prepareStmt(InsertForumStatement, `
+	err := r.conn.prepareStmt(InsertForumStatement, `
         SELECT * FROM insert_forum($1,$2,$3);
     `)
 	if err != nil {
@@ -142,26 +84,86 @@ func (r *ForumRepository) Init() error {
 		return err
 	}
 
+	err = r.conn.prepareStmt(SelectForumForUpdateStatement, `
+        SELECT f."slug", f."admin" = $2
+        FROM "forum" f
+        WHERE f."slug" = $1
+        FOR UPDATE;
+    `)
+	if err != nil {
+		return err
+	}
+
+	err = r.conn.prepareStmt(UpdateForumSearchConfigStatement, `
+        UPDATE "forum" f SET
+            "search_config" = $2
+        WHERE f."slug" = $1;
+    `)
+	if err != nil {
+		return err
+	}
+
+	err = r.conn.prepareStmt(UpdateForumPostsSearchStatement, `
+        WITH "batch" AS (
+            SELECT p."id" FROM "post" p
+            WHERE p."forum" = $1 AND p."id" > $2::BIGINT
+            ORDER BY p."id"
+            LIMIT $3
+        ), "updated" AS (
+            UPDATE "post" p SET
+                "search_vector" = post_search_vector(forum_search_config(p."forum"), p."message")
+            FROM "batch" b
+            WHERE p."id" = b."id"
+            RETURNING p."id"
+        )
+        SELECT COUNT(*), COALESCE(MAX(u."id"), 0)::BIGINT FROM "updated" u;
+    `)
+	if err != nil {
+		return err
+	}
+
+	err = r.conn.prepareStmt(UpdateForumThreadsSearchStatement, `
+        WITH "batch" AS (
+            SELECT th."id" FROM "thread" th
+            WHERE th."forum" = $1 AND th."id" > $2::BIGINT
+            ORDER BY th."id"
+            LIMIT $3
+        ), "updated" AS (
+            UPDATE "thread" th SET
+                "search_vector" = thread_search_vector(forum_search_config(th."forum"), th."title", th."message")
+            FROM "batch" b
+            WHERE th."id" = b."id"
+            RETURNING th."id"
+        )
+        SELECT COUNT(*), COALESCE(MAX(u."id"), 0)::BIGINT FROM "updated" u;
+    `)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
-func (r *ForumRepository) CreateForum(forum *models.Forum, existing *sql.NullString) int {
+func (r *ForumRepository) CreateForum(ctx context.Context, forum *models.Forum, existing *sql.NullString) (int, *errs.Error) {
 	var status int
 
-	row := r.conn.conn.QueryRow(InsertForumStatement,
+	row := r.conn.queryRow(ctx, InsertForumStatement,
 		&forum.Slug, &forum.Admin, &forum.Title,
 	)
 	if err := row.Scan(&status, existing); err != nil {
-		panic(err)
+		return 0, wrapError(err)
+	}
+	if status == http.StatusNotFound {
+		return status, r.adminNotFoundErr
 	}
 
-	return status
+	return status, nil
 }
 
-func (r *ForumRepository) FindForum(forum *models.Forum) *errs.Error {
-	rows, err := r.conn.conn.Query(SelectForumBySlugStatement, &forum.Slug)
+func (r *ForumRepository) FindForum(ctx context.Context, forum *models.Forum) *errs.Error {
+	rows, err := r.conn.query(ctx, SelectForumBySlugStatement, &forum.Slug)
 	if err != nil {
-		panic(err)
+		return wrapError(err)
 	}
 	defer rows.Close()
 
@@ -173,9 +175,12 @@ func (r *ForumRepository) FindForum(forum *models.Forum) *errs.Error {
 			&forum.NumThreads, &forum.NumPosts,
 		)
 		if err != nil {
-			panic(err)
+			return wrapError(err)
 		}
 	}
+	if err = rows.Err(); err != nil {
+		return wrapError(err)
+	}
 
 	if !found {
 		return r.notFoundErr
@@ -183,3 +188,44 @@ func (r *ForumRepository) FindForum(forum *models.Forum) *errs.Error {
 
 	return nil
 }
+
+// UpdateForumSearchConfig only locks the forum to change the setting. The
+// search vectors are rebuilt afterwards in separately committed batches; a
+// rebuild that fails halfway is finished by repeating the request.
+func (r *ForumRepository) UpdateForumSearchConfig(ctx context.Context, config *models.ForumSearchConfig) *errs.Error {
+	err := r.conn.performTxOp(ctx, pgx.ReadCommitted, func(tx *Tx) *errs.Error {
+		var isAdmin bool
+		row := tx.queryRow(SelectForumForUpdateStatement, &config.Forum, &config.Moderator)
+		if err := row.Scan(&config.Forum, &isAdmin); err != nil {
+			return wrapNotFoundError(err, r.notFoundErr)
+		}
+		if !isAdmin {
+			return r.notModeratorErr
+		}
+
+		_, err := tx.exec(UpdateForumSearchConfigStatement, &config.Forum, &config.Config)
+		return wrapError(err)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := r.reindexSearch(ctx, UpdateForumPostsSearchStatement, config.Forum); err != nil {
+		return err
+	}
+	return r.reindexSearch(ctx, UpdateForumThreadsSearchStatement, config.Forum)
+}
+
+func (r *ForumRepository) reindexSearch(ctx context.Context, stmt, forum string) *errs.Error {
+	var after int64
+	for {
+		var updated int64
+		row := r.conn.queryRow(ctx, stmt, &forum, &after, SearchReindexBatchSize)
+		if err := row.Scan(&updated, &after); err != nil {
+			return wrapError(err)
+		}
+		if updated < SearchReindexBatchSize {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"tp-project-db/models"
+)
+
+const (
+	DatabaseComponent   = "database"
+	MigrationsComponent = "migrations"
+	StatementsComponent = "statements"
+)
+
+const (
+	StatementsNotPreparedMessage = "statements are not prepared yet"
+)
+
+type HealthRepository struct {
+	conn     *Connection
+	migrator *Migrator
+	prepared int32
+}
+
+func NewHealthRepository(conn *Connection, migrator *Migrator) *HealthRepository {
+	return &HealthRepository{
+		conn:     conn,
+		migrator: migrator,
+	}
+}
+
+func (r *HealthRepository) MarkPrepared() {
+	atomic.StoreInt32(&r.prepared, 1)
+}
+
+func (r *HealthRepository) CheckReadiness(ctx context.Context, health *models.Health) {
+	health.Components[DatabaseComponent] = r.checkDatabase(ctx)
+	health.Components[MigrationsComponent] = r.checkMigrations(ctx)
+	health.Components[StatementsComponent] = r.checkStatements()
+}
+
+func (r *HealthRepository) checkDatabase(ctx context.Context) models.ComponentHealth {
+	var one int32
+	if err := r.conn.queryRow(ctx, `SELECT 1;`).Scan(&one); err != nil {
+		return unavailable(wrapError(err).Message)
+	}
+	return models.ComponentHealth{Status: models.HealthStatusOK}
+}
+
+func (r *HealthRepository) checkMigrations(ctx context.Context) models.ComponentHealth {
+	pending, err := r.migrator.Pending(ctx)
+	if err != nil {
+		return unavailable(wrapError(err).Message)
+	}
+	if len(pending) > 0 {
+		return unavailable(fmt.Sprintf("%d pending migrations, first is %04d_%s",
+			len(pending), pending[0].Version, pending[0].Name))
+	}
+	return models.ComponentHealth{Status: models.HealthStatusOK}
+}
+
+func (r *HealthRepository) checkStatements() models.ComponentHealth {
+	if atomic.LoadInt32(&r.prepared) == 0 {
+		return unavailable(StatementsNotPreparedMessage)
+	}
+
+	r.conn.stmtsMtx.RLock()
+	n := len(r.conn.stmts)
+	r.conn.stmtsMtx.RUnlock()
+	return models.ComponentHealth{
+		Status:  models.HealthStatusOK,
+		Message: fmt.Sprintf("%d statements prepared", n),
+	}
+}
+
+func unavailable(message string) models.ComponentHealth {
+	return models.ComponentHealth{
+		Status:  models.HealthStatusUnavailable,
+		Message: message,
+	}
+}
@@ -0,0 +1,21 @@
+package repositories
+
+import (
+	"context"
+	"time"
+	"tp-project-db/logging"
+)
+
+func (c *Connection) logSlowQuery(ctx context.Context, sql string, start time.Time) {
+	dt := time.Since(start)
+	if dt < c.config.SlowQueryThreshold {
+		return
+	}
+
+	logging.Default.Warn("slow query", logging.Fields{
+		logging.RequestIDField: logging.RequestID(ctx),
+		"statement":            c.statementLabel(sql),
+		"sql":                  sql,
+		"duration_ms":          float64(dt) / float64(time.Millisecond),
+	})
+}
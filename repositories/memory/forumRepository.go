@@ -0,0 +1,90 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"github.com/mailru/easyjson"
+	"net/http"
+	"tp-project-db/errs"
+	"tp-project-db/models"
+	"tp-project-db/repositories"
+)
+
+type ForumRepository struct {
+	storage          *Storage
+	notFoundErr      *errs.Error
+	adminNotFoundErr *errs.Error
+	notModeratorErr  *errs.Error
+}
+
+func NewForumRepository(storage *Storage) *ForumRepository {
+	return &ForumRepository{
+		storage: storage,
+		notFoundErr: errs.NewNotFoundError(repositories.ForumNotFoundErrMessage).
+			WithCode(repositories.ForumNotFoundErrCode).WithEntity("forum", "slug"),
+		adminNotFoundErr: errs.NewNotFoundError(repositories.ForumAdminNotFoundErrMessage).
+			WithCode(repositories.ForumAdminNotFoundErrCode).WithEntity("user", "user"),
+		notModeratorErr: errs.NewForbiddenError(repositories.ForumNotModeratorErrMessage).
+			WithCode(repositories.ForumNotModeratorErrCode).WithEntity("user", "moderator"),
+	}
+}
+
+func (r *ForumRepository) CreateForum(ctx context.Context, forum *models.Forum, existing *sql.NullString) (int, *errs.Error) {
+	s := r.storage
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	admin, ok := s.users[key(forum.Admin)]
+	if !ok {
+		return http.StatusNotFound, r.adminNotFoundErr
+	}
+
+	if f, ok := s.forums[key(forum.Slug)]; ok {
+		b, _ := easyjson.Marshal(f)
+		*existing = sql.NullString{Valid: true, String: string(b)}
+		return http.StatusConflict, nil
+	}
+
+	f := models.Forum{
+		Slug:  forum.Slug,
+		Title: forum.Title,
+		Admin: admin.Nickname,
+	}
+	s.forums[key(f.Slug)] = &f
+
+	b, _ := easyjson.Marshal(&f)
+	*existing = sql.NullString{Valid: true, String: string(b)}
+	return http.StatusCreated, nil
+}
+
+func (r *ForumRepository) FindForum(ctx context.Context, forum *models.Forum) *errs.Error {
+	s := r.storage
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	f, ok := s.forums[key(forum.Slug)]
+	if !ok {
+		return r.notFoundErr
+	}
+
+	*forum = *f
+	return nil
+}
+
+func (r *ForumRepository) UpdateForumSearchConfig(ctx context.Context, config *models.ForumSearchConfig) *errs.Error {
+	s := r.storage
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	f, ok := s.forums[key(config.Forum)]
+	if !ok {
+		return r.notFoundErr
+	}
+	if key(f.Admin) != key(config.Moderator) {
+		return r.notModeratorErr
+	}
+
+	config.Forum = f.Slug
+	s.searchConfs[key(f.Slug)] = config.Config
+	return nil
+}
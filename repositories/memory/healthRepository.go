@@ -0,0 +1,24 @@
+package memory
+
+import (
+	"context"
+	"tp-project-db/models"
+)
+
+const (
+	StorageComponent = "storage"
+)
+
+type HealthRepository struct {
+	storage *Storage
+}
+
+func NewHealthRepository(storage *Storage) *HealthRepository {
+	return &HealthRepository{
+		storage: storage,
+	}
+}
+
+func (r *HealthRepository) CheckReadiness(ctx context.Context, health *models.Health) {
+	health.Components[StorageComponent] = models.ComponentHealth{Status: models.HealthStatusOK}
+}
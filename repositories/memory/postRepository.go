@@ -0,0 +1,514 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"tp-project-db/consts"
+	"tp-project-db/errs"
+	"tp-project-db/models"
+	"tp-project-db/repositories"
+)
+
+type PostRepository struct {
+	storage           *Storage
+	notFoundErr       *errs.Error
+	conflictErr       *errs.Error
+	authorNotFoundErr *errs.Error
+	threadNotFoundErr *errs.Error
+	deletedErr        *errs.Error
+	notModeratorErr   *errs.Error
+	editorNotFoundErr *errs.Error
+	userNotFoundErr   *errs.Error
+	threadDeletedErr  *errs.Error
+	threadArchivedErr *errs.Error
+}
+
+func NewPostRepository(storage *Storage) *PostRepository {
+	return &PostRepository{
+		storage: storage,
+		notFoundErr: errs.NewNotFoundError(repositories.PostNotFoundErrMessage).
+			WithCode(repositories.PostNotFoundErrCode).WithEntity("post", "id"),
+		conflictErr: errs.NewConflictError(repositories.PostParentNotFoundErrMessage).
+			WithCode(repositories.PostParentNotFoundErrCode).WithEntity("post", "parent"),
+		authorNotFoundErr: errs.NewNotFoundError(repositories.PostAuthorNotFoundErrMessage).
+			WithCode(repositories.PostAuthorNotFoundErrCode).WithEntity("user", "author"),
+		threadNotFoundErr: errs.NewNotFoundError(repositories.PostThreadNotFoundErrMessage).
+			WithCode(repositories.PostThreadNotFoundErrCode).WithEntity("thread", "slug_or_id"),
+		deletedErr: errs.NewConflictError(repositories.PostDeletedErrMessage).
+			WithCode(repositories.PostDeletedErrCode).WithEntity("post", "id"),
+		notModeratorErr: errs.NewForbiddenError(repositories.PostNotModeratorErrMessage).
+			WithCode(repositories.PostNotModeratorErrCode).WithEntity("user", "moderator"),
+		editorNotFoundErr: errs.NewNotFoundError(repositories.PostEditorNotFoundErrMessage).
+			WithCode(repositories.PostEditorNotFoundErrCode).WithEntity("user", "editor"),
+		userNotFoundErr: errs.NewNotFoundError(repositories.UserNotFoundErrMessage).
+			WithCode(repositories.UserNotFoundErrCode).WithEntity("user", "nickname"),
+		threadDeletedErr: errs.NewConflictError(repositories.ThreadDeletedErrMessage).
+			WithCode(repositories.ThreadDeletedErrCode).WithEntity("thread", "slug_or_id"),
+		threadArchivedErr: errs.NewConflictError(repositories.ThreadArchivedErrMessage).
+			WithCode(repositories.ThreadArchivedErrCode).WithEntity("thread", "slug_or_id"),
+	}
+}
+
+func (r *PostRepository) checkThreadWritable(th *models.Thread) *errs.Error {
+	if th.IsDeleted {
+		return r.threadDeletedErr
+	}
+	if th.IsArchived {
+		return r.threadArchivedErr
+	}
+	if th.IsClosed {
+		return repositories.NewThreadClosedError(th.CloseReason)
+	}
+	return nil
+}
+
+func (r *PostRepository) CreatePosts(ctx context.Context, posts *models.Posts, args *repositories.CreatePostArgs) *errs.Error {
+	s := r.storage
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	th, ok := s.threads[args.ThreadID]
+	if !ok {
+		return r.threadNotFoundErr
+	}
+	if err := r.checkThreadWritable(th); err != nil {
+		return err
+	}
+	forum := s.forums[key(th.Forum)]
+
+	arr := *posts
+	for i := range arr {
+		if arr[i].ParentID != 0 {
+			parent, ok := s.posts[arr[i].ParentID]
+			if !ok || parent.Thread != th.ID {
+				return r.conflictErr
+			}
+		}
+		if _, ok := s.users[key(arr[i].Author)]; !ok {
+			return r.authorNotFoundErr
+		}
+	}
+
+	for i := range arr {
+		postPtr := &arr[i]
+
+		s.lastPostID++
+		postPtr.ID = s.lastPostID
+		postPtr.Author = s.users[key(postPtr.Author)].Nickname
+		postPtr.Forum = forum.Slug
+		postPtr.Thread = th.ID
+		postPtr.CreatedTimestamp = args.Timestamp
+		postPtr.IsEdited = false
+
+		p := &post{Post: *postPtr}
+		if postPtr.ParentID == 0 {
+			p.path = []int64{p.ID}
+			p.pathRoot = p.ID
+		} else {
+			parent := s.posts[postPtr.ParentID]
+			p.path = append(append(make([]int64, 0, len(parent.path)+1), parent.path...), p.ID)
+			p.pathRoot = parent.pathRoot
+		}
+
+		s.posts[p.ID] = p
+		s.threadPosts[th.ID] = append(s.threadPosts[th.ID], p)
+		s.addForumUser(forum.Slug, postPtr.Author)
+	}
+	forum.NumPosts += int64(len(arr))
+
+	return nil
+}
+
+func (r *PostRepository) FindPost(ctx context.Context, post *models.Post) *errs.Error {
+	s := r.storage
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	p, ok := s.posts[post.ID]
+	if !ok {
+		return r.notFoundErr
+	}
+
+	*post = p.view()
+	return nil
+}
+
+func (r *PostRepository) FindFullPost(ctx context.Context, post *models.PostFull) *errs.Error {
+	s := r.storage
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	mapPtr := (*map[string]interface{})(post)
+	postPtr := (*mapPtr)["post"].(*models.Post)
+
+	p, ok := s.posts[postPtr.ID]
+	if !ok {
+		return r.notFoundErr
+	}
+	*postPtr = p.view()
+
+	if fItf, ok := (*mapPtr)["forum"]; ok {
+		*fItf.(*models.Forum) = *s.forums[key(p.Forum)]
+	}
+	if thItf, ok := (*mapPtr)["thread"]; ok {
+		*thItf.(*models.Thread) = threadView(s.threads[p.Thread])
+	}
+	if uItf, ok := (*mapPtr)["author"]; ok {
+		if p.IsDeleted {
+			delete(*mapPtr, "author")
+		} else {
+			*uItf.(*models.User) = *s.users[key(p.Author)]
+		}
+	}
+	if revItf, ok := (*mapPtr)["revisions"]; ok {
+		if p.IsDeleted {
+			*revItf.(*int64) = 0
+		} else {
+			*revItf.(*int64) = int64(len(p.revisionList()))
+		}
+	}
+
+	return nil
+}
+
+func (r *PostRepository) FindPostsByThread(ctx context.Context, args *repositories.PostsByThreadSearchArgs) (*models.Posts, *errs.Error) {
+	s := r.storage
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	th := s.findThread(int32(args.ThreadID.Int64), args.ThreadSlug, args.ThreadID.Valid)
+	if th == nil {
+		return nil, r.threadNotFoundErr
+	}
+
+	var selected []*post
+	switch args.SortType {
+	case "flat":
+		selected = r.sortFlat(s.threadPosts[th.ID], args)
+	case "tree":
+		selected = r.sortTree(s.threadPosts[th.ID], args)
+	case "parent_tree":
+		selected = r.sortParentTree(s.threadPosts[th.ID], args)
+	}
+
+	posts := make([]models.Post, 0, len(selected))
+	for _, p := range selected {
+		posts = append(posts, p.view())
+	}
+
+	return (*models.Posts)(&posts), nil
+}
+
+func (r *PostRepository) sortFlat(all []*post, args *repositories.PostsByThreadSearchArgs) []*post {
+	since := int64(args.Since)
+
+	posts := make([]*post, 0, len(all))
+	for _, p := range all {
+		if since > 0 && (args.Desc && p.ID >= since || !args.Desc && p.ID <= since) {
+			continue
+		}
+		posts = append(posts, p)
+	}
+
+	sort.Slice(posts, func(i, j int) bool {
+		if args.Desc {
+			return posts[i].ID > posts[j].ID
+		}
+		return posts[i].ID < posts[j].ID
+	})
+
+	return limitPosts(posts, args.Limit)
+}
+
+func (r *PostRepository) sortTree(all []*post, args *repositories.PostsByThreadSearchArgs) []*post {
+	var sincePath []int64
+	if args.Since > 0 {
+		sincePost, ok := r.storage.posts[int64(args.Since)]
+		if !ok {
+			return nil
+		}
+		sincePath = sincePost.path
+	}
+
+	posts := make([]*post, 0, len(all))
+	for _, p := range all {
+		if sincePath != nil {
+			cmp := comparePaths(p.path, sincePath)
+			if args.Desc && cmp >= 0 || !args.Desc && cmp <= 0 {
+				continue
+			}
+		}
+		posts = append(posts, p)
+	}
+
+	sort.Slice(posts, func(i, j int) bool {
+		if args.Desc {
+			return comparePaths(posts[i].path, posts[j].path) > 0
+		}
+		return comparePaths(posts[i].path, posts[j].path) < 0
+	})
+
+	return limitPosts(posts, args.Limit)
+}
+
+func (r *PostRepository) sortParentTree(all []*post, args *repositories.PostsByThreadSearchArgs) []*post {
+	var sinceRoot int64
+	if args.Since > 0 {
+		sincePost, ok := r.storage.posts[int64(args.Since)]
+		if !ok {
+			return nil
+		}
+		sinceRoot = sincePost.pathRoot
+	}
+
+	roots := make([]*post, 0)
+	for _, p := range all {
+		if p.ParentID != 0 {
+			continue
+		}
+		if sinceRoot > 0 && (args.Desc && p.ID >= sinceRoot || !args.Desc && p.ID <= sinceRoot) {
+			continue
+		}
+		roots = append(roots, p)
+	}
+
+	sort.Slice(roots, func(i, j int) bool {
+		if args.Desc {
+			return roots[i].ID > roots[j].ID
+		}
+		return roots[i].ID < roots[j].ID
+	})
+	roots = limitPosts(roots, args.Limit)
+
+	selected := make(map[int64]bool, len(roots))
+	for _, root := range roots {
+		selected[root.ID] = true
+	}
+
+	posts := make([]*post, 0, len(all))
+	for _, p := range all {
+		if !selected[p.pathRoot] {
+			continue
+		}
+		if args.MaxDepth.Valid && int64(len(p.path)-1) > args.MaxDepth.Int64 {
+			continue
+		}
+		posts = append(posts, p)
+	}
+
+	sort.Slice(posts, func(i, j int) bool {
+		if args.Desc && posts[i].pathRoot != posts[j].pathRoot {
+			return posts[i].pathRoot > posts[j].pathRoot
+		}
+		return comparePaths(posts[i].path, posts[j].path) < 0
+	})
+
+	return posts
+}
+
+func (r *PostRepository) FindPostsByAuthor(ctx context.Context, args *repositories.AuthorSearchArgs) (*models.Posts, *errs.Error) {
+	s := r.storage
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	if _, ok := s.users[key(args.Author)]; !ok {
+		return nil, r.userNotFoundErr
+	}
+
+	selected := make([]*post, 0)
+	for _, p := range s.posts {
+		if key(p.Author) != key(args.Author) || p.IsDeleted || s.threads[p.Thread].IsDeleted {
+			continue
+		}
+		if !inAuthorPage(args, p.Forum, p.ID) {
+			continue
+		}
+		selected = append(selected, p)
+	}
+	sort.Slice(selected, func(i, j int) bool {
+		return (selected[i].ID < selected[j].ID) != args.Desc
+	})
+	selected = limitPosts(selected, args.Limit)
+
+	posts := make([]models.Post, 0, len(selected))
+	for _, p := range selected {
+		posts = append(posts, p.view())
+	}
+	return (*models.Posts)(&posts), nil
+}
+
+func (r *PostRepository) FindPostSubtree(ctx context.Context, args *repositories.PostSubtreeArgs) (*models.Posts, *errs.Error) {
+	s := r.storage
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	target, ok := s.posts[args.ID]
+	if !ok {
+		return nil, r.notFoundErr
+	}
+
+	selected := make([]*post, 0)
+	for _, p := range s.threadPosts[target.Thread] {
+		if !p.inSubtree(target) {
+			continue
+		}
+		if args.Depth.Valid && int64(len(p.path)-len(target.path)) > args.Depth.Int64 {
+			continue
+		}
+		selected = append(selected, p)
+	}
+	sort.Slice(selected, func(i, j int) bool {
+		return comparePaths(selected[i].path, selected[j].path) < 0
+	})
+
+	posts := make([]models.Post, 0, len(selected))
+	for _, p := range selected {
+		posts = append(posts, p.view())
+	}
+	return (*models.Posts)(&posts), nil
+}
+
+func (r *PostRepository) FindPostAncestors(ctx context.Context, id int64) (*models.Posts, *errs.Error) {
+	s := r.storage
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	target, ok := s.posts[id]
+	if !ok {
+		return nil, r.notFoundErr
+	}
+
+	posts := make([]models.Post, 0, len(target.path))
+	for _, ancestorID := range target.path {
+		posts = append(posts, s.posts[ancestorID].view())
+	}
+	return (*models.Posts)(&posts), nil
+}
+
+func inAuthorPage(args *repositories.AuthorSearchArgs, forum string, id int64) bool {
+	if args.Forum != consts.EmptyString && key(forum) != key(args.Forum) {
+		return false
+	}
+	if args.Since <= 0 {
+		return true
+	}
+	if args.Desc {
+		return id < int64(args.Since)
+	}
+	return id > int64(args.Since)
+}
+
+func (r *PostRepository) UpdatePost(ctx context.Context, post *models.Post, args *repositories.UpdatePostArgs) *errs.Error {
+	s := r.storage
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	p, ok := s.posts[post.ID]
+	if !ok {
+		return r.notFoundErr
+	}
+	if p.IsDeleted {
+		return r.deletedErr
+	}
+
+	if p.Message != post.Message {
+		editor := p.Author
+		if args.Editor != "" {
+			u, ok := s.users[key(args.Editor)]
+			if !ok {
+				return r.editorNotFoundErr
+			}
+			editor = u.Nickname
+		}
+
+		p.revisions = append(p.revisionList(), models.PostRevision{
+			Revision:         int32(len(p.revisionList()) + 1),
+			Message:          post.Message,
+			Editor:           editor,
+			CreatedTimestamp: args.Timestamp,
+		})
+		p.Message = post.Message
+		p.IsEdited = true
+	}
+
+	*post = p.view()
+	return nil
+}
+
+func (r *PostRepository) FindPostRevisions(ctx context.Context, id int64) (*models.PostRevisions, *errs.Error) {
+	s := r.storage
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	p, ok := s.posts[id]
+	if !ok {
+		return nil, r.notFoundErr
+	}
+	if p.IsDeleted {
+		return nil, r.deletedErr
+	}
+
+	revisions := append([]models.PostRevision(nil), p.revisionList()...)
+	return (*models.PostRevisions)(&revisions), nil
+}
+
+func (r *PostRepository) DeletePost(ctx context.Context, post *models.Post) *errs.Error {
+	s := r.storage
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	p, ok := s.posts[post.ID]
+	if !ok {
+		return r.notFoundErr
+	}
+
+	p.revisions = p.revisionList()
+	p.Message = ""
+	p.IsDeleted = true
+
+	*post = p.view()
+	return nil
+}
+
+func (r *PostRepository) PurgePostSubtree(ctx context.Context, deletion *models.PostDeletion, moderator string) *errs.Error {
+	s := r.storage
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	target, ok := s.posts[deletion.ID]
+	if !ok {
+		return r.notFoundErr
+	}
+	forum := s.forums[key(target.Forum)]
+	if key(forum.Admin) != key(moderator) {
+		return r.notModeratorErr
+	}
+
+	authors := make(map[string]string)
+	kept := make([]*post, 0, len(s.threadPosts[target.Thread]))
+	for _, p := range s.threadPosts[target.Thread] {
+		if !p.inSubtree(target) {
+			kept = append(kept, p)
+			continue
+		}
+		delete(s.posts, p.ID)
+		authors[key(p.Author)] = p.Author
+	}
+	deletion.Deleted = int64(len(s.threadPosts[target.Thread]) - len(kept))
+	s.threadPosts[target.Thread] = kept
+
+	if !s.threads[target.Thread].IsDeleted {
+		forum.NumPosts -= deletion.Deleted
+	}
+	for _, author := range authors {
+		s.removeStaleForumUser(forum.Slug, author)
+	}
+	return nil
+}
+
+func limitPosts(posts []*post, limit int) []*post {
+	if limit > 0 && len(posts) > limit {
+		return posts[:limit]
+	}
+	return posts
+}
@@ -0,0 +1,277 @@
+package memory
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"tp-project-db/models"
+	"tp-project-db/repositories"
+)
+
+func TestDeletePostKeepsTree(t *testing.T) {
+	f := newFixture(t)
+	th := f.thread("bob", "", 0)
+	root := f.post(th, 0, "alice", "root")
+	child := f.post(th, root, "bob", "child")
+
+	p := models.Post{ID: root}
+	if err := f.posts.DeletePost(ctx, &p); err != nil {
+		t.Fatal(err)
+	}
+	if !p.IsDeleted || p.Message != "" || p.Author != "" {
+		t.Errorf("DeletePost() = %+v, want a tombstone without message and author", p)
+	}
+
+	c := models.Post{ID: child}
+	if err := f.posts.FindPost(ctx, &c); err != nil || c.ParentID != root {
+		t.Errorf("FindPost(child) = %+v, %v, want it still under the tombstone", c, err)
+	}
+
+	full := models.PostFull{"post": &models.Post{ID: root}, "author": &models.User{}}
+	var revisions int64
+	full["revisions"] = &revisions
+	if err := f.posts.FindFullPost(ctx, &full); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := full["author"]; ok {
+		t.Error("FindFullPost() returned the author of a deleted post")
+	}
+	if revisions != 0 {
+		t.Errorf("FindFullPost() revisions = %d, want 0 for a deleted post", revisions)
+	}
+
+	_, err := f.posts.FindPostRevisions(ctx, root)
+	checkErr(t, "FindPostRevisions(deleted)", err, f.posts.deletedErr)
+
+	p = models.Post{ID: root, Message: "again"}
+	checkErr(t, "UpdatePost(deleted)", f.posts.UpdatePost(ctx, &p, &repositories.UpdatePostArgs{}), f.posts.deletedErr)
+}
+
+// Regression: deleting a post that was never edited must keep its original
+// message in the history instead of dropping it with the message.
+func TestDeletePostKeepsRevisions(t *testing.T) {
+	f := newFixture(t)
+	th := f.thread("bob", "", 0)
+	id := f.post(th, 0, "alice", "original")
+
+	p := models.Post{ID: id}
+	if err := f.posts.DeletePost(ctx, &p); err != nil {
+		t.Fatal(err)
+	}
+
+	revisions := f.storage.posts[id].revisions
+	if len(revisions) != 1 || revisions[0].Message != "original" || revisions[0].Editor != "alice" {
+		t.Errorf("stored revisions = %+v, want the original message", revisions)
+	}
+
+	if err := f.posts.DeletePost(ctx, &p); err != nil {
+		t.Fatal(err)
+	}
+	if revisions := f.storage.posts[id].revisions; len(revisions) != 1 || revisions[0].Message != "original" {
+		t.Errorf("stored revisions after a second delete = %+v", revisions)
+	}
+}
+
+func TestPurgePostSubtree(t *testing.T) {
+	f := newFixture(t)
+	th := f.thread("bob", "", 0)
+	root := f.post(th, 0, "alice", "root")
+	child := f.post(th, root, "bob", "child")
+	f.post(th, child, "bob", "grandchild")
+	other := f.post(th, 0, "alice", "other")
+
+	deletion := models.PostDeletion{ID: child}
+	checkErr(t, "PurgePostSubtree(not admin)", f.posts.PurgePostSubtree(ctx, &deletion, "bob"), f.posts.notModeratorErr)
+
+	if err := f.posts.PurgePostSubtree(ctx, &deletion, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if deletion.Deleted != 2 {
+		t.Errorf("deleted = %d, want 2", deletion.Deleted)
+	}
+	if n := f.storage.forums["pirate"].NumPosts; n != 2 {
+		t.Errorf("forum posts = %d, want 2", n)
+	}
+	for _, id := range []int64{root, other} {
+		if _, ok := f.storage.posts[id]; !ok {
+			t.Errorf("post %d was purged", id)
+		}
+	}
+	if _, ok := f.storage.posts[child]; ok {
+		t.Errorf("post %d was kept", child)
+	}
+}
+
+func TestUpdatePostRevisions(t *testing.T) {
+	f := newFixture(t)
+	th := f.thread("bob", "", 0)
+	id := f.post(th, 0, "alice", "first")
+
+	revisions, err := f.posts.FindPostRevisions(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*revisions) != 1 || (*revisions)[0].Message != "first" || (*revisions)[0].Revision != 1 {
+		t.Errorf("revisions of an unedited post = %+v, want the original", *revisions)
+	}
+
+	p := models.Post{ID: id, Message: "first"}
+	if err := f.posts.UpdatePost(ctx, &p, &repositories.UpdatePostArgs{}); err != nil {
+		t.Fatal(err)
+	}
+	if p.IsEdited {
+		t.Error("UpdatePost() with the same message marked the post edited")
+	}
+
+	p = models.Post{ID: id, Message: "second"}
+	checkErr(t, "UpdatePost(unknown editor)",
+		f.posts.UpdatePost(ctx, &p, &repositories.UpdatePostArgs{Editor: "carol"}), f.posts.editorNotFoundErr)
+
+	p = models.Post{ID: id, Message: "second"}
+	if err := f.posts.UpdatePost(ctx, &p, &repositories.UpdatePostArgs{Editor: "BOB"}); err != nil {
+		t.Fatal(err)
+	}
+	p = models.Post{ID: id, Message: "third"}
+	if err := f.posts.UpdatePost(ctx, &p, &repositories.UpdatePostArgs{}); err != nil {
+		t.Fatal(err)
+	}
+	if !p.IsEdited || p.Message != "third" {
+		t.Errorf("UpdatePost() = %+v", p)
+	}
+
+	revisions, err = f.posts.FindPostRevisions(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		message, editor string
+	}{{"first", "alice"}, {"second", "bob"}, {"third", "alice"}}
+	if len(*revisions) != len(want) {
+		t.Fatalf("revisions = %+v, want %d", *revisions, len(want))
+	}
+	for i, rev := range *revisions {
+		if rev.Revision != int32(i+1) || rev.Message != want[i].message || rev.Editor != want[i].editor {
+			t.Errorf("revision %d = %+v, want %+v", i+1, rev, want[i])
+		}
+	}
+}
+
+func TestFindPostsByAuthor(t *testing.T) {
+	f := newFixture(t)
+	f.user("carol")
+	f.forum("navy", "carol")
+	th := f.thread("bob", "", 0)
+	hidden := f.thread("bob", "", 1)
+
+	var ids []int64
+	for i := 0; i < 4; i++ {
+		ids = append(ids, f.post(th, 0, "alice", "hi"))
+	}
+	f.post(th, 0, "bob", "not alice")
+	deleted := f.post(th, 0, "alice", "deleted")
+	f.posts.DeletePost(ctx, &models.Post{ID: deleted})
+	f.post(hidden, 0, "alice", "in a deleted thread")
+	f.threads.DeleteThread(ctx, &models.Thread{ID: hidden}, "alice")
+
+	tests := []struct {
+		name string
+		args repositories.AuthorSearchArgs
+		want []int64
+	}{
+		{"first page", repositories.AuthorSearchArgs{Limit: 3}, ids[:3]},
+		{"next page", repositories.AuthorSearchArgs{Limit: 3, Since: int(ids[2])}, ids[3:]},
+		{"descending", repositories.AuthorSearchArgs{Limit: 2, Desc: true}, []int64{ids[3], ids[2]}},
+		{"descending next page", repositories.AuthorSearchArgs{Limit: 2, Desc: true, Since: int(ids[2])}, []int64{ids[1], ids[0]}},
+		{"other forum", repositories.AuthorSearchArgs{Forum: "navy"}, []int64{}},
+	}
+	for _, tt := range tests {
+		tt.args.Author = "ALICE"
+		posts, err := f.posts.FindPostsByAuthor(ctx, &tt.args)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got := postIDs(posts); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: posts = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	_, err := f.posts.FindPostsByAuthor(ctx, &repositories.AuthorSearchArgs{Author: "dave"})
+	checkErr(t, "FindPostsByAuthor(unknown user)", err, f.posts.userNotFoundErr)
+}
+
+func TestFindPostSubtreeAndAncestors(t *testing.T) {
+	f := newFixture(t)
+	th := f.thread("bob", "", 0)
+	root := f.post(th, 0, "alice", "root")
+	a := f.post(th, root, "bob", "a")
+	other := f.post(th, 0, "bob", "other")
+	a1 := f.post(th, a, "alice", "a1")
+	b := f.post(th, root, "alice", "b")
+	a2 := f.post(th, a1, "bob", "a2")
+
+	tests := []struct {
+		name string
+		args repositories.PostSubtreeArgs
+		want []int64
+	}{
+		{"whole subtree", repositories.PostSubtreeArgs{ID: root}, []int64{root, a, a1, a2, b}},
+		{"inner node", repositories.PostSubtreeArgs{ID: a}, []int64{a, a1, a2}},
+		{"depth 1", repositories.PostSubtreeArgs{ID: root, Depth: sql.NullInt64{Valid: true, Int64: 1}}, []int64{root, a, b}},
+		{"depth 0", repositories.PostSubtreeArgs{ID: root, Depth: sql.NullInt64{Valid: true}}, []int64{root}},
+		{"leaf", repositories.PostSubtreeArgs{ID: other}, []int64{other}},
+	}
+	for _, tt := range tests {
+		posts, err := f.posts.FindPostSubtree(ctx, &tt.args)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got := postIDs(posts); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: posts = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	posts, err := f.posts.FindPostAncestors(ctx, a2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := postIDs(posts); !reflect.DeepEqual(got, []int64{root, a, a1, a2}) {
+		t.Errorf("ancestors = %v, want [%d %d %d %d]", got, root, a, a1, a2)
+	}
+
+	_, err = f.posts.FindPostSubtree(ctx, &repositories.PostSubtreeArgs{ID: 99})
+	checkErr(t, "FindPostSubtree(unknown)", err, f.posts.notFoundErr)
+	_, err = f.posts.FindPostAncestors(ctx, 99)
+	checkErr(t, "FindPostAncestors(unknown)", err, f.posts.notFoundErr)
+}
+
+func TestFindPostsByThreadMaxDepth(t *testing.T) {
+	f := newFixture(t)
+	th := f.thread("bob", "jolly", 0)
+	r1 := f.post(th, 0, "alice", "r1")
+	a := f.post(th, r1, "bob", "a")
+	a1 := f.post(th, a, "alice", "a1")
+	r2 := f.post(th, 0, "bob", "r2")
+	b := f.post(th, r2, "alice", "b")
+
+	tests := []struct {
+		maxDepth sql.NullInt64
+		want     []int64
+	}{
+		{sql.NullInt64{}, []int64{r1, a, a1, r2, b}},
+		{sql.NullInt64{Valid: true, Int64: 1}, []int64{r1, a, r2, b}},
+		{sql.NullInt64{Valid: true}, []int64{r1, r2}},
+	}
+	for _, tt := range tests {
+		posts, err := f.posts.FindPostsByThread(ctx, &repositories.PostsByThreadSearchArgs{
+			ThreadSlug: "jolly", SortType: "parent_tree", MaxDepth: tt.maxDepth,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := postIDs(posts); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("max depth %v: posts = %v, want %v", tt.maxDepth, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package memory
+
+import (
+	"context"
+	"html"
+	"sort"
+	"strings"
+	"time"
+	"tp-project-db/consts"
+	"tp-project-db/errs"
+	"tp-project-db/models"
+	"tp-project-db/repositories"
+	"unicode"
+)
+
+type SearchRepository struct {
+	storage *Storage
+}
+
+func NewSearchRepository(storage *Storage) *SearchRepository {
+	return &SearchRepository{
+		storage: storage,
+	}
+}
+
+func (r *SearchRepository) Search(ctx context.Context, args *repositories.SearchArgs) (*models.SearchResults, *errs.Error) {
+	s := r.storage
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	terms := searchTerms(args.Query)
+	var thread *models.Thread
+	if args.Thread.ID != 0 || args.Thread.Slug.Valid {
+		if thread = s.findThread(args.Thread.ID, args.Thread.Slug.String, args.Thread.ID != 0); thread == nil {
+			return &models.SearchResults{Results: make([]models.SearchResult, 0)}, nil
+		}
+	}
+
+	matches := func(th *models.Thread, author string, created time.Time) bool {
+		if th.IsDeleted {
+			return false
+		}
+		if args.Forum != consts.EmptyString && key(th.Forum) != key(args.Forum) {
+			return false
+		}
+		if thread != nil && th.ID != thread.ID {
+			return false
+		}
+		if args.Author != consts.EmptyString && key(author) != key(args.Author) {
+			return false
+		}
+		if args.Since.Valid && created.Before(time.Time(args.Since.Timestamp)) {
+			return false
+		}
+		return !args.Until.Valid || created.Before(time.Time(args.Until.Timestamp))
+	}
+
+	results := make([]models.SearchResult, 0)
+	if args.Kind != models.SearchKindThread {
+		for _, p := range s.posts {
+			th := s.threads[p.Thread]
+			if p.IsDeleted || !matches(th, p.Author, time.Time(p.CreatedTimestamp)) {
+				continue
+			}
+			rank := searchRank(terms, p.Message)
+			if rank == 0 {
+				continue
+			}
+			results = append(results, models.SearchResult{
+				Kind:             models.SearchKindPost,
+				ID:               p.ID,
+				Thread:           p.Thread,
+				Forum:            p.Forum,
+				Author:           p.Author,
+				Title:            th.Title,
+				Snippet:          searchSnippet(terms, p.Message),
+				CreatedTimestamp: p.CreatedTimestamp,
+				Rank:             rank,
+			})
+		}
+	}
+	if args.Kind != models.SearchKindPost {
+		for _, th := range s.threads {
+			if !matches(th, th.Author, time.Time(th.CreatedTimestamp.Timestamp)) {
+				continue
+			}
+			rank := searchRank(terms, th.Title+" "+th.Message)
+			if rank == 0 {
+				continue
+			}
+			results = append(results, models.SearchResult{
+				Kind:             models.SearchKindThread,
+				ID:               int64(th.ID),
+				Thread:           th.ID,
+				Forum:            th.Forum,
+				Author:           th.Author,
+				Title:            th.Title,
+				Snippet:          searchSnippet(terms, th.Message),
+				CreatedTimestamp: th.CreatedTimestamp.Timestamp,
+				Rank:             rank,
+			})
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return cursorBefore(searchCursor(&results[i]), searchCursor(&results[j]))
+	})
+	if args.After != nil {
+		i := sort.Search(len(results), func(i int) bool {
+			return cursorBefore(*args.After, searchCursor(&results[i]))
+		})
+		results = results[i:]
+	}
+	if args.Limit > 0 && len(results) > args.Limit {
+		results = results[:args.Limit]
+	}
+
+	return &models.SearchResults{Results: results}, nil
+}
+
+func searchCursor(res *models.SearchResult) repositories.SearchCursor {
+	return repositories.SearchCursor{
+		Rank: res.Rank,
+		Kind: res.Kind,
+		ID:   res.ID,
+	}
+}
+
+func cursorBefore(a, b repositories.SearchCursor) bool {
+	if a.Rank != b.Rank {
+		return a.Rank > b.Rank
+	}
+	if a.Kind != b.Kind {
+		return a.Kind > b.Kind
+	}
+	return a.ID > b.ID
+}
+
+func searchTerms(query string) []string {
+	return strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func searchRank(terms []string, text string) float32 {
+	if len(terms) == 0 {
+		return 0
+	}
+	text = strings.ToLower(text)
+
+	var rank float32
+	for _, term := range terms {
+		n := strings.Count(text, term)
+		if n == 0 {
+			return 0
+		}
+		rank += float32(n)
+	}
+	return rank / float32(len(terms))
+}
+
+func searchSnippet(terms []string, text string) string {
+	words := strings.Fields(text)
+	for i, word := range words {
+		words[i] = html.EscapeString(word)
+
+		lower := strings.ToLower(word)
+		for _, term := range terms {
+			if strings.Contains(lower, term) {
+				words[i] = "<b>" + words[i] + "</b>"
+				break
+			}
+		}
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,104 @@
+package memory
+
+import (
+	"reflect"
+	"testing"
+	"tp-project-db/models"
+	"tp-project-db/repositories"
+)
+
+func searchIDs(results *models.SearchResults) []int64 {
+	ids := make([]int64, 0, len(results.Results))
+	for _, res := range results.Results {
+		ids = append(ids, res.ID)
+	}
+	return ids
+}
+
+func TestSearchPosts(t *testing.T) {
+	f := newFixture(t)
+	th := f.thread("bob", "", 0)
+	once := f.post(th, 0, "alice", "The parrot talks")
+	twice := f.post(th, 0, "bob", "Parrot, parrot!")
+	f.post(th, 0, "alice", "No birds here")
+	deleted := f.post(th, 0, "alice", "a deleted parrot")
+	f.posts.DeletePost(ctx, &models.Post{ID: deleted})
+
+	tests := []struct {
+		name string
+		args repositories.SearchArgs
+		want []int64
+	}{
+		{"ranked by matches", repositories.SearchArgs{Query: "PARROT"}, []int64{twice, once}},
+		{"all terms required", repositories.SearchArgs{Query: "parrot talks"}, []int64{once}},
+		{"by author", repositories.SearchArgs{Query: "parrot", Author: "Alice"}, []int64{once}},
+		{"other forum", repositories.SearchArgs{Query: "parrot", Forum: "navy"}, []int64{}},
+		{"unknown thread", repositories.SearchArgs{Query: "parrot", Thread: models.Thread{ID: 99}}, []int64{}},
+		{"no terms", repositories.SearchArgs{Query: "!!"}, []int64{}},
+	}
+	for _, tt := range tests {
+		tt.args.Kind = models.SearchKindPost
+		results, err := f.search(&tt.args)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got := searchIDs(results); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: results = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	results, _ := f.search(&repositories.SearchArgs{Query: "talks", Kind: models.SearchKindPost})
+	if snippet := results.Results[0].Snippet; snippet != "The parrot <b>talks</b>" {
+		t.Errorf("snippet = %q", snippet)
+	}
+
+	// Regression: stored text is escaped, only the highlighting is markup.
+	f.post(th, 0, "bob", `<script>alert("parrot")</script>`)
+	results, _ = f.search(&repositories.SearchArgs{Query: "alert", Kind: models.SearchKindPost})
+	if snippet := results.Results[0].Snippet; snippet != "<b>&lt;script&gt;alert(&#34;parrot&#34;)&lt;/script&gt;</b>" {
+		t.Errorf("snippet = %q, want the stored markup escaped", snippet)
+	}
+}
+
+func TestSearchKindsAndPages(t *testing.T) {
+	f := newFixture(t)
+	th := f.thread("bob", "", 0)
+	for i := 0; i < 4; i++ {
+		f.post(th, 0, "alice", "message")
+	}
+
+	all, err := f.search(&repositories.SearchArgs{Query: "message"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all.Results) != 5 {
+		t.Fatalf("results = %d, want 4 posts and the thread", len(all.Results))
+	}
+
+	var paged []models.SearchResult
+	args := repositories.SearchArgs{Query: "message", Limit: 2}
+	for page := 0; page < 4; page++ {
+		results, err := f.search(&args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results.Results) == 0 {
+			break
+		}
+		paged = append(paged, results.Results...)
+		cursor := searchCursor(&results.Results[len(results.Results)-1])
+		args.After = &cursor
+	}
+	if !reflect.DeepEqual(paged, all.Results) {
+		t.Errorf("pages = %+v, want %+v", paged, all.Results)
+	}
+}
+
+func (f *fixture) search(args *repositories.SearchArgs) (*models.SearchResults, error) {
+	results, err := NewSearchRepository(f.storage).Search(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
@@ -0,0 +1,38 @@
+package memory
+
+import (
+	"context"
+	"tp-project-db/errs"
+	"tp-project-db/models"
+)
+
+type StatusRepository struct {
+	storage *Storage
+}
+
+func NewStatusRepository(storage *Storage) *StatusRepository {
+	return &StatusRepository{
+		storage: storage,
+	}
+}
+
+func (r *StatusRepository) GetStatus(ctx context.Context, status *models.Status) *errs.Error {
+	s := r.storage
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	status.NumUsers = int32(len(s.users))
+	status.NumForums = int32(len(s.forums))
+	status.NumThreads = int32(len(s.threads))
+	status.NumPosts = int64(len(s.posts))
+	return nil
+}
+
+func (r *StatusRepository) ClearDatabase(ctx context.Context) *errs.Error {
+	s := r.storage
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.clear()
+	return nil
+}
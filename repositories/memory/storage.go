@@ -0,0 +1,160 @@
+package memory
+
+import (
+	"strings"
+	"sync"
+	"time"
+	"tp-project-db/models"
+)
+
+type post struct {
+	models.Post
+	path      []int64
+	pathRoot  int64
+	revisions []models.PostRevision
+}
+
+func (p *post) view() models.Post {
+	v := p.Post
+	if v.IsDeleted {
+		v.Author = ""
+	}
+	return v
+}
+
+func (p *post) revisionList() []models.PostRevision {
+	if len(p.revisions) > 0 || p.IsDeleted {
+		return p.revisions
+	}
+	return []models.PostRevision{{
+		Revision:         1,
+		Message:          p.Message,
+		Editor:           p.Author,
+		CreatedTimestamp: p.CreatedTimestamp,
+	}}
+}
+
+func (p *post) inSubtree(root *post) bool {
+	return p.pathRoot == root.pathRoot && len(p.path) >= len(root.path) &&
+		p.path[len(root.path)-1] == root.ID
+}
+
+func threadView(th *models.Thread) models.Thread {
+	v := *th
+	if v.IsPinned && v.PinnedUntil.Valid && !time.Time(v.PinnedUntil.Timestamp).After(time.Now()) {
+		v.IsPinned = false
+	}
+	return v
+}
+
+type Storage struct {
+	mtx *sync.RWMutex
+
+	users       map[string]*models.User
+	emails      map[string]string
+	forums      map[string]*models.Forum
+	forumUsers  map[string]map[string]string
+	searchConfs map[string]string
+	threads     map[int32]*models.Thread
+	threadSlugs map[string]int32
+	redirects   map[string]int32
+	posts       map[int64]*post
+	threadPosts map[int32][]*post
+	votes       map[int32]map[string]int32
+
+	lastThreadID int32
+	lastPostID   int64
+}
+
+func NewStorage() *Storage {
+	s := &Storage{
+		mtx: &sync.RWMutex{},
+	}
+	s.clear()
+	return s
+}
+
+func (s *Storage) clear() {
+	s.users = make(map[string]*models.User)
+	s.emails = make(map[string]string)
+	s.forums = make(map[string]*models.Forum)
+	s.forumUsers = make(map[string]map[string]string)
+	s.searchConfs = make(map[string]string)
+	s.threads = make(map[int32]*models.Thread)
+	s.threadSlugs = make(map[string]int32)
+	s.redirects = make(map[string]int32)
+	s.posts = make(map[int64]*post)
+	s.threadPosts = make(map[int32][]*post)
+	s.votes = make(map[int32]map[string]int32)
+}
+
+func (s *Storage) addForumUser(forum, nickname string) {
+	fu, ok := s.forumUsers[key(forum)]
+	if !ok {
+		fu = make(map[string]string)
+		s.forumUsers[key(forum)] = fu
+	}
+	if _, ok := fu[key(nickname)]; !ok {
+		fu[key(nickname)] = nickname
+	}
+}
+
+func (s *Storage) removeStaleForumUser(forum, nickname string) {
+	for _, p := range s.posts {
+		if key(p.Forum) == key(forum) && key(p.Author) == key(nickname) {
+			return
+		}
+	}
+	for _, th := range s.threads {
+		if key(th.Forum) == key(forum) && key(th.Author) == key(nickname) {
+			return
+		}
+	}
+	delete(s.forumUsers[key(forum)], key(nickname))
+}
+
+func (s *Storage) findThread(id int32, slug string, byID bool) *models.Thread {
+	if !byID {
+		var ok bool
+		if id, ok = s.threadSlugs[key(slug)]; !ok {
+			if id, ok = s.redirects[key(slug)]; !ok {
+				return nil
+			}
+		}
+	}
+	return s.threads[id]
+}
+
+func (s *Storage) retargetThread(from, to int32) {
+	for slug, id := range s.redirects {
+		if id != from {
+			continue
+		}
+		if to == 0 {
+			delete(s.redirects, slug)
+		} else {
+			s.redirects[slug] = to
+		}
+	}
+	for _, p := range s.posts {
+		if p.SplitTo == from {
+			p.SplitTo = to
+		}
+	}
+}
+
+func key(s string) string {
+	return strings.ToLower(s)
+}
+
+func comparePaths(a, b []int64) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] < b[i] {
+			return -1
+		}
+		if a[i] > b[i] {
+			return 1
+		}
+	}
+	return len(a) - len(b)
+}
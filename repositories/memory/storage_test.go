@@ -0,0 +1,287 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"github.com/go-openapi/strfmt"
+	"net/http"
+	"testing"
+	"time"
+	"tp-project-db/errs"
+	"tp-project-db/models"
+	"tp-project-db/repositories"
+)
+
+var (
+	ctx   = context.Background()
+	epoch = time.Date(2018, 11, 1, 12, 0, 0, 0, time.UTC)
+)
+
+// fixture is a storage with users alice and bob and the forum "pirate"
+// administered by alice.
+type fixture struct {
+	t       *testing.T
+	storage *Storage
+	users   *UserRepository
+	forums  *ForumRepository
+	threads *ThreadRepository
+	posts   *PostRepository
+	votes   *VoteRepository
+	status  *StatusRepository
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+
+	s := NewStorage()
+	f := &fixture{
+		t:       t,
+		storage: s,
+		users:   NewUserRepository(s),
+		forums:  NewForumRepository(s),
+		threads: NewThreadRepository(s),
+		posts:   NewPostRepository(s),
+		votes:   NewVoteRepository(s),
+		status:  NewStatusRepository(s),
+	}
+
+	f.user("alice")
+	f.user("bob")
+	f.forum("pirate", "alice")
+	return f
+}
+
+func (f *fixture) user(nickname string) {
+	f.t.Helper()
+
+	var existing string
+	u := models.User{Nickname: nickname, FullName: nickname, Email: nickname + "@example.com"}
+	if status, err := f.users.CreateUser(ctx, &u, &existing); err != nil || status != http.StatusCreated {
+		f.t.Fatalf("CreateUser(%s) = %d, %v", nickname, status, err)
+	}
+}
+
+func (f *fixture) forum(slug, admin string) {
+	f.t.Helper()
+
+	var existing sql.NullString
+	forum := models.Forum{Slug: slug, Title: slug, Admin: admin}
+	if status, err := f.forums.CreateForum(ctx, &forum, &existing); err != nil || status != http.StatusCreated {
+		f.t.Fatalf("CreateForum(%s) = %d, %v", slug, status, err)
+	}
+}
+
+// thread creates a thread in forum "pirate" created minutes after epoch and
+// returns its id. An empty slug leaves the thread without one.
+func (f *fixture) thread(author, slug string, minutes int) int32 {
+	f.t.Helper()
+
+	var existing sql.NullString
+	th := models.Thread{
+		Forum:            "pirate",
+		Author:           author,
+		Title:            "title",
+		Message:          "message",
+		CreatedTimestamp: timestamp(minutes),
+	}
+	if slug != "" {
+		th.Slug = models.NullString{Valid: true, String: slug}
+	}
+	if status, err := f.threads.CreateThread(ctx, &th, &existing); err != nil || status != http.StatusCreated {
+		f.t.Fatalf("CreateThread(%s) = %d, %v", slug, status, err)
+	}
+	return f.storage.lastThreadID
+}
+
+// post adds a post by author under parent (0 for a root post) and returns its
+// id.
+func (f *fixture) post(thread int32, parent int64, author, message string) int64 {
+	f.t.Helper()
+
+	posts := models.Posts{{ParentID: parent, Author: author, Message: message}}
+	args := repositories.CreatePostArgs{ThreadID: thread, Timestamp: strfmt.DateTime(epoch)}
+	if err := f.posts.CreatePosts(ctx, &posts, &args); err != nil {
+		f.t.Fatalf("CreatePosts(thread %d, parent %d) = %v", thread, parent, err)
+	}
+	return posts[0].ID
+}
+
+func (f *fixture) getThread(id int32) *models.Thread {
+	return f.storage.threads[id]
+}
+
+func timestamp(minutes int) models.NullTimestamp {
+	return models.NullTimestamp{Valid: true, Timestamp: strfmt.DateTime(epoch.Add(time.Duration(minutes) * time.Minute))}
+}
+
+func postIDs(posts *models.Posts) []int64 {
+	ids := make([]int64, 0, len(*posts))
+	for _, p := range *posts {
+		ids = append(ids, p.ID)
+	}
+	return ids
+}
+
+func threadIDs(threads *models.Threads) []int32 {
+	ids := make([]int32, 0, len(*threads))
+	for _, th := range *threads {
+		ids = append(ids, th.ID)
+	}
+	return ids
+}
+
+func checkErr(t *testing.T, name string, err, want *errs.Error) {
+	t.Helper()
+
+	if want == nil {
+		if err != nil {
+			t.Errorf("%s = %v, want success", name, err)
+		}
+		return
+	}
+	if err == nil || err.Code != want.Code {
+		t.Errorf("%s = %v, want %s", name, err, want.Code)
+	}
+}
+
+func TestCreateUserConflicts(t *testing.T) {
+	f := newFixture(t)
+
+	var existing string
+	u := models.User{Nickname: "ALICE", Email: "bob@EXAMPLE.com"}
+	status, err := f.users.CreateUser(ctx, &u, &existing)
+	if err != nil || status != http.StatusConflict {
+		t.Fatalf("CreateUser() = %d, %v, want 409", status, err)
+	}
+
+	var conflicts models.Users
+	if err := conflicts.UnmarshalJSON([]byte(existing)); err != nil {
+		t.Fatal(err)
+	}
+	if len(conflicts) != 2 || conflicts[0].Nickname != "alice" || conflicts[1].Nickname != "bob" {
+		t.Errorf("conflicts = %+v, want alice and bob", conflicts)
+	}
+}
+
+func TestCreateThreadSlugConflict(t *testing.T) {
+	f := newFixture(t)
+	id := f.thread("bob", "jolly", 0)
+
+	var existing sql.NullString
+	th := models.Thread{Forum: "PIRATE", Author: "alice", Slug: models.NullString{Valid: true, String: "JOLLY"}}
+	status, err := f.threads.CreateThread(ctx, &th, &existing)
+	if err != nil || status != http.StatusConflict {
+		t.Fatalf("CreateThread() = %d, %v, want 409", status, err)
+	}
+
+	var got models.Thread
+	if err := got.UnmarshalJSON([]byte(existing.String)); err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != id {
+		t.Errorf("conflicting thread id = %d, want %d", got.ID, id)
+	}
+}
+
+func TestCreatePosts(t *testing.T) {
+	f := newFixture(t)
+	th := f.thread("bob", "jolly", 0)
+	other := f.thread("bob", "", 1)
+
+	root := f.post(th, 0, "alice", "root")
+	child := f.post(th, root, "BOB", "child")
+
+	p := models.Post{ID: child}
+	if err := f.posts.FindPost(ctx, &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Author != "bob" || p.Forum != "pirate" || p.Thread != th || p.ParentID != root {
+		t.Errorf("FindPost() = %+v", p)
+	}
+	if path := f.storage.posts[child].path; len(path) != 2 || path[0] != root || path[1] != child {
+		t.Errorf("child path = %v, want [%d %d]", path, root, child)
+	}
+
+	tests := []struct {
+		name   string
+		thread int32
+		post   models.Post
+		want   *errs.Error
+	}{
+		{"unknown thread", 99, models.Post{Author: "alice"}, f.posts.threadNotFoundErr},
+		{"unknown author", th, models.Post{Author: "carol"}, f.posts.authorNotFoundErr},
+		{"unknown parent", th, models.Post{Author: "alice", ParentID: 99}, f.posts.conflictErr},
+		{"parent in another thread", other, models.Post{Author: "alice", ParentID: root}, f.posts.conflictErr},
+	}
+	for _, tt := range tests {
+		posts := models.Posts{tt.post}
+		err := f.posts.CreatePosts(ctx, &posts, &repositories.CreatePostArgs{ThreadID: tt.thread})
+		checkErr(t, tt.name, err, tt.want)
+	}
+
+	if n := f.storage.forums["pirate"].NumPosts; n != 2 {
+		t.Errorf("forum posts = %d, want 2", n)
+	}
+}
+
+func TestAddVote(t *testing.T) {
+	f := newFixture(t)
+	th := f.thread("bob", "jolly", 0)
+
+	vote := func(user string, voice int32) int32 {
+		t.Helper()
+
+		var thread sql.NullString
+		v := models.Vote{User: user, ThreadSlug: "JOLLY", Voice: voice}
+		if status, err := f.votes.AddVote(ctx, &v, &thread); err != nil || status != http.StatusOK {
+			t.Fatalf("AddVote(%s, %d) = %d, %v", user, voice, status, err)
+		}
+		return f.getThread(th).NumVotes
+	}
+
+	if n := vote("alice", 1); n != 1 {
+		t.Errorf("votes = %d, want 1", n)
+	}
+	if n := vote("alice", 1); n != 1 {
+		t.Errorf("votes after a repeated vote = %d, want 1", n)
+	}
+	if n := vote("bob", 1); n != 2 {
+		t.Errorf("votes = %d, want 2", n)
+	}
+	if n := vote("alice", -1); n != 0 {
+		t.Errorf("votes after a changed vote = %d, want 0", n)
+	}
+}
+
+func TestFindUsersByForum(t *testing.T) {
+	f := newFixture(t)
+	f.user("carol")
+	th := f.thread("bob", "", 0)
+	f.post(th, 0, "carol", "hi")
+
+	users, err := f.users.FindUsersByForum(ctx, &repositories.UsersByForumSearchArgs{Forum: "pirate", Since: "bob", Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*users) != 1 || (*users)[0].Nickname != "carol" {
+		t.Errorf("FindUsersByForum(since bob) = %+v, want carol", *users)
+	}
+}
+
+func TestStatusAndClear(t *testing.T) {
+	f := newFixture(t)
+	th := f.thread("bob", "", 0)
+	f.post(th, 0, "alice", "hi")
+
+	var status models.Status
+	f.status.GetStatus(ctx, &status)
+	if status != (models.Status{NumUsers: 2, NumForums: 1, NumThreads: 1, NumPosts: 1}) {
+		t.Errorf("GetStatus() = %+v", status)
+	}
+
+	f.status.ClearDatabase(ctx)
+	f.status.GetStatus(ctx, &status)
+	if status != (models.Status{}) {
+		t.Errorf("GetStatus() after ClearDatabase() = %+v, want zeros", status)
+	}
+}
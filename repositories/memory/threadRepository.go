@@ -0,0 +1,650 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"github.com/mailru/easyjson"
+	"net/http"
+	"sort"
+	"time"
+	"tp-project-db/consts"
+	"tp-project-db/errs"
+	"tp-project-db/models"
+	"tp-project-db/repositories"
+)
+
+type ThreadRepository struct {
+	storage           *Storage
+	notFoundErr       *errs.Error
+	authorNotFoundErr *errs.Error
+	forumNotFoundErr  *errs.Error
+	deletedErr        *errs.Error
+	archivedErr       *errs.Error
+	notModeratorErr   *errs.Error
+	targetNotFoundErr *errs.Error
+	conflictErr       *errs.Error
+	postNotFoundErr   *errs.Error
+	postDeletedErr    *errs.Error
+	parentNotFoundErr *errs.Error
+	mergeTargetErr    *errs.Error
+	mergeSelfErr      *errs.Error
+	forumMismatchErr  *errs.Error
+	userNotFoundErr   *errs.Error
+}
+
+func NewThreadRepository(storage *Storage) *ThreadRepository {
+	return &ThreadRepository{
+		storage: storage,
+		notFoundErr: errs.NewNotFoundError(repositories.ThreadNotFoundErrMessage).
+			WithCode(repositories.ThreadNotFoundErrCode).WithEntity("thread", "slug_or_id"),
+		authorNotFoundErr: errs.NewNotFoundError(repositories.ThreadAuthorNotFoundErrMessage).
+			WithCode(repositories.ThreadAuthorNotFoundErrCode).WithEntity("user", "author"),
+		forumNotFoundErr: errs.NewNotFoundError(repositories.ThreadForumNotFoundErrMessage).
+			WithCode(repositories.ThreadForumNotFoundErrCode).WithEntity("forum", "forum"),
+		deletedErr: errs.NewConflictError(repositories.ThreadDeletedErrMessage).
+			WithCode(repositories.ThreadDeletedErrCode).WithEntity("thread", "slug_or_id"),
+		archivedErr: errs.NewConflictError(repositories.ThreadArchivedErrMessage).
+			WithCode(repositories.ThreadArchivedErrCode).WithEntity("thread", "slug_or_id"),
+		notModeratorErr: errs.NewForbiddenError(repositories.ThreadNotModeratorErrMessage).
+			WithCode(repositories.ThreadNotModeratorErrCode).WithEntity("user", "moderator"),
+		targetNotFoundErr: errs.NewNotFoundError(repositories.ThreadTargetForumNotFoundErrMessage).
+			WithCode(repositories.ThreadTargetForumNotFoundErrCode).WithEntity("forum", "forum"),
+		conflictErr: errs.NewConflictError(repositories.ThreadAttributeDuplicateErrMessage).
+			WithCode(repositories.ThreadAttributeDuplicateErrCode).WithEntity("thread", "slug"),
+		postNotFoundErr: errs.NewNotFoundError(repositories.PostNotFoundErrMessage).
+			WithCode(repositories.PostNotFoundErrCode).WithEntity("post", "id"),
+		postDeletedErr: errs.NewConflictError(repositories.PostDeletedErrMessage).
+			WithCode(repositories.PostDeletedErrCode).WithEntity("post", "id"),
+		parentNotFoundErr: errs.NewConflictError(repositories.PostParentNotFoundErrMessage).
+			WithCode(repositories.PostParentNotFoundErrCode).WithEntity("post", "parent"),
+		mergeTargetErr: errs.NewNotFoundError(repositories.ThreadTargetNotFoundErrMessage).
+			WithCode(repositories.ThreadTargetNotFoundErrCode).WithEntity("thread", "target"),
+		mergeSelfErr: errs.NewBadRequestError(repositories.ThreadMergeSelfErrMessage).
+			WithCode(repositories.ThreadMergeSelfErrCode).WithEntity("thread", "target"),
+		forumMismatchErr: errs.NewConflictError(repositories.ThreadForumMismatchErrMessage).
+			WithCode(repositories.ThreadForumMismatchErrCode).WithEntity("thread", "target"),
+		userNotFoundErr: errs.NewNotFoundError(repositories.UserNotFoundErrMessage).
+			WithCode(repositories.UserNotFoundErrCode).WithEntity("user", "nickname"),
+	}
+}
+
+func (r *ThreadRepository) CreateThread(ctx context.Context, thread *models.Thread, existing *sql.NullString) (int, *errs.Error) {
+	s := r.storage
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	author, ok := s.users[key(thread.Author)]
+	if !ok {
+		return http.StatusNotFound, r.authorNotFoundErr
+	}
+
+	forum, ok := s.forums[key(thread.Forum)]
+	if !ok {
+		return http.StatusNotFound, r.forumNotFoundErr
+	}
+
+	if thread.Slug.Valid {
+		if th := s.findThread(0, thread.Slug.String, false); th != nil {
+			v := threadView(th)
+			b, _ := easyjson.Marshal(&v)
+			*existing = sql.NullString{Valid: true, String: string(b)}
+			return http.StatusConflict, nil
+		}
+	}
+
+	s.lastThreadID++
+	th := models.Thread{
+		ID:               s.lastThreadID,
+		Slug:             thread.Slug,
+		Forum:            forum.Slug,
+		Author:           author.Nickname,
+		Title:            thread.Title,
+		Message:          thread.Message,
+		CreatedTimestamp: thread.CreatedTimestamp,
+	}
+	s.threads[th.ID] = &th
+	if th.Slug.Valid {
+		s.threadSlugs[key(th.Slug.String)] = th.ID
+	}
+
+	forum.NumThreads++
+	s.addForumUser(forum.Slug, author.Nickname)
+
+	b, _ := easyjson.Marshal(&th)
+	*existing = sql.NullString{Valid: true, String: string(b)}
+	return http.StatusCreated, nil
+}
+
+func (r *ThreadRepository) FindThreadByID(ctx context.Context, id int32, existing *string) *errs.Error {
+	return r.findThread(id, consts.EmptyString, true, existing)
+}
+
+func (r *ThreadRepository) FindThreadBySlug(ctx context.Context, slug *string, existing *string) *errs.Error {
+	return r.findThread(0, *slug, false, existing)
+}
+
+func (r *ThreadRepository) findThread(id int32, slug string, byID bool, existing *string) *errs.Error {
+	s := r.storage
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	th := s.findThread(id, slug, byID)
+	if th == nil {
+		return r.notFoundErr
+	}
+
+	v := threadView(th)
+	b, _ := easyjson.Marshal(&v)
+	*existing = string(b)
+	return nil
+}
+
+func (r *ThreadRepository) FindThreadForumByID(ctx context.Context, args *repositories.CreatePostArgs) *errs.Error {
+	s := r.storage
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	th, ok := s.threads[args.ThreadID]
+	if !ok {
+		return r.notFoundErr
+	}
+
+	args.ThreadForum = th.Forum
+	return r.checkWritable(th)
+}
+
+func (r *ThreadRepository) FindThreadIDAndForumBySlug(ctx context.Context, args *repositories.CreatePostArgs) *errs.Error {
+	s := r.storage
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	th := s.findThread(0, args.ThreadSlug, false)
+	if th == nil {
+		return r.notFoundErr
+	}
+
+	args.ThreadID, args.ThreadForum = th.ID, th.Forum
+	return r.checkWritable(th)
+}
+
+func (r *ThreadRepository) checkWritable(th *models.Thread) *errs.Error {
+	if th.IsDeleted {
+		return r.deletedErr
+	}
+	if th.IsArchived {
+		return r.archivedErr
+	}
+	if th.IsClosed {
+		return repositories.NewThreadClosedError(th.CloseReason)
+	}
+	return nil
+}
+
+func (r *ThreadRepository) FindThreadsByForum(ctx context.Context, args *repositories.ForumThreadsSearchArgs) (*models.Threads, *errs.Error) {
+	s := r.storage
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	if _, ok := s.forums[key(args.Forum)]; !ok {
+		return nil, r.forumNotFoundErr
+	}
+
+	since := time.Time(args.Since.Timestamp)
+	pinned := make([]models.Thread, 0)
+	threads := make([]models.Thread, 0)
+	for _, th := range s.threads {
+		if key(th.Forum) != key(args.Forum) || th.IsDeleted {
+			continue
+		}
+		v := threadView(th)
+		if v.IsPinned {
+			if !args.ExcludePinned {
+				pinned = append(pinned, v)
+			}
+			continue
+		}
+		if args.Since.Valid {
+			if !th.CreatedTimestamp.Valid {
+				continue
+			}
+			created := time.Time(th.CreatedTimestamp.Timestamp)
+			if args.Desc && created.After(since) || !args.Desc && created.Before(since) {
+				continue
+			}
+		}
+		threads = append(threads, v)
+	}
+
+	sortThreads(pinned, args.Desc)
+	sortThreads(threads, args.Desc)
+
+	threads = append(pinned, threads...)
+	if args.Limit > 0 && len(threads) > args.Limit {
+		threads = threads[:args.Limit]
+	}
+	return (*models.Threads)(&threads), nil
+}
+
+func (r *ThreadRepository) FindThreadsByAuthor(ctx context.Context, args *repositories.AuthorSearchArgs) (*models.Threads, *errs.Error) {
+	s := r.storage
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	if _, ok := s.users[key(args.Author)]; !ok {
+		return nil, r.userNotFoundErr
+	}
+
+	threads := make([]models.Thread, 0)
+	for _, th := range s.threads {
+		if key(th.Author) != key(args.Author) || th.IsDeleted {
+			continue
+		}
+		if !inAuthorPage(args, th.Forum, int64(th.ID)) {
+			continue
+		}
+		threads = append(threads, threadView(th))
+	}
+	sort.Slice(threads, func(i, j int) bool {
+		return (threads[i].ID < threads[j].ID) != args.Desc
+	})
+	if args.Limit > 0 && len(threads) > args.Limit {
+		threads = threads[:args.Limit]
+	}
+
+	return (*models.Threads)(&threads), nil
+}
+
+func sortThreads(threads []models.Thread, desc bool) {
+	sort.Slice(threads, func(i, j int) bool {
+		if desc {
+			return createdBefore(&threads[j], &threads[i])
+		}
+		return createdBefore(&threads[i], &threads[j])
+	})
+}
+
+func (r *ThreadRepository) UpdateThreadByID(ctx context.Context, thread *models.Thread) *errs.Error {
+	return r.updateThread(thread, true)
+}
+
+func (r *ThreadRepository) UpdateThreadBySlug(ctx context.Context, thread *models.Thread) *errs.Error {
+	return r.updateThread(thread, false)
+}
+
+func (r *ThreadRepository) updateThread(thread *models.Thread, byID bool) *errs.Error {
+	s := r.storage
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	th := s.findThread(thread.ID, thread.Slug.String, byID)
+	if th == nil {
+		return r.notFoundErr
+	}
+
+	if thread.Title != consts.EmptyString {
+		th.Title = thread.Title
+	}
+	if thread.Message != consts.EmptyString {
+		th.Message = thread.Message
+	}
+
+	*thread = threadView(th)
+	return nil
+}
+
+func (r *ThreadRepository) DeleteThread(ctx context.Context, thread *models.Thread, moderator string) *errs.Error {
+	return r.setThreadDeleted(thread, moderator, true)
+}
+
+func (r *ThreadRepository) RestoreThread(ctx context.Context, thread *models.Thread, moderator string) *errs.Error {
+	return r.setThreadDeleted(thread, moderator, false)
+}
+
+func (r *ThreadRepository) setThreadDeleted(thread *models.Thread, moderator string, deleted bool) *errs.Error {
+	s := r.storage
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	th := s.findThread(thread.ID, thread.Slug.String, thread.ID != 0)
+	if th == nil {
+		return r.notFoundErr
+	}
+	if key(s.forums[key(th.Forum)].Admin) != key(moderator) {
+		return r.notModeratorErr
+	}
+
+	if th.IsDeleted != deleted {
+		numThreads, numPosts := int32(1), int64(len(s.threadPosts[th.ID]))
+		if deleted {
+			numThreads, numPosts = -numThreads, -numPosts
+		}
+
+		forum := s.forums[key(th.Forum)]
+		forum.NumThreads += numThreads
+		forum.NumPosts += numPosts
+		th.IsDeleted = deleted
+	}
+
+	*thread = threadView(th)
+	return nil
+}
+
+func (r *ThreadRepository) ArchiveThread(ctx context.Context, thread *models.Thread, archived bool, moderator string) *errs.Error {
+	s := r.storage
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	th := s.findThread(thread.ID, thread.Slug.String, thread.ID != 0)
+	if th == nil {
+		return r.notFoundErr
+	}
+	if key(s.forums[key(th.Forum)].Admin) != key(moderator) {
+		return r.notModeratorErr
+	}
+	th.IsArchived = archived
+
+	*thread = threadView(th)
+	return nil
+}
+
+func (r *ThreadRepository) CloseThread(ctx context.Context, thread *models.Thread, args *repositories.CloseThreadArgs) *errs.Error {
+	s := r.storage
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	th := s.findThread(thread.ID, thread.Slug.String, thread.ID != 0)
+	if th == nil {
+		return r.notFoundErr
+	}
+	forum := s.forums[key(th.Forum)]
+	if key(forum.Admin) != key(args.Moderator) {
+		return r.notModeratorErr
+	}
+
+	if th.IsClosed != args.Closed {
+		th.IsClosed = args.Closed
+		if args.Closed {
+			th.ClosedBy = models.NullString{Valid: true, String: s.users[key(args.Moderator)].Nickname}
+			th.ClosedAt = models.NullTimestamp{Valid: true, Timestamp: args.Timestamp}
+			th.CloseReason = args.Reason
+		} else {
+			th.ClosedBy = models.NullString{}
+			th.ClosedAt = models.NullTimestamp{}
+			th.CloseReason = consts.EmptyString
+		}
+	}
+
+	*thread = threadView(th)
+	return nil
+}
+
+func (r *ThreadRepository) PinThread(ctx context.Context, thread *models.Thread, args *repositories.PinThreadArgs) *errs.Error {
+	s := r.storage
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	th := s.findThread(thread.ID, thread.Slug.String, thread.ID != 0)
+	if th == nil {
+		return r.notFoundErr
+	}
+	if key(s.forums[key(th.Forum)].Admin) != key(args.Moderator) {
+		return r.notModeratorErr
+	}
+
+	th.IsPinned = args.Pinned
+	if args.Pinned {
+		th.PinnedUntil = args.Until
+	} else {
+		th.PinnedUntil = models.NullTimestamp{}
+	}
+
+	*thread = threadView(th)
+	return nil
+}
+
+func (r *ThreadRepository) MoveThread(ctx context.Context, thread *models.Thread, args *repositories.MoveThreadArgs) *errs.Error {
+	s := r.storage
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	th := s.findThread(thread.ID, thread.Slug.String, thread.ID != 0)
+	if th == nil {
+		return r.notFoundErr
+	}
+	source := s.forums[key(th.Forum)]
+	if key(source.Admin) != key(args.Moderator) {
+		return r.notModeratorErr
+	}
+
+	target, ok := s.forums[key(args.Forum)]
+	if !ok {
+		return r.targetNotFoundErr
+	}
+
+	if target != source {
+		authors := map[string]string{key(th.Author): th.Author}
+		for _, p := range s.threadPosts[th.ID] {
+			p.Forum = target.Slug
+			authors[key(p.Author)] = p.Author
+		}
+		th.Forum = target.Slug
+
+		if !th.IsDeleted {
+			numPosts := int64(len(s.threadPosts[th.ID]))
+			source.NumThreads--
+			source.NumPosts -= numPosts
+			target.NumThreads++
+			target.NumPosts += numPosts
+		}
+
+		for _, author := range authors {
+			s.addForumUser(target.Slug, author)
+			s.removeStaleForumUser(source.Slug, author)
+		}
+	}
+
+	*thread = threadView(th)
+	return nil
+}
+
+func (r *ThreadRepository) SplitThread(ctx context.Context, thread *models.Thread, args *repositories.SplitThreadArgs) *errs.Error {
+	s := r.storage
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	target, ok := s.posts[args.Post]
+	if !ok {
+		return r.postNotFoundErr
+	}
+	source := s.threads[target.Thread]
+	forum := s.forums[key(source.Forum)]
+	if key(forum.Admin) != key(args.Moderator) {
+		return r.notModeratorErr
+	}
+	if target.IsDeleted {
+		return r.postDeletedErr
+	}
+	if source.IsDeleted {
+		return r.deletedErr
+	}
+	if thread.Slug.Valid {
+		if s.findThread(0, thread.Slug.String, false) != nil {
+			return r.conflictErr
+		}
+	}
+
+	s.lastThreadID++
+	th := models.Thread{
+		ID:               s.lastThreadID,
+		Slug:             thread.Slug,
+		Forum:            forum.Slug,
+		Author:           target.Author,
+		Title:            thread.Title,
+		Message:          target.Message,
+		CreatedTimestamp: models.NullTimestamp{Valid: true, Timestamp: target.CreatedTimestamp},
+	}
+	s.threads[th.ID] = &th
+	if th.Slug.Valid {
+		s.threadSlugs[key(th.Slug.String)] = th.ID
+	}
+
+	depth := len(target.path)
+	kept := make([]*post, 0, len(s.threadPosts[source.ID]))
+	for _, p := range s.threadPosts[source.ID] {
+		if p == target || !p.inSubtree(target) {
+			kept = append(kept, p)
+			continue
+		}
+		p.Thread = th.ID
+		if p.ParentID == target.ID {
+			p.ParentID = 0
+		}
+		p.path = append([]int64(nil), p.path[depth:]...)
+		p.pathRoot = p.path[0]
+		s.threadPosts[th.ID] = append(s.threadPosts[th.ID], p)
+	}
+	s.threadPosts[source.ID] = kept
+
+	target.revisions = target.revisionList()
+	target.Message = ""
+	target.IsDeleted = true
+	target.SplitTo = th.ID
+
+	forum.NumThreads++
+
+	*thread = threadView(&th)
+	return nil
+}
+
+func (r *ThreadRepository) MergeThread(ctx context.Context, thread *models.Thread, args *repositories.MergeThreadArgs) *errs.Error {
+	s := r.storage
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	source := s.findThread(thread.ID, thread.Slug.String, thread.ID != 0)
+	if source == nil {
+		return r.notFoundErr
+	}
+	target := s.findThread(args.Target.ID, args.Target.Slug.String, args.Target.ID != 0)
+	if target == nil {
+		return r.mergeTargetErr
+	}
+	if source == target {
+		return r.mergeSelfErr
+	}
+
+	forum := s.forums[key(source.Forum)]
+	if key(forum.Admin) != key(args.Moderator) {
+		return r.notModeratorErr
+	}
+	if key(source.Forum) != key(target.Forum) {
+		return r.forumMismatchErr
+	}
+	if source.IsDeleted || target.IsDeleted {
+		return r.deletedErr
+	}
+
+	var parent *post
+	if args.Parent != 0 {
+		p, ok := s.posts[args.Parent]
+		if !ok || p.Thread != target.ID {
+			return r.parentNotFoundErr
+		}
+		parent = p
+	}
+
+	for _, p := range s.threadPosts[source.ID] {
+		p.Thread = target.ID
+		if parent != nil {
+			if p.ParentID == 0 {
+				p.ParentID = parent.ID
+			}
+			p.path = append(append(make([]int64, 0, len(parent.path)+len(p.path)), parent.path...), p.path...)
+			p.pathRoot = parent.pathRoot
+		}
+		s.threadPosts[target.ID] = append(s.threadPosts[target.ID], p)
+	}
+	delete(s.threadPosts, source.ID)
+
+	votes, ok := s.votes[target.ID]
+	if !ok {
+		votes = make(map[string]int32)
+		s.votes[target.ID] = votes
+	}
+	for user, voice := range s.votes[source.ID] {
+		if _, ok := votes[user]; !ok {
+			votes[user] = voice
+		}
+	}
+	delete(s.votes, source.ID)
+
+	target.NumVotes = 0
+	for _, voice := range votes {
+		target.NumVotes += voice
+	}
+
+	s.retargetThread(source.ID, target.ID)
+	delete(s.threads, source.ID)
+	if source.Slug.Valid {
+		delete(s.threadSlugs, key(source.Slug.String))
+		s.redirects[key(source.Slug.String)] = target.ID
+	}
+
+	forum.NumThreads--
+	s.removeStaleForumUser(forum.Slug, source.Author)
+
+	*thread = threadView(target)
+	return nil
+}
+
+func (r *ThreadRepository) PurgeThread(ctx context.Context, deletion *models.ThreadDeletion, moderator string) *errs.Error {
+	s := r.storage
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	th := s.findThread(deletion.ID, deletion.Slug.String, deletion.ID != 0)
+	if th == nil {
+		return r.notFoundErr
+	}
+	forum := s.forums[key(th.Forum)]
+	if key(forum.Admin) != key(moderator) {
+		return r.notModeratorErr
+	}
+
+	authors := map[string]string{key(th.Author): th.Author}
+	for _, p := range s.threadPosts[th.ID] {
+		delete(s.posts, p.ID)
+		authors[key(p.Author)] = p.Author
+	}
+	deletion.ID, deletion.Slug = th.ID, th.Slug
+	deletion.Posts = int64(len(s.threadPosts[th.ID]))
+
+	delete(s.threadPosts, th.ID)
+	delete(s.votes, th.ID)
+	delete(s.threads, th.ID)
+	if th.Slug.Valid {
+		delete(s.threadSlugs, key(th.Slug.String))
+	}
+	s.retargetThread(th.ID, 0)
+
+	if !th.IsDeleted {
+		forum.NumThreads--
+		forum.NumPosts -= deletion.Posts
+	}
+	for _, author := range authors {
+		s.removeStaleForumUser(forum.Slug, author)
+	}
+	return nil
+}
+
+func createdBefore(a, b *models.Thread) bool {
+	if a.CreatedTimestamp.Valid != b.CreatedTimestamp.Valid {
+		return a.CreatedTimestamp.Valid
+	}
+
+	ta, tb := time.Time(a.CreatedTimestamp.Timestamp), time.Time(b.CreatedTimestamp.Timestamp)
+	if !a.CreatedTimestamp.Valid || ta.Equal(tb) {
+		return a.ID < b.ID
+	}
+	return ta.Before(tb)
+}
@@ -0,0 +1,537 @@
+package memory
+
+import (
+	"database/sql"
+	"net/http"
+	"reflect"
+	"testing"
+	"tp-project-db/errs"
+	"tp-project-db/models"
+	"tp-project-db/repositories"
+)
+
+func TestDeleteAndRestoreThread(t *testing.T) {
+	f := newFixture(t)
+	th := f.thread("bob", "jolly", 0)
+	f.post(th, 0, "alice", "hi")
+	f.post(th, 0, "bob", "hello")
+
+	thread := models.Thread{Slug: models.NullString{Valid: true, String: "jolly"}}
+	checkErr(t, "DeleteThread(not admin)", f.threads.DeleteThread(ctx, &thread, "bob"), f.threads.notModeratorErr)
+	if err := f.threads.DeleteThread(ctx, &thread, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if forum := f.storage.forums["pirate"]; forum.NumThreads != 0 || forum.NumPosts != 0 {
+		t.Errorf("forum counters after delete = %d threads, %d posts, want 0", forum.NumThreads, forum.NumPosts)
+	}
+
+	threads, err := f.threads.FindThreadsByForum(ctx, &repositories.ForumThreadsSearchArgs{Forum: "pirate"})
+	if err != nil || len(*threads) != 0 {
+		t.Errorf("FindThreadsByForum() = %v, %v, want the deleted thread hidden", threadIDs(threads), err)
+	}
+
+	thread = models.Thread{ID: th}
+	checkErr(t, "RestoreThread(not admin)", f.threads.RestoreThread(ctx, &thread, "bob"), f.threads.notModeratorErr)
+	checkErr(t, "ArchiveThread(not admin)", f.threads.ArchiveThread(ctx, &thread, true, "bob"), f.threads.notModeratorErr)
+	if err := f.threads.RestoreThread(ctx, &thread, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if forum := f.storage.forums["pirate"]; forum.NumThreads != 1 || forum.NumPosts != 2 {
+		t.Errorf("forum counters after restore = %d threads, %d posts, want 1 and 2", forum.NumThreads, forum.NumPosts)
+	}
+}
+
+func TestWritesToDeletedOrArchivedThread(t *testing.T) {
+	f := newFixture(t)
+	deleted := f.thread("bob", "", 0)
+	archived := f.thread("bob", "", 1)
+
+	f.threads.DeleteThread(ctx, &models.Thread{ID: deleted}, "alice")
+	f.threads.ArchiveThread(ctx, &models.Thread{ID: archived}, true, "alice")
+
+	args := repositories.CreatePostArgs{ThreadID: deleted}
+	checkErr(t, "FindThreadForumByID(deleted)", f.threads.FindThreadForumByID(ctx, &args), f.threads.deletedErr)
+	args = repositories.CreatePostArgs{ThreadID: archived}
+	checkErr(t, "FindThreadForumByID(archived)", f.threads.FindThreadForumByID(ctx, &args), f.threads.archivedErr)
+
+	var thread sql.NullString
+	vote := models.Vote{User: "alice", ThreadID: archived, Voice: 1}
+	status, err := f.votes.AddVote(ctx, &vote, &thread)
+	checkErr(t, "AddVote(archived)", err, f.votes.threadArchivedErr)
+	if status != http.StatusConflict {
+		t.Errorf("AddVote(archived) status = %d, want 409", status)
+	}
+}
+
+// Regression: the thread state is checked again inside CreatePosts, so a
+// thread deleted or archived after the handler's pre-check still rejects the
+// posts.
+func TestCreatePostsRechecksThreadState(t *testing.T) {
+	f := newFixture(t)
+	th := f.thread("bob", "", 0)
+
+	args := repositories.CreatePostArgs{ThreadID: th}
+	if err := f.threads.FindThreadForumByID(ctx, &args); err != nil {
+		t.Fatal(err)
+	}
+	f.threads.DeleteThread(ctx, &models.Thread{ID: th}, "alice")
+
+	posts := models.Posts{{Author: "alice", Message: "late"}}
+	checkErr(t, "CreatePosts(deleted)", f.posts.CreatePosts(ctx, &posts, &args), f.posts.threadDeletedErr)
+
+	f.threads.RestoreThread(ctx, &models.Thread{ID: th}, "alice")
+	f.threads.ArchiveThread(ctx, &models.Thread{ID: th}, true, "alice")
+	checkErr(t, "CreatePosts(archived)", f.posts.CreatePosts(ctx, &posts, &args), f.posts.threadArchivedErr)
+
+	if n := len(f.storage.posts); n != 0 {
+		t.Errorf("%d posts were stored, want none", n)
+	}
+}
+
+func TestCloseThread(t *testing.T) {
+	f := newFixture(t)
+	th := f.thread("bob", "jolly", 0)
+
+	thread := models.Thread{ID: th}
+	args := repositories.CloseThreadArgs{Moderator: "bob", Closed: true}
+	checkErr(t, "CloseThread(not admin)", f.threads.CloseThread(ctx, &thread, &args), f.threads.notModeratorErr)
+
+	args = repositories.CloseThreadArgs{Moderator: "ALICE", Closed: true, Reason: "off topic", Timestamp: timestamp(5).Timestamp}
+	if err := f.threads.CloseThread(ctx, &thread, &args); err != nil {
+		t.Fatal(err)
+	}
+	if !thread.IsClosed || thread.CloseReason != "off topic" || !thread.ClosedAt.Valid {
+		t.Errorf("CloseThread() = %+v", thread)
+	}
+	// Regression: closed_by is the moderator who closed the thread, spelled
+	// as registered, not the thread author.
+	if !thread.ClosedBy.Valid || thread.ClosedBy.String != "alice" {
+		t.Errorf("closedBy = %+v, want alice", thread.ClosedBy)
+	}
+
+	args = repositories.CloseThreadArgs{Moderator: "alice", Closed: false}
+	if err := f.threads.CloseThread(ctx, &thread, &args); err != nil {
+		t.Fatal(err)
+	}
+	if thread.IsClosed || thread.ClosedBy.Valid || thread.ClosedAt.Valid || thread.CloseReason != "" {
+		t.Errorf("reopened thread = %+v, want the closing fields cleared", thread)
+	}
+}
+
+func TestWritesToClosedThread(t *testing.T) {
+	f := newFixture(t)
+	th := f.thread("bob", "jolly", 0)
+
+	thread := models.Thread{ID: th}
+	args := repositories.CloseThreadArgs{Moderator: "alice", Closed: true, Reason: "done"}
+	if err := f.threads.CloseThread(ctx, &thread, &args); err != nil {
+		t.Fatal(err)
+	}
+	closedErr := repositories.NewThreadClosedError("done")
+
+	postArgs := repositories.CreatePostArgs{ThreadSlug: "jolly"}
+	err := f.threads.FindThreadIDAndForumBySlug(ctx, &postArgs)
+	checkErr(t, "FindThreadIDAndForumBySlug(closed)", err, closedErr)
+	if err != nil && err.Reason != "done" {
+		t.Errorf("close reason = %q, want done", err.Reason)
+	}
+
+	var voted sql.NullString
+	vote := models.Vote{User: "bob", ThreadID: th, Voice: 1}
+	status, err := f.votes.AddVote(ctx, &vote, &voted)
+	checkErr(t, "AddVote(closed)", err, closedErr)
+	if status != http.StatusForbidden {
+		t.Errorf("AddVote(closed) status = %d, want 403", status)
+	}
+}
+
+// Regression: a thread closed between the handler's pre-check and the insert
+// must still reject the posts.
+func TestCreatePostsRechecksClosed(t *testing.T) {
+	f := newFixture(t)
+	th := f.thread("bob", "", 0)
+
+	args := repositories.CreatePostArgs{ThreadID: th}
+	if err := f.threads.FindThreadForumByID(ctx, &args); err != nil {
+		t.Fatal(err)
+	}
+
+	thread := models.Thread{ID: th}
+	closeArgs := repositories.CloseThreadArgs{Moderator: "alice", Closed: true, Reason: "locked"}
+	if err := f.threads.CloseThread(ctx, &thread, &closeArgs); err != nil {
+		t.Fatal(err)
+	}
+
+	posts := models.Posts{{Author: "alice", Message: "late"}}
+	checkErr(t, "CreatePosts(closed)", f.posts.CreatePosts(ctx, &posts, &args), repositories.NewThreadClosedError("locked"))
+	if n := len(f.storage.posts); n != 0 {
+		t.Errorf("%d posts were stored, want none", n)
+	}
+}
+
+func (f *fixture) pin(id int32, until models.NullTimestamp) {
+	f.t.Helper()
+
+	thread := models.Thread{ID: id}
+	args := repositories.PinThreadArgs{Moderator: "alice", Pinned: true, Until: until}
+	if err := f.threads.PinThread(ctx, &thread, &args); err != nil {
+		f.t.Fatalf("PinThread(%d) = %v", id, err)
+	}
+}
+
+func TestPinThread(t *testing.T) {
+	f := newFixture(t)
+	th := f.thread("bob", "", 0)
+
+	thread := models.Thread{ID: th}
+	args := repositories.PinThreadArgs{Moderator: "bob", Pinned: true}
+	checkErr(t, "PinThread(not admin)", f.threads.PinThread(ctx, &thread, &args), f.threads.notModeratorErr)
+
+	// A pin that has already expired reads as unpinned.
+	f.pin(th, timestamp(-1))
+	var existing string
+	if err := f.threads.FindThreadByID(ctx, th, &existing); err != nil {
+		t.Fatal(err)
+	}
+	if err := thread.UnmarshalJSON([]byte(existing)); err != nil {
+		t.Fatal(err)
+	}
+	if thread.IsPinned {
+		t.Error("thread with an expired pin is reported pinned")
+	}
+
+	thread = models.Thread{ID: th}
+	args = repositories.PinThreadArgs{Moderator: "alice", Pinned: false}
+	if err := f.threads.PinThread(ctx, &thread, &args); err != nil {
+		t.Fatal(err)
+	}
+	if thread.IsPinned || thread.PinnedUntil.Valid {
+		t.Errorf("unpinned thread = %+v", thread)
+	}
+}
+
+// Regression: pinned threads come first on every page, since or not, and
+// count towards the limit.
+func TestFindThreadsByForumPinned(t *testing.T) {
+	f := newFixture(t)
+	var ids []int32
+	for i := 0; i < 6; i++ {
+		ids = append(ids, f.thread("bob", "", i))
+	}
+	f.pin(ids[4], models.NullTimestamp{})
+	f.pin(ids[5], models.NullTimestamp{})
+
+	tests := []struct {
+		name string
+		args repositories.ForumThreadsSearchArgs
+		want []int32
+	}{
+		{"first page", repositories.ForumThreadsSearchArgs{Limit: 3},
+			[]int32{ids[4], ids[5], ids[0]}},
+		{"limit below pinned count", repositories.ForumThreadsSearchArgs{Limit: 1},
+			[]int32{ids[4]}},
+		{"next page", repositories.ForumThreadsSearchArgs{Limit: 3, Since: timestamp(1)},
+			[]int32{ids[4], ids[5], ids[1]}},
+		{"next page without pinned", repositories.ForumThreadsSearchArgs{Limit: 3, Since: timestamp(1), ExcludePinned: true},
+			[]int32{ids[1], ids[2], ids[3]}},
+		{"descending", repositories.ForumThreadsSearchArgs{Limit: 3, Desc: true},
+			[]int32{ids[5], ids[4], ids[3]}},
+		{"descending next page", repositories.ForumThreadsSearchArgs{Limit: 3, Desc: true, Since: timestamp(2)},
+			[]int32{ids[5], ids[4], ids[2]}},
+		{"pinned excluded", repositories.ForumThreadsSearchArgs{Limit: 3, ExcludePinned: true},
+			[]int32{ids[0], ids[1], ids[2]}},
+	}
+
+	for _, tt := range tests {
+		tt.args.Forum = "pirate"
+		threads, err := f.threads.FindThreadsByForum(ctx, &tt.args)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got := threadIDs(threads); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: threads = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMoveThread(t *testing.T) {
+	f := newFixture(t)
+	f.user("carol")
+	f.forum("navy", "carol")
+	th := f.thread("bob", "jolly", 0)
+	f.post(th, 0, "alice", "hi")
+	f.post(th, 0, "bob", "hello")
+	f.thread("alice", "", 1)
+
+	thread := models.Thread{ID: th}
+	args := repositories.MoveThreadArgs{Moderator: "carol", Forum: "navy"}
+	checkErr(t, "MoveThread(admin of the target only)", f.threads.MoveThread(ctx, &thread, &args), f.threads.notModeratorErr)
+
+	args = repositories.MoveThreadArgs{Moderator: "alice", Forum: "army"}
+	checkErr(t, "MoveThread(unknown forum)", f.threads.MoveThread(ctx, &thread, &args), f.threads.targetNotFoundErr)
+
+	args = repositories.MoveThreadArgs{Moderator: "alice", Forum: "NAVY"}
+	if err := f.threads.MoveThread(ctx, &thread, &args); err != nil {
+		t.Fatal(err)
+	}
+	if thread.Forum != "navy" {
+		t.Errorf("moved thread forum = %q, want navy", thread.Forum)
+	}
+	for _, p := range f.storage.threadPosts[th] {
+		if p.Forum != "navy" {
+			t.Errorf("post %d forum = %q, want navy", p.ID, p.Forum)
+		}
+	}
+
+	source, target := f.storage.forums["pirate"], f.storage.forums["navy"]
+	if source.NumThreads != 1 || source.NumPosts != 0 || target.NumThreads != 1 || target.NumPosts != 2 {
+		t.Errorf("counters = pirate %d/%d, navy %d/%d, want 1/0 and 1/2",
+			source.NumThreads, source.NumPosts, target.NumThreads, target.NumPosts)
+	}
+
+	// alice still authors a thread in pirate, bob has nothing left there.
+	users := f.storage.forumUsers
+	if _, ok := users["pirate"]["alice"]; !ok {
+		t.Error("alice was removed from pirate")
+	}
+	if _, ok := users["pirate"]["bob"]; ok {
+		t.Error("bob is still a pirate user")
+	}
+	if len(users["navy"]) != 2 {
+		t.Errorf("navy users = %v, want alice and bob", users["navy"])
+	}
+}
+
+func TestSplitThread(t *testing.T) {
+	f := newFixture(t)
+	source := f.thread("bob", "jolly", 0)
+	root := f.post(source, 0, "alice", "root")
+	target := f.post(source, root, "bob", "split here")
+	child := f.post(source, target, "alice", "child")
+	grandchild := f.post(source, child, "bob", "grandchild")
+	sibling := f.post(source, root, "alice", "sibling")
+
+	thread := models.Thread{Title: "new"}
+	args := repositories.SplitThreadArgs{Post: target, Moderator: "bob"}
+	checkErr(t, "SplitThread(not admin)", f.threads.SplitThread(ctx, &thread, &args), f.threads.notModeratorErr)
+
+	thread = models.Thread{Title: "new", Slug: models.NullString{Valid: true, String: "JOLLY"}}
+	args = repositories.SplitThreadArgs{Post: target, Moderator: "alice"}
+	checkErr(t, "SplitThread(taken slug)", f.threads.SplitThread(ctx, &thread, &args), f.threads.conflictErr)
+
+	thread = models.Thread{Title: "new", Slug: models.NullString{Valid: true, String: "split"}}
+	if err := f.threads.SplitThread(ctx, &thread, &args); err != nil {
+		t.Fatal(err)
+	}
+	if thread.Author != "bob" || thread.Message != "split here" || thread.Forum != "pirate" {
+		t.Errorf("new thread = %+v, want it to take the split post's author and message", thread)
+	}
+
+	tombstone := f.storage.posts[target]
+	if !tombstone.IsDeleted || tombstone.SplitTo != thread.ID || tombstone.Thread != source {
+		t.Errorf("split post = %+v, want a tombstone in the source pointing at %d", tombstone.Post, thread.ID)
+	}
+
+	moved := map[int64][]int64{
+		child:      {child},
+		grandchild: {child, grandchild},
+	}
+	for id, path := range moved {
+		p := f.storage.posts[id]
+		if p.Thread != thread.ID || !reflect.DeepEqual(p.path, path) || p.pathRoot != child {
+			t.Errorf("post %d = thread %d, path %v, root %d, want thread %d, path %v", id, p.Thread, p.path, p.pathRoot, thread.ID, path)
+		}
+	}
+	if p := f.storage.posts[child]; p.ParentID != 0 {
+		t.Errorf("child parent = %d, want a root post", p.ParentID)
+	}
+	if p := f.storage.posts[sibling]; p.Thread != source {
+		t.Errorf("sibling moved to thread %d", p.Thread)
+	}
+
+	posts, err := f.posts.FindPostsByThread(ctx, &repositories.PostsByThreadSearchArgs{
+		ThreadSlug: "split", SortType: "tree",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := postIDs(posts); !reflect.DeepEqual(got, []int64{child, grandchild}) {
+		t.Errorf("new thread posts = %v, want [%d %d]", got, child, grandchild)
+	}
+
+	checkErr(t, "SplitThread(tombstone)", f.threads.SplitThread(ctx, &models.Thread{Title: "again"}, &args), f.threads.postDeletedErr)
+}
+
+// Regression: the split post becomes a tombstone, but its edit history must
+// survive, including the original message of a post that was never edited.
+func TestSplitThreadKeepsRevisions(t *testing.T) {
+	f := newFixture(t)
+	source := f.thread("bob", "", 0)
+	unedited := f.post(source, 0, "alice", "original")
+	edited := f.post(source, 0, "bob", "draft")
+
+	p := models.Post{ID: edited, Message: "final"}
+	if err := f.posts.UpdatePost(ctx, &p, &repositories.UpdatePostArgs{}); err != nil {
+		t.Fatal(err)
+	}
+
+	for id, want := range map[int64][]string{unedited: {"original"}, edited: {"draft", "final"}} {
+		args := repositories.SplitThreadArgs{Post: id, Moderator: "alice"}
+		if err := f.threads.SplitThread(ctx, &models.Thread{Title: "split"}, &args); err != nil {
+			t.Fatal(err)
+		}
+
+		var got []string
+		for _, rev := range f.storage.posts[id].revisions {
+			got = append(got, rev.Message)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("post %d revisions = %q, want %q", id, got, want)
+		}
+	}
+}
+
+func TestMergeThread(t *testing.T) {
+	f := newFixture(t)
+	f.user("carol")
+	f.forum("navy", "carol")
+	source := f.thread("bob", "jolly", 0)
+	target := f.thread("alice", "roger", 1)
+	elsewhere := f.thread("alice", "", 2)
+	f.threads.MoveThread(ctx, &models.Thread{ID: elsewhere}, &repositories.MoveThreadArgs{Moderator: "alice", Forum: "navy"})
+
+	anchor := f.post(target, 0, "alice", "anchor")
+	root := f.post(source, 0, "bob", "root")
+	child := f.post(source, root, "alice", "child")
+
+	vote := func(user string, thread int32, voice int32) {
+		var existing sql.NullString
+		if _, err := f.votes.AddVote(ctx, &models.Vote{User: user, ThreadID: thread, Voice: voice}, &existing); err != nil {
+			t.Fatal(err)
+		}
+	}
+	vote("alice", source, 1)
+	vote("bob", source, 1)
+	vote("alice", target, -1)
+
+	bySlug := func(slug string) models.Thread {
+		return models.Thread{Slug: models.NullString{Valid: true, String: slug}}
+	}
+
+	tests := []struct {
+		name   string
+		source models.Thread
+		args   repositories.MergeThreadArgs
+		want   *errs.Error
+	}{
+		{"unknown target", models.Thread{ID: source},
+			repositories.MergeThreadArgs{Moderator: "alice", Target: models.Thread{ID: 99}}, f.threads.mergeTargetErr},
+		{"into itself", bySlug("jolly"),
+			repositories.MergeThreadArgs{Moderator: "alice", Target: models.Thread{ID: source}}, f.threads.mergeSelfErr},
+		{"not admin", models.Thread{ID: source},
+			repositories.MergeThreadArgs{Moderator: "bob", Target: models.Thread{ID: target}}, f.threads.notModeratorErr},
+		{"other forum", models.Thread{ID: source},
+			repositories.MergeThreadArgs{Moderator: "alice", Target: models.Thread{ID: elsewhere}}, f.threads.forumMismatchErr},
+		{"parent outside target", models.Thread{ID: source},
+			repositories.MergeThreadArgs{Moderator: "alice", Target: models.Thread{ID: target}, Parent: root}, f.threads.parentNotFoundErr},
+	}
+	for _, tt := range tests {
+		checkErr(t, tt.name, f.threads.MergeThread(ctx, &tt.source, &tt.args), tt.want)
+	}
+
+	thread := bySlug("jolly")
+	args := repositories.MergeThreadArgs{Moderator: "alice", Target: bySlug("roger"), Parent: anchor}
+	if err := f.threads.MergeThread(ctx, &thread, &args); err != nil {
+		t.Fatal(err)
+	}
+	if thread.ID != target {
+		t.Errorf("MergeThread() returned thread %d, want the target %d", thread.ID, target)
+	}
+	// alice keeps her vote on the target, bob's vote carries over.
+	if thread.NumVotes != 0 {
+		t.Errorf("merged votes = %d, want 0", thread.NumVotes)
+	}
+
+	posts, err := f.posts.FindPostsByThread(ctx, &repositories.PostsByThreadSearchArgs{
+		ThreadID: sql.NullInt64{Valid: true, Int64: int64(target)}, SortType: "tree",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := postIDs(posts); !reflect.DeepEqual(got, []int64{anchor, root, child}) {
+		t.Errorf("merged posts = %v, want [%d %d %d]", got, anchor, root, child)
+	}
+	if p := f.storage.posts[root]; p.ParentID != anchor || !reflect.DeepEqual(p.path, []int64{anchor, root}) {
+		t.Errorf("merged root = parent %d, path %v, want it under %d", p.ParentID, p.path, anchor)
+	}
+
+	if n := f.storage.forums["pirate"].NumThreads; n != 1 {
+		t.Errorf("forum threads = %d, want 1", n)
+	}
+
+	var existing string
+	slug := "JOLLY"
+	if err := f.threads.FindThreadBySlug(ctx, &slug, &existing); err != nil {
+		t.Errorf("old slug no longer resolves: %v", err)
+	}
+	var redirected models.Thread
+	redirected.UnmarshalJSON([]byte(existing))
+	if redirected.ID != target {
+		t.Errorf("old slug resolves to %d, want %d", redirected.ID, target)
+	}
+}
+
+// Regression: the slug of a merged thread keeps redirecting, so it can't be
+// taken by a new thread or a split.
+func TestRedirectSlugIsTaken(t *testing.T) {
+	f := newFixture(t)
+	source := f.thread("bob", "jolly", 0)
+	target := f.thread("alice", "", 1)
+	post := f.post(target, 0, "alice", "hi")
+
+	thread := models.Thread{ID: source}
+	args := repositories.MergeThreadArgs{Moderator: "alice", Target: models.Thread{ID: target}}
+	if err := f.threads.MergeThread(ctx, &thread, &args); err != nil {
+		t.Fatal(err)
+	}
+
+	var existing sql.NullString
+	th := models.Thread{Forum: "pirate", Author: "bob", Slug: models.NullString{Valid: true, String: "Jolly"}}
+	status, err := f.threads.CreateThread(ctx, &th, &existing)
+	if err != nil || status != http.StatusConflict {
+		t.Errorf("CreateThread(redirect slug) = %d, %v, want 409", status, err)
+	}
+
+	split := models.Thread{Title: "split", Slug: models.NullString{Valid: true, String: "jolly"}}
+	splitArgs := repositories.SplitThreadArgs{Post: post, Moderator: "alice"}
+	checkErr(t, "SplitThread(redirect slug)", f.threads.SplitThread(ctx, &split, &splitArgs), f.threads.conflictErr)
+}
+
+func TestFindThreadsByAuthor(t *testing.T) {
+	f := newFixture(t)
+	first := f.thread("bob", "", 0)
+	f.thread("alice", "", 1)
+	second := f.thread("bob", "", 2)
+	deleted := f.thread("bob", "", 3)
+	f.threads.DeleteThread(ctx, &models.Thread{ID: deleted}, "alice")
+
+	args := repositories.AuthorSearchArgs{Author: "bob", Limit: 1}
+	threads, err := f.threads.FindThreadsByAuthor(ctx, &args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := threadIDs(threads); !reflect.DeepEqual(got, []int32{first}) {
+		t.Errorf("first page = %v, want [%d]", got, first)
+	}
+
+	args.Since = int(first)
+	args.Limit = 10
+	threads, _ = f.threads.FindThreadsByAuthor(ctx, &args)
+	if got := threadIDs(threads); !reflect.DeepEqual(got, []int32{second}) {
+		t.Errorf("next page = %v, want [%d] without the deleted thread", got, second)
+	}
+
+	_, err = f.threads.FindThreadsByAuthor(ctx, &repositories.AuthorSearchArgs{Author: "dave"})
+	checkErr(t, "FindThreadsByAuthor(unknown user)", err, f.threads.userNotFoundErr)
+}
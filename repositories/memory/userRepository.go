@@ -0,0 +1,136 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"github.com/mailru/easyjson"
+	"net/http"
+	"sort"
+	"tp-project-db/consts"
+	"tp-project-db/errs"
+	"tp-project-db/models"
+	"tp-project-db/repositories"
+)
+
+type UserRepository struct {
+	storage          *Storage
+	notFoundErr      *errs.Error
+	forumNotFoundErr *errs.Error
+}
+
+func NewUserRepository(storage *Storage) *UserRepository {
+	return &UserRepository{
+		storage: storage,
+		notFoundErr: errs.NewNotFoundError(repositories.UserNotFoundErrMessage).
+			WithCode(repositories.UserNotFoundErrCode).WithEntity("user", "nickname"),
+		forumNotFoundErr: errs.NewNotFoundError(repositories.ForumNotFoundErrMessage).
+			WithCode(repositories.ForumNotFoundErrCode).WithEntity("forum", "slug"),
+	}
+}
+
+func (r *UserRepository) CreateUser(ctx context.Context, user *models.User, existing *string) (int, *errs.Error) {
+	s := r.storage
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	conflicts := make([]models.User, 0, 2)
+	if u, ok := s.users[key(user.Nickname)]; ok {
+		conflicts = append(conflicts, *u)
+	}
+	if nickname, ok := s.emails[key(user.Email)]; ok && nickname != key(user.Nickname) {
+		conflicts = append(conflicts, *s.users[nickname])
+	}
+	if len(conflicts) > 0 {
+		b, _ := easyjson.Marshal((*models.Users)(&conflicts))
+		*existing = string(b)
+		return http.StatusConflict, nil
+	}
+
+	u := *user
+	s.users[key(u.Nickname)] = &u
+	s.emails[key(u.Email)] = key(u.Nickname)
+
+	b, _ := easyjson.Marshal(&u)
+	*existing = string(b)
+	return http.StatusCreated, nil
+}
+
+func (r *UserRepository) FindUser(ctx context.Context, user *models.User) *errs.Error {
+	s := r.storage
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	u, ok := s.users[key(user.Nickname)]
+	if !ok {
+		return r.notFoundErr
+	}
+
+	*user = *u
+	return nil
+}
+
+func (r *UserRepository) FindUsersByForum(ctx context.Context, args *repositories.UsersByForumSearchArgs) (*models.Users, *errs.Error) {
+	s := r.storage
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	if _, ok := s.forums[key(args.Forum)]; !ok {
+		return nil, r.forumNotFoundErr
+	}
+
+	since := key(args.Since)
+	users := make([]models.User, 0)
+	for k := range s.forumUsers[key(args.Forum)] {
+		if args.Since != consts.EmptyString {
+			if args.Desc && k >= since || !args.Desc && k <= since {
+				continue
+			}
+		}
+		users = append(users, *s.users[k])
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		if args.Desc {
+			return key(users[i].Nickname) > key(users[j].Nickname)
+		}
+		return key(users[i].Nickname) < key(users[j].Nickname)
+	})
+	if args.Limit > 0 && len(users) > args.Limit {
+		users = users[:args.Limit]
+	}
+
+	return (*models.Users)(&users), nil
+}
+
+func (r *UserRepository) UpdateUser(ctx context.Context, user *models.User, existing *sql.NullString) (int, *errs.Error) {
+	s := r.storage
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if nickname, ok := s.emails[key(user.Email)]; ok {
+		b, _ := easyjson.Marshal(s.users[nickname])
+		*existing = sql.NullString{Valid: true, String: string(b)}
+		return http.StatusConflict, nil
+	}
+
+	u, ok := s.users[key(user.Nickname)]
+	if !ok {
+		return http.StatusNotFound, r.notFoundErr
+	}
+
+	if user.Email != consts.EmptyString {
+		delete(s.emails, key(u.Email))
+		u.Email = user.Email
+		s.emails[key(u.Email)] = key(u.Nickname)
+	}
+	if user.FullName != consts.EmptyString {
+		u.FullName = user.FullName
+	}
+	if user.About != consts.EmptyString {
+		u.About = user.About
+	}
+
+	b, _ := easyjson.Marshal(u)
+	*existing = sql.NullString{Valid: true, String: string(b)}
+	return http.StatusOK, nil
+}
@@ -0,0 +1,78 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"github.com/mailru/easyjson"
+	"net/http"
+	"tp-project-db/errs"
+	"tp-project-db/models"
+	"tp-project-db/repositories"
+)
+
+type VoteRepository struct {
+	storage           *Storage
+	authorNotFoundErr *errs.Error
+	threadNotFoundErr *errs.Error
+	threadDeletedErr  *errs.Error
+	threadArchivedErr *errs.Error
+}
+
+func NewVoteRepository(storage *Storage) *VoteRepository {
+	return &VoteRepository{
+		storage: storage,
+		authorNotFoundErr: errs.NewNotFoundError(repositories.VoteAuthorNotFoundErrMessage).
+			WithCode(repositories.VoteAuthorNotFoundErrCode).WithEntity("user", "nickname"),
+		threadNotFoundErr: errs.NewNotFoundError(repositories.VoteThreadNotFoundErrMessage).
+			WithCode(repositories.VoteThreadNotFoundErrCode).WithEntity("thread", "slug_or_id"),
+		threadDeletedErr: errs.NewConflictError(repositories.ThreadDeletedErrMessage).
+			WithCode(repositories.ThreadDeletedErrCode).WithEntity("thread", "slug_or_id"),
+		threadArchivedErr: errs.NewConflictError(repositories.ThreadArchivedErrMessage).
+			WithCode(repositories.ThreadArchivedErrCode).WithEntity("thread", "slug_or_id"),
+	}
+}
+
+func (r *VoteRepository) AddVote(ctx context.Context, vote *models.Vote, thread *sql.NullString) (int, *errs.Error) {
+	s := r.storage
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	th := s.findThread(vote.ThreadID, vote.ThreadSlug, vote.ThreadID != 0)
+	if th == nil {
+		return http.StatusNotFound, r.threadNotFoundErr
+	}
+
+	if _, ok := s.users[key(vote.User)]; !ok {
+		return http.StatusNotFound, r.authorNotFoundErr
+	}
+
+	if th.IsDeleted {
+		return http.StatusConflict, r.threadDeletedErr
+	}
+	if th.IsArchived {
+		return http.StatusConflict, r.threadArchivedErr
+	}
+	if th.IsClosed {
+		return http.StatusForbidden, repositories.NewThreadClosedError(th.CloseReason)
+	}
+
+	votes, ok := s.votes[th.ID]
+	if !ok {
+		votes = make(map[string]int32)
+		s.votes[th.ID] = votes
+	}
+
+	prev, ok := votes[key(vote.User)]
+	switch {
+	case !ok:
+		th.NumVotes += vote.Voice
+	case prev != vote.Voice:
+		th.NumVotes += 2 * vote.Voice
+	}
+	votes[key(vote.User)] = vote.Voice
+
+	v := threadView(th)
+	b, _ := easyjson.Marshal(&v)
+	*thread = sql.NullString{Valid: true, String: string(b)}
+	return http.StatusOK, nil
+}
@@ -0,0 +1,90 @@
+package repositories
+
+import (
+	"github.com/jackc/pgx"
+	"strings"
+	"sync/atomic"
+	"time"
+	"tp-project-db/metrics"
+)
+
+const (
+	AdHocStatementLabel = "ad_hoc"
+)
+
+var (
+	txControlStatements = []string{"begin", "commit", "rollback"}
+)
+
+var (
+	statementDuration = metrics.DefaultRegistry.NewHistogramVec(
+		"db_statement_duration_seconds",
+		"Execution time of database statements.",
+		metrics.DefaultBuckets,
+		"statement",
+	)
+	statementErrors = metrics.DefaultRegistry.NewCounterVec(
+		"db_statement_errors_total",
+		"Number of failed database statements.",
+		"statement",
+	)
+)
+
+func (c *Connection) registerPoolMetrics() {
+	metrics.DefaultRegistry.NewGaugeFunc(
+		"db_pool_max_connections",
+		"Maximum number of connections in the pool.",
+		func() float64 { return float64(c.conn.Stat().MaxConnections) },
+	)
+	metrics.DefaultRegistry.NewGaugeFunc(
+		"db_pool_acquired_connections",
+		"Number of connections currently checked out of the pool.",
+		func() float64 {
+			stat := c.conn.Stat()
+			return float64(stat.CheckedOutConnections())
+		},
+	)
+	metrics.DefaultRegistry.NewGaugeFunc(
+		"db_pool_idle_connections",
+		"Number of open connections available in the pool.",
+		func() float64 { return float64(c.conn.Stat().AvailableConnections) },
+	)
+	metrics.DefaultRegistry.NewGaugeFunc(
+		"db_pool_waiting_requests",
+		"Number of callers waiting to acquire a connection.",
+		func() float64 { return float64(atomic.LoadInt64(&c.waiting)) },
+	)
+}
+
+func (c *Connection) Log(level pgx.LogLevel, msg string, data map[string]interface{}) {
+	if msg != "Query" && msg != "Exec" {
+		return
+	}
+
+	sql, _ := data["sql"].(string)
+	stmt := c.statementLabel(sql)
+
+	if level <= pgx.LogLevelError {
+		statementErrors.Inc(stmt)
+		return
+	}
+	if dt, ok := data["time"].(time.Duration); ok {
+		statementDuration.Observe(dt.Seconds(), stmt)
+	}
+}
+
+func (c *Connection) statementLabel(sql string) string {
+	c.stmtsMtx.RLock()
+	_, ok := c.stmts[sql]
+	c.stmtsMtx.RUnlock()
+	if ok {
+		return sql
+	}
+
+	for _, stmt := range txControlStatements {
+		if strings.HasPrefix(sql, stmt) {
+			return stmt
+		}
+	}
+	return AdHocStatementLabel
+}
@@ -0,0 +1,218 @@
+package repositories
+
+import (
+	"context"
+	"github.com/jackc/pgx"
+	"time"
+	"tp-project-db/migrations"
+)
+
+const (
+	MigrationsLockKey = 20181101
+
+	CreateSchemaMigrationsTableQuery = `
+        CREATE TABLE IF NOT EXISTS "schema_migrations" (
+            "version" INTEGER
+                CONSTRAINT "schema_migrations_version_pk" PRIMARY KEY,
+            "name" TEXT
+                CONSTRAINT "schema_migrations_name_not_null" NOT NULL,
+            "applied_timestamp" TIMESTAMPTZ
+                DEFAULT(now())
+                CONSTRAINT "schema_migrations_applied_timestamp_not_null" NOT NULL
+        );
+    `
+	SelectSchemaMigrationsQuery = `
+        SELECT sm."version", sm."applied_timestamp"
+        FROM "schema_migrations" sm;
+    `
+	InsertSchemaMigrationQuery = `
+        INSERT INTO "schema_migrations"("version","name")
+        VALUES($1,$2);
+    `
+	DeleteSchemaMigrationQuery = `
+        DELETE FROM "schema_migrations"
+        WHERE "version" = $1;
+    `
+)
+
+type MigrationStatus struct {
+	Version          int
+	Name             string
+	Applied          bool
+	AppliedTimestamp time.Time
+}
+
+type Migrator struct {
+	conn       *Connection
+	migrations []migrations.Migration
+}
+
+func NewMigrator(conn *Connection) *Migrator {
+	return &Migrator{
+		conn:       conn,
+		migrations: migrations.All,
+	}
+}
+
+func (m *Migrator) Up() error {
+	changed := false
+	err := m.withLock(func(conn *pgx.Conn) error {
+		applied, err := m.selectApplied(conn)
+		if err != nil {
+			return err
+		}
+
+		for _, mg := range m.migrations {
+			if _, ok := applied[mg.Version]; ok {
+				continue
+			}
+			if err := m.exec(conn, mg.Up, InsertSchemaMigrationQuery, mg.Version, mg.Name); err != nil {
+				return err
+			}
+			changed = true
+		}
+		return nil
+	})
+	if changed {
+		m.conn.conn.Reset()
+	}
+	return err
+}
+
+func (m *Migrator) Down(steps int) error {
+	changed := false
+	err := m.withLock(func(conn *pgx.Conn) error {
+		applied, err := m.selectApplied(conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && steps > 0; i-- {
+			mg := m.migrations[i]
+			if _, ok := applied[mg.Version]; !ok {
+				continue
+			}
+			if err := m.exec(conn, mg.Down, DeleteSchemaMigrationQuery, mg.Version); err != nil {
+				return err
+			}
+			changed = true
+			steps--
+		}
+		return nil
+	})
+	if changed {
+		m.conn.conn.Reset()
+	}
+	return err
+}
+
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	err := m.withLock(func(conn *pgx.Conn) error {
+		applied, err := m.selectApplied(conn)
+		if err != nil {
+			return err
+		}
+
+		for _, mg := range m.migrations {
+			ts, ok := applied[mg.Version]
+			statuses = append(statuses, MigrationStatus{
+				Version:          mg.Version,
+				Name:             mg.Name,
+				Applied:          ok,
+				AppliedTimestamp: ts,
+			})
+		}
+		return nil
+	})
+	return statuses, err
+}
+
+func (m *Migrator) Pending(ctx context.Context) ([]migrations.Migration, error) {
+	rows, err := m.conn.query(ctx, SelectSchemaMigrationsQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]bool)
+	for rows.Next() {
+		var version int
+		var ts time.Time
+		if err = rows.Scan(&version, &ts); err != nil {
+			return nil, err
+		}
+		applied[version] = true
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	pending := make([]migrations.Migration, 0)
+	for _, mg := range m.migrations {
+		if !applied[mg.Version] {
+			pending = append(pending, mg)
+		}
+	}
+	return pending, nil
+}
+
+func (m *Migrator) withLock(f func(conn *pgx.Conn) error) error {
+	conn, err := m.conn.acquire(context.Background())
+	if err != nil {
+		return err
+	}
+	defer m.conn.conn.Release(conn)
+
+	if _, err = conn.Exec(`SELECT pg_advisory_lock($1);`, MigrationsLockKey); err != nil {
+		return err
+	}
+	defer func() {
+		_, _ = conn.Exec(`SELECT pg_advisory_unlock($1);`, MigrationsLockKey)
+	}()
+
+	if _, err = conn.Exec(CreateSchemaMigrationsTableQuery); err != nil {
+		return err
+	}
+
+	return f(conn)
+}
+
+func (m *Migrator) selectApplied(conn *pgx.Conn) (map[int]time.Time, error) {
+	rows, err := conn.Query(SelectSchemaMigrationsQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var ts time.Time
+		if err = rows.Scan(&version, &ts); err != nil {
+			return nil, err
+		}
+		applied[version] = ts
+	}
+
+	return applied, rows.Err()
+}
+
+func (m *Migrator) exec(conn *pgx.Conn, stmt, bookkeeping string, args ...interface{}) error {
+	tx, err := conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if _, err = tx.Exec(stmt); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(bookkeeping, args...); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -1,10 +1,12 @@
 package repositories
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"github.com/go-openapi/strfmt"
 	"github.com/jackc/pgx"
+	"tp-project-db/consts"
 	"tp-project-db/errs"
 	"tp-project-db/models"
 )
@@ -15,52 +17,43 @@ const (
 	PostForumNotFoundErrMessage  = "post forum not found"
 	PostThreadNotFoundErrMessage = "post thread not found"
 	PostParentNotFoundErrMessage = "post parent not found"
+	PostsNotInsertedErrMessage   = "posts not inserted"
+	PostDeletedErrMessage        = "post is deleted"
+	PostNotModeratorErrMessage   = "only the forum admin can purge posts"
+	PostEditorNotFoundErrMessage = "post editor not found"
 )
 
 const (
-	CreatePostTableQuery = `
-        CREATE TABLE IF NOT EXISTS "post" (
-            "id" BIGINT
-                CONSTRAINT "post_id_pk" PRIMARY KEY,
-            "parent_id" BIGINT
-                DEFAULT(0)
-                CONSTRAINT "post_parent_id_nullable" NULL,
-            "author" CITEXT COLLATE "ucs_basic"
-                CONSTRAINT "post_author_not_null" NOT NULL
-                CONSTRAINT "post_author_fk" REFERENCES "user"("nickname"),
-            "forum" CITEXT COLLATE "ucs_basic"
-                CONSTRAINT "post_forum_not_null" NOT NULL
-                CONSTRAINT "post_forum_fk" REFERENCES "forum"("slug"),
-            "thread" INTEGER
-                CONSTRAINT "post_thread_not_null" NOT NULL
-                CONSTRAINT "post_thread_fk" REFERENCES "thread"("id"),
-            "message" TEXT
-                CONSTRAINT "post_message_not_null" NOT NULL,
-            "created_timestamp" TIMESTAMPTZ
-                CONSTRAINT "post_created_timestamp_nullable" NULL,
-            "is_edited" BOOLEAN
-                DEFAULT(FALSE)
-                CONSTRAINT "post_is_edited_not_null" NOT NULL,
-            "path" BIGINT ARRAY,
-            "path_root" BIGINT
-                CONSTRAINT "post_parent_root_nullable" NULL
-        );
-
-        CREATE SEQUENCE IF NOT EXISTS "post_id_seq" START 1;
-
-        CREATE INDEX IF NOT EXISTS "post_author_idx" ON "post"("author");
-        CREATE INDEX IF NOT EXISTS "post_forum_idx" ON "post"("forum");
-        CREATE INDEX IF NOT EXISTS "post_thread_idx" ON "post"("thread");
-        CREATE INDEX IF NOT EXISTS "post_path_root_idx" ON "post"("path_root");
-    `
+	PostNotFoundErrCode       = "post_not_found"
+	PostAuthorNotFoundErrCode = "post_author_not_found"
+	PostForumNotFoundErrCode  = "post_forum_not_found"
+	PostThreadNotFoundErrCode = "post_thread_not_found"
+	PostParentNotFoundErrCode = "post_parent_not_found"
+	PostDeletedErrCode        = "post_deleted"
+	PostNotModeratorErrCode   = "not_moderator"
+	PostEditorNotFoundErrCode = "post_editor_not_found"
+)
 
+const (
 	SelectNextPostIDStatement              = "select_next_post_id_statement"
 	SelectPostByIDStatement                = "select_post_by_id_statement"
-	SelectPostExistsByIDStatement          = "select_post_exists_by_id_statement"
 	SelectPostExistsByIDAndThreadStatement = "select_post_exists_by_id_and_thread_statement"
 	UpdateForumNumPostsStatement           = "update_forum_num_posts_statement"
 	InsertForumUserStatement               = "insert_forum_user_statement"
 	UpdatePostByIDStatement                = "update_post_by_id_statement"
+	DeletePostByIDStatement                = "delete_post_by_id_statement"
+	SelectPostForPurgeStatement            = "select_post_for_purge_statement"
+	SelectForumAdminExistsStatement        = "select_forum_admin_exists_statement"
+	DeletePostSubtreeStatement             = "delete_post_subtree_statement"
+	DeleteStaleForumUsersStatement         = "delete_stale_forum_users_statement"
+	SelectPostForUpdateStatement           = "select_post_for_update_statement"
+	InsertOriginalPostRevisionStatement    = "insert_original_post_revision_statement"
+	InsertPostRevisionStatement            = "insert_post_revision_statement"
+	SelectPostRevisionsStatement           = "select_post_revisions_statement"
+	SelectPostIsDeletedStatement           = "select_post_is_deleted_statement"
+	SelectThreadStateForShareStatement     = "select_thread_state_for_share_statement"
+	SelectPostSubtreeStatement             = "select_post_subtree_statement"
+	SelectPostAncestorsStatement           = "select_post_ancestors_statement"
 )
 
 type PostRepository struct {
@@ -70,26 +63,44 @@ type PostRepository struct {
 	authorNotFoundErr *errs.Error
 	forumNotFoundErr  *errs.Error
 	threadNotFoundErr *errs.Error
+	deletedErr        *errs.Error
+	notModeratorErr   *errs.Error
+	editorNotFoundErr *errs.Error
+	userNotFoundErr   *errs.Error
+	threadDeletedErr  *errs.Error
+	threadArchivedErr *errs.Error
 }
 
 func NewPostRepository(conn *Connection) *PostRepository {
 	return &PostRepository{
-		conn:              conn,
-		notFoundErr:       errs.NewNotFoundError(PostNotFoundErrMessage),
-		conflictErr:       errs.NewConflictError(PostParentNotFoundErrMessage),
-		authorNotFoundErr: errs.NewNotFoundError(PostAuthorNotFoundErrMessage),
-		forumNotFoundErr:  errs.NewNotFoundError(PostForumNotFoundErrMessage),
-		threadNotFoundErr: errs.NewNotFoundError(PostThreadNotFoundErrMessage),
+		conn: conn,
+		notFoundErr: errs.NewNotFoundError(PostNotFoundErrMessage).
+			WithCode(PostNotFoundErrCode).WithEntity("post", "id"),
+		conflictErr: errs.NewConflictError(PostParentNotFoundErrMessage).
+			WithCode(PostParentNotFoundErrCode).WithEntity("post", "parent"),
+		authorNotFoundErr: errs.NewNotFoundError(PostAuthorNotFoundErrMessage).
+			WithCode(PostAuthorNotFoundErrCode).WithEntity("user", "author"),
+		forumNotFoundErr: errs.NewNotFoundError(PostForumNotFoundErrMessage).
+			WithCode(PostForumNotFoundErrCode).WithEntity("forum", "forum"),
+		threadNotFoundErr: errs.NewNotFoundError(PostThreadNotFoundErrMessage).
+			WithCode(PostThreadNotFoundErrCode).WithEntity("thread", "slug_or_id"),
+		deletedErr: errs.NewConflictError(PostDeletedErrMessage).
+			WithCode(PostDeletedErrCode).WithEntity("post", "id"),
+		notModeratorErr: errs.NewForbiddenError(PostNotModeratorErrMessage).
+			WithCode(PostNotModeratorErrCode).WithEntity("user", "moderator"),
+		editorNotFoundErr: errs.NewNotFoundError(PostEditorNotFoundErrMessage).
+			WithCode(PostEditorNotFoundErrCode).WithEntity("user", "editor"),
+		userNotFoundErr: errs.NewNotFoundError(UserNotFoundErrMessage).
+			WithCode(UserNotFoundErrCode).WithEntity("user", "nickname"),
+		threadDeletedErr: errs.NewConflictError(ThreadDeletedErrMessage).
+			WithCode(ThreadDeletedErrCode).WithEntity("thread", "slug_or_id"),
+		threadArchivedErr: errs.NewConflictError(ThreadArchivedErrMessage).
+			WithCode(ThreadArchivedErrCode).WithEntity("thread", "slug_or_id"),
 	}
 }
 
 func (r *PostRepository) Init() error {
-	err := r.conn.execInit(CreatePostTableQuery)
-	if err != nil {
-		return err
-	}
-
-	err = r.conn.prepareStmt(SelectNextPostIDStatement, `
+	err := r.conn.prepareStmt(SelectNextPostIDStatement, `
         SELECT nextval('post_id_seq');
     `)
 	if err != nil {
@@ -105,13 +116,6 @@ func (r *PostRepository) Init() error {
 		return err
 	}
 
-	err = r.conn.prepareStmt(SelectPostExistsByIDStatement, `
-        SELECT EXISTS(SELECT * FROM "post" p WHERE p."id" = $1);
-    `)
-	if err != nil {
-		return err
-	}
-
 	err = r.conn.prepareStmt(SelectPostExistsByIDAndThreadStatement, `
         SELECT EXISTS(SELECT * FROM "post" p WHERE p."id" = $1 AND p."thread" = $2);
     `)
@@ -137,18 +141,165 @@ func (r *PostRepository) Init() error {
 	}
 
 	err = r.conn.prepareStmt(UpdatePostByIDStatement, `
-        UPDATE "post" SET
+        UPDATE "post" p SET
             "message" = $2,
-            "is_edited" =
-                CASE
-                    WHEN "message" != $2 THEN TRUE
-                    ELSE "is_edited"
-                END
-        WHERE "id" = $1
-        RETURNING
-            "id","parent_id","author",
-            "forum","thread","message",
-            "created_timestamp","is_edited";
+            "is_edited" = TRUE
+        WHERE p."id" = $1
+        RETURNING `+PostAttributes+`;
+    `)
+	if err != nil {
+		return err
+	}
+
+	err = r.conn.prepareStmt(DeletePostByIDStatement, `
+        WITH "original" AS (
+            INSERT INTO "post_revision"("post","revision","message","editor","created_timestamp")
+            SELECT p."id", 1, p."message", p."author", p."created_timestamp"
+            FROM "post" p
+            WHERE p."id" = $1 AND NOT p."is_deleted"
+                AND NOT EXISTS(SELECT * FROM "post_revision" r WHERE r."post" = p."id")
+        )
+        UPDATE "post" p SET
+            "message" = '',
+            "is_deleted" = TRUE
+        WHERE p."id" = $1
+        RETURNING `+PostAttributes+`;
+    `)
+	if err != nil {
+		return err
+	}
+
+	err = r.conn.prepareStmt(SelectPostForPurgeStatement, `
+        SELECT p."forum", p."path_root", th."is_deleted"
+        FROM "post" p
+        JOIN "thread" th ON th."id" = p."thread"
+        WHERE p."id" = $1
+        FOR UPDATE OF p;
+    `)
+	if err != nil {
+		return err
+	}
+
+	err = r.conn.prepareStmt(SelectForumAdminExistsStatement, `
+        SELECT EXISTS(SELECT * FROM "forum" f WHERE f."slug" = $1 AND f."admin" = $2);
+    `)
+	if err != nil {
+		return err
+	}
+
+	err = r.conn.prepareStmt(DeletePostSubtreeStatement, `
+        WITH "deleted" AS (
+            DELETE FROM "post" p
+            WHERE p."path_root" = $2 AND p."path" @> ARRAY[$1::BIGINT]
+            RETURNING p."author"
+        )
+        SELECT COUNT(*), COALESCE(array_agg(DISTINCT d."author"::TEXT), '{}')
+        FROM "deleted" d;
+    `)
+	if err != nil {
+		return err
+	}
+
+	err = r.conn.prepareStmt(DeleteStaleForumUsersStatement, `
+        DELETE FROM "forum_user" fu
+        WHERE fu."forum" = $1
+            AND fu."user" = ANY($2::CITEXT[])
+            AND NOT EXISTS(
+                SELECT * FROM "post" p
+                WHERE p."forum" = fu."forum" AND p."author" = fu."user"
+            )
+            AND NOT EXISTS(
+                SELECT * FROM "thread" th
+                WHERE th."forum" = fu."forum" AND th."author" = fu."user"
+            );
+    `)
+	if err != nil {
+		return err
+	}
+
+	err = r.conn.prepareStmt(SelectPostForUpdateStatement, `
+        SELECT p."message", p."author", p."is_deleted"
+        FROM "post" p
+        WHERE p."id" = $1
+        FOR UPDATE;
+    `)
+	if err != nil {
+		return err
+	}
+
+	err = r.conn.prepareStmt(InsertOriginalPostRevisionStatement, `
+        INSERT INTO "post_revision"("post","revision","message","editor","created_timestamp")
+        SELECT p."id", 1, p."message", p."author", p."created_timestamp"
+        FROM "post" p
+        WHERE p."id" = $1
+            AND NOT EXISTS(SELECT * FROM "post_revision" r WHERE r."post" = p."id");
+    `)
+	if err != nil {
+		return err
+	}
+
+	err = r.conn.prepareStmt(InsertPostRevisionStatement, `
+        INSERT INTO "post_revision"("post","revision","message","editor","created_timestamp")
+        SELECT $1, MAX(r."revision") + 1, $2, $3, $4
+        FROM "post_revision" r
+        WHERE r."post" = $1;
+    `)
+	if err != nil {
+		return err
+	}
+
+	err = r.conn.prepareStmt(SelectPostRevisionsStatement, `
+        SELECT 1, p."message", p."author", p."created_timestamp"
+        FROM "post" p
+        WHERE p."id" = $1 AND NOT p."is_deleted"
+            AND NOT EXISTS(SELECT * FROM "post_revision" r WHERE r."post" = p."id")
+        UNION ALL
+        SELECT r."revision", r."message", r."editor", r."created_timestamp"
+        FROM "post_revision" r
+        JOIN "post" p ON p."id" = r."post"
+        WHERE r."post" = $1 AND NOT p."is_deleted"
+        ORDER BY 1;
+    `)
+	if err != nil {
+		return err
+	}
+
+	err = r.conn.prepareStmt(SelectPostIsDeletedStatement, `
+        SELECT p."is_deleted" FROM "post" p WHERE p."id" = $1;
+    `)
+	if err != nil {
+		return err
+	}
+
+	err = r.conn.prepareStmt(SelectThreadStateForShareStatement, `
+        SELECT th."forum",th."is_deleted",th."is_archived",
+            th."is_closed",COALESCE(th."close_reason",'')
+        FROM "thread" th
+        WHERE th."id" = $1
+        FOR SHARE;
+    `)
+	if err != nil {
+		return err
+	}
+
+	err = r.conn.prepareStmt(SelectPostSubtreeStatement, `
+        SELECT `+PostAttributes+`
+        FROM "post" p
+        JOIN "post" t ON t."id" = $1
+        WHERE p."path_root" = t."path_root" AND p."path" @> ARRAY[t."id"]
+            AND ($2::INTEGER IS NULL OR array_length(p."path", 1) <= array_length(t."path", 1) + $2)
+        ORDER BY p."path";
+    `)
+	if err != nil {
+		return err
+	}
+
+	err = r.conn.prepareStmt(SelectPostAncestorsStatement, `
+        SELECT `+PostAttributes+`
+        FROM "post" p
+        JOIN "post" t ON t."id" = $1
+        WHERE p."id" = ANY(t."path")
+        ORDER BY array_length(p."path", 1);
     `)
 	if err != nil {
 		return err
@@ -157,6 +308,19 @@ func (r *PostRepository) Init() error {
 	return nil
 }
 
+func (r *PostRepository) checkThreadWritable(th *models.Thread) *errs.Error {
+	if th.IsDeleted {
+		return r.threadDeletedErr
+	}
+	if th.IsArchived {
+		return r.threadArchivedErr
+	}
+	if th.IsClosed {
+		return NewThreadClosedError(th.CloseReason)
+	}
+	return nil
+}
+
 type CreatePostArgs struct {
 	ThreadID    int32
 	ThreadSlug  string
@@ -164,11 +328,21 @@ type CreatePostArgs struct {
 	Timestamp   strfmt.DateTime
 }
 
-func (r *PostRepository) CreatePosts(posts *models.Posts, args *CreatePostArgs) *errs.Error {
-	return r.conn.performTxOp(func(tx *pgx.Tx) *errs.Error {
+func (r *PostRepository) CreatePosts(ctx context.Context, posts *models.Posts, args *CreatePostArgs) *errs.Error {
+	return r.conn.performTxOp(ctx, pgx.ReadCommitted, func(tx *Tx) *errs.Error {
 		arrPtr := (*[]models.Post)(posts)
 		n := len(*arrPtr)
 
+		var th models.Thread
+		row := tx.queryRow(SelectThreadStateForShareStatement, &args.ThreadID)
+		err := row.Scan(&args.ThreadForum, &th.IsDeleted, &th.IsArchived, &th.IsClosed, &th.CloseReason)
+		if err != nil {
+			return wrapNotFoundError(err, r.threadNotFoundErr)
+		}
+		if err := r.checkThreadWritable(&th); err != nil {
+			return err
+		}
+
 		query := `INSERT INTO "post"("id",
             "parent_id","author","forum","thread",
             "created_timestamp","message","path","path_root"
@@ -180,9 +354,9 @@ func (r *PostRepository) CreatePosts(posts *models.Posts, args *CreatePostArgs)
 		for i := 0; i < n; i++ {
 			postPtr := &(*arrPtr)[i]
 
-			row := tx.QueryRow(SelectNextPostIDStatement)
+			row = tx.queryRow(SelectNextPostIDStatement)
 			if err := row.Scan(&postPtr.ID); err != nil {
-				panic(err)
+				return wrapError(err)
 			}
 
 			postPtr.Thread = args.ThreadID
@@ -191,17 +365,20 @@ func (r *PostRepository) CreatePosts(posts *models.Posts, args *CreatePostArgs)
 
 			if postPtr.ParentID != 0 {
 				var exists bool
-				row := tx.QueryRow(SelectPostExistsByIDAndThreadStatement,
+				row := tx.queryRow(SelectPostExistsByIDAndThreadStatement,
 					&postPtr.ParentID, &postPtr.Thread,
 				)
-				if _ = row.Scan(&exists); !exists {
+				if err := row.Scan(&exists); err != nil {
+					return wrapError(err)
+				}
+				if !exists {
 					return r.conflictErr
 				}
 			}
 
-			row = tx.QueryRow(SelectUserNicknameByNicknameStatement, &postPtr.Author)
-			if row.Scan(&postPtr.Author) != nil {
-				return r.authorNotFoundErr
+			row = tx.queryRow(SelectUserNicknameByNicknameStatement, &postPtr.Author)
+			if err := row.Scan(&postPtr.Author); err != nil {
+				return wrapNotFoundError(err, r.authorNotFoundErr)
 			}
 
 			if i > 0 {
@@ -244,14 +421,17 @@ func (r *PostRepository) CreatePosts(posts *models.Posts, args *CreatePostArgs)
 		}
 		query += `;`
 
-		res, err := tx.Exec(query, qArgs...)
-		if err != nil || res.RowsAffected() != int64(n) {
-			panic(err)
+		res, err := tx.exec(query, qArgs...)
+		if err != nil {
+			return wrapError(err)
+		}
+		if res.RowsAffected() != int64(n) {
+			return errs.NewInternalError(PostsNotInsertedErrMessage)
 		}
 
-		_, err = tx.Exec(UpdateForumNumPostsStatement, &args.ThreadForum, &n)
+		_, err = tx.exec(UpdateForumNumPostsStatement, &args.ThreadForum, &n)
 		if err != nil {
-			panic(err)
+			return wrapError(err)
 		}
 
 		query = `INSERT INTO "forum_user"("forum","user") VALUES`
@@ -270,52 +450,59 @@ func (r *PostRepository) CreatePosts(posts *models.Posts, args *CreatePostArgs)
 
 		query += ` ON CONFLICT DO NOTHING;`
 
-		_, err = tx.Exec(query, qArgs...)
-		if err != nil {
-			panic(err)
-		}
-
-		return nil
+		_, err = tx.exec(query, qArgs...)
+		return wrapError(err)
 	})
 }
 
 const (
 	PostAttributes = `
-        p."id",p."parent_id",p."author",
+        p."id",p."parent_id",
+        CASE WHEN p."is_deleted" THEN '' ELSE p."author" END,
         p."forum",p."thread",p."message",
-        p."created_timestamp",p."is_edited"
+        p."created_timestamp",p."is_edited",p."is_deleted",
+        COALESCE(p."split_to",0)
     `
 	ThreadAttributes = `
         th."id",th."slug",th."title", th."forum",th."author",
-        th."created_timestamp", th."message",th."num_votes"
+        th."created_timestamp", th."message",th."num_votes",
+        th."is_archived",th."is_deleted",
+        th."is_closed",th."closed_by",th."closed_at",COALESCE(th."close_reason",''),
+        ` + ThreadIsPinnedCondition + `,th."pinned_until"
     `
-	ForumAttributes = `
+	ThreadIsPinnedCondition = `th."is_pinned" AND (th."pinned_until" IS NULL OR th."pinned_until" > now())`
+	ForumAttributes         = `
         f."slug",f."title",f."admin",f."num_threads",f."num_posts"
     `
-	UserAttributes = `u."nickname",u."fullname",u."email",u."about"`
+	UserAttributes         = `u."nickname",u."fullname",u."email",u."about"`
+	RevisionCountAttribute = `
+        CASE
+            WHEN p."is_deleted" THEN 0
+            ELSE GREATEST((SELECT COUNT(*) FROM "post_revision" r WHERE r."post" = p."id"), 1)
+        END
+    `
 )
 
-func (r *PostRepository) FindPost(post *models.Post) *errs.Error {
-	row := r.conn.conn.QueryRow(SelectPostByIDStatement, &post.ID)
-	if err := r.scanPost(row.Scan, post); err != nil {
-		return r.notFoundErr
-	}
-	return nil
+func (r *PostRepository) FindPost(ctx context.Context, post *models.Post) *errs.Error {
+	row := r.conn.queryRow(ctx, SelectPostByIDStatement, &post.ID)
+	return wrapNotFoundError(r.scanPost(row.Scan, post), r.notFoundErr)
 }
 
-func (r *PostRepository) FindFullPost(post *models.PostFull) *errs.Error {
+func (r *PostRepository) FindFullPost(ctx context.Context, post *models.PostFull) *errs.Error {
 	mapPtr := (*map[string]interface{})(post)
 
 	var fAttr, fJoin string
 	var thAttr, thJoin string
 	var uAttr, uJoin string
+	var revAttr string
 
 	p, _ := (*mapPtr)["post"].(*models.Post)
 	var pID sql.NullInt64
 	dest := []interface{}{
 		&p.ID, &pID, &p.Author,
 		&p.Forum, &p.Thread, &p.Message,
-		&p.CreatedTimestamp, &p.IsEdited,
+		&p.CreatedTimestamp, &p.IsEdited, &p.IsDeleted,
+		&p.SplitTo,
 	}
 
 	if fItf, ok := (*mapPtr)["forum"]; ok {
@@ -335,6 +522,9 @@ func (r *PostRepository) FindFullPost(post *models.PostFull) *errs.Error {
 		dest = append(dest,
 			&th.ID, &th.Slug, &th.Title, &th.Forum, &th.Author,
 			&th.CreatedTimestamp, &th.Message, &th.NumVotes,
+			&th.IsArchived, &th.IsDeleted,
+			&th.IsClosed, &th.ClosedBy, &th.ClosedAt, &th.CloseReason,
+			&th.IsPinned, &th.PinnedUntil,
 		)
 	}
 	if uItf, ok := (*mapPtr)["author"]; ok {
@@ -346,13 +536,17 @@ func (r *PostRepository) FindFullPost(post *models.PostFull) *errs.Error {
 			&u.Nickname, &u.FullName, &u.Email, &u.About,
 		)
 	}
-	query := fmt.Sprintf(`SELECT %s%s%s%s FROM "post" p %s%s%s WHERE p."id" = $1;`,
-		PostAttributes, fAttr, thAttr, uAttr, fJoin, thJoin, uJoin,
+	if revItf, ok := (*mapPtr)["revisions"]; ok {
+		revAttr = `,` + RevisionCountAttribute
+		dest = append(dest, revItf.(*int64))
+	}
+	query := fmt.Sprintf(`SELECT %s%s%s%s%s FROM "post" p %s%s%s WHERE p."id" = $1;`,
+		PostAttributes, fAttr, thAttr, uAttr, revAttr, fJoin, thJoin, uJoin,
 	)
 
-	row := r.conn.conn.QueryRow(query, &p.ID)
+	row := r.conn.queryRow(ctx, query, &p.ID)
 	if err := row.Scan(dest...); err != nil {
-		return r.notFoundErr
+		return wrapNotFoundError(err, r.notFoundErr)
 	}
 
 	if pID.Valid {
@@ -360,6 +554,9 @@ func (r *PostRepository) FindFullPost(post *models.PostFull) *errs.Error {
 	} else {
 		p.ParentID = 0
 	}
+	if p.IsDeleted {
+		delete(*mapPtr, "author")
+	}
 	return nil
 }
 
@@ -370,9 +567,10 @@ type PostsByThreadSearchArgs struct {
 	SortType   string
 	Desc       bool
 	Limit      int
+	MaxDepth   sql.NullInt64
 }
 
-func (r *PostRepository) FindPostsByThread(args *PostsByThreadSearchArgs) (*models.Posts, *errs.Error) {
+func (r *PostRepository) FindPostsByThread(ctx context.Context, args *PostsByThreadSearchArgs) (*models.Posts, *errs.Error) {
 	query := `SELECT ` + PostAttributes + ` FROM "post" p `
 
 	qArgs := make([]interface{}, 0, 1)
@@ -381,7 +579,7 @@ func (r *PostRepository) FindPostsByThread(args *PostsByThreadSearchArgs) (*mode
 	switch args.SortType {
 	case "flat":
 		if !args.ThreadID.Valid {
-			query += `JOIN "thread" th ON th."id" = p."thread" WHERE th."slug" = $1`
+			query += `WHERE p."thread" = thread_id_by_slug($1)`
 			qArgs = append(qArgs, &args.ThreadSlug)
 		} else {
 			query += `WHERE p."thread" = $1`
@@ -418,7 +616,7 @@ func (r *PostRepository) FindPostsByThread(args *PostsByThreadSearchArgs) (*mode
 
 	case "tree":
 		if !args.ThreadID.Valid {
-			query += `JOIN "thread" th ON th."id" = p."thread" WHERE th."slug" = $1`
+			query += `WHERE p."thread" = thread_id_by_slug($1)`
 			qArgs = append(qArgs, &args.ThreadSlug)
 		} else {
 			query += `WHERE p."thread" = $1`
@@ -459,7 +657,7 @@ func (r *PostRepository) FindPostsByThread(args *PostsByThreadSearchArgs) (*mode
         `
 
 		if !args.ThreadID.Valid {
-			query += `JOIN "thread" th ON th."id" = r."thread" WHERE th."slug" = $1`
+			query += `WHERE r."thread" = thread_id_by_slug($1)`
 			qArgs = append(qArgs, &args.ThreadSlug)
 		} else {
 			query += `WHERE r."thread" = $1`
@@ -497,6 +695,11 @@ func (r *PostRepository) FindPostsByThread(args *PostsByThreadSearchArgs) (*mode
 		}
 
 		query += `)`
+		if args.MaxDepth.Valid {
+			qArgsIndex++
+			qArgs = append(qArgs, &args.MaxDepth.Int64)
+			query += fmt.Sprintf(` AND array_length(p."path", 1) <= $%d + 1`, qArgsIndex)
+		}
 		if args.Desc {
 			query += ` ORDER BY p."path_root" DESC, p."path"[2:]`
 		} else {
@@ -505,9 +708,9 @@ func (r *PostRepository) FindPostsByThread(args *PostsByThreadSearchArgs) (*mode
 	}
 	query += `;`
 
-	rows, err := r.conn.conn.Query(query, qArgs...)
+	rows, err := r.conn.query(ctx, query, qArgs...)
 	if err != nil {
-		panic(err)
+		return nil, wrapError(err)
 	}
 	defer rows.Close()
 
@@ -515,22 +718,28 @@ func (r *PostRepository) FindPostsByThread(args *PostsByThreadSearchArgs) (*mode
 	for rows.Next() {
 		var post models.Post
 		if err := r.scanPost(rows.Scan, &post); err != nil {
-			panic(err)
+			return nil, wrapError(err)
 		}
 		posts = append(posts, post)
 	}
+	if err = rows.Err(); err != nil {
+		return nil, wrapError(err)
+	}
 
 	if len(posts) == 0 {
 		var exists bool
-		var row *pgx.Row
+		var row *pooledRow
 
 		if args.ThreadID.Valid {
-			row = r.conn.conn.QueryRow(SelectThreadExistsByIDStatement, &args.ThreadID.Int64)
+			row = r.conn.queryRow(ctx, SelectThreadExistsByIDStatement, &args.ThreadID.Int64)
 		} else {
-			row = r.conn.conn.QueryRow(SelectThreadExistsBySlugStatement, &args.ThreadSlug)
+			row = r.conn.queryRow(ctx, SelectThreadExistsBySlugStatement, &args.ThreadSlug)
+		}
+		if err = row.Scan(&exists); err != nil {
+			return nil, wrapError(err)
 		}
-		if err = row.Scan(&exists); !exists {
-			return nil, r.notFoundErr
+		if !exists {
+			return nil, r.threadNotFoundErr
 		}
 	}
 
@@ -539,22 +748,193 @@ func (r *PostRepository) FindPostsByThread(args *PostsByThreadSearchArgs) (*mode
 
 type ScanFunc func(...interface{}) error
 
-func (r *PostRepository) CheckPostExists(id int64) *errs.Error {
-	var exists bool
-	row := r.conn.conn.QueryRow(SelectPostExistsByIDStatement, &id)
-	if _ = row.Scan(&exists); !exists {
-		return r.notFoundErr
+func (r *PostRepository) FindPostsByAuthor(ctx context.Context, args *AuthorSearchArgs) (*models.Posts, *errs.Error) {
+	qArgs := []interface{}{args.Author}
+	query := `SELECT ` + PostAttributes + ` FROM "post" p
+        JOIN "thread" th ON th."id" = p."thread"
+        WHERE p."author" = $1 AND NOT p."is_deleted" AND NOT th."is_deleted"` + args.keysetClause("p", &qArgs)
+
+	posts := make([]models.Post, 0)
+	if err := r.queryPosts(ctx, &posts, query, qArgs...); err != nil {
+		return nil, err
 	}
-	return nil
+
+	if len(posts) == 0 {
+		var author string
+		row := r.conn.queryRow(ctx, SelectUserNicknameByNicknameStatement, &args.Author)
+		if err := row.Scan(&author); err != nil {
+			return nil, wrapNotFoundError(err, r.userNotFoundErr)
+		}
+	}
+
+	return (*models.Posts)(&posts), nil
 }
 
-func (r *PostRepository) UpdatePost(post *models.Post) *errs.Error {
-	return r.conn.performTxOp(func(tx *pgx.Tx) *errs.Error {
-		row := tx.QueryRow(UpdatePostByIDStatement, &post.ID, &post.Message)
-		if err := r.scanPost(row.Scan, post); err != nil {
-			return r.notFoundErr
+type PostSubtreeArgs struct {
+	ID    int64
+	Depth sql.NullInt64
+}
+
+func (r *PostRepository) FindPostSubtree(ctx context.Context, args *PostSubtreeArgs) (*models.Posts, *errs.Error) {
+	var depth interface{}
+	if args.Depth.Valid {
+		depth = &args.Depth.Int64
+	}
+
+	posts := make([]models.Post, 0)
+	if err := r.queryPosts(ctx, &posts, SelectPostSubtreeStatement, &args.ID, depth); err != nil {
+		return nil, err
+	}
+	if len(posts) == 0 {
+		return nil, r.notFoundErr
+	}
+	return (*models.Posts)(&posts), nil
+}
+
+func (r *PostRepository) FindPostAncestors(ctx context.Context, id int64) (*models.Posts, *errs.Error) {
+	posts := make([]models.Post, 0)
+	if err := r.queryPosts(ctx, &posts, SelectPostAncestorsStatement, &id); err != nil {
+		return nil, err
+	}
+	if len(posts) == 0 {
+		return nil, r.notFoundErr
+	}
+	return (*models.Posts)(&posts), nil
+}
+
+func (r *PostRepository) queryPosts(ctx context.Context, posts *[]models.Post, query string, args ...interface{}) *errs.Error {
+	rows, err := r.conn.query(ctx, query, args...)
+	if err != nil {
+		return wrapError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var post models.Post
+		if err = r.scanPost(rows.Scan, &post); err != nil {
+			return wrapError(err)
 		}
-		return nil
+		*posts = append(*posts, post)
+	}
+	return wrapError(rows.Err())
+}
+
+type UpdatePostArgs struct {
+	Editor    string
+	Timestamp strfmt.DateTime
+}
+
+func (r *PostRepository) UpdatePost(ctx context.Context, post *models.Post, args *UpdatePostArgs) *errs.Error {
+	return r.conn.performTxOp(ctx, pgx.ReadCommitted, func(tx *Tx) *errs.Error {
+		var message, author string
+		var isDeleted bool
+		row := tx.queryRow(SelectPostForUpdateStatement, &post.ID)
+		if err := row.Scan(&message, &author, &isDeleted); err != nil {
+			return wrapNotFoundError(err, r.notFoundErr)
+		}
+		if isDeleted {
+			return r.deletedErr
+		}
+
+		if message == post.Message {
+			row = tx.queryRow(SelectPostByIDStatement, &post.ID)
+			return wrapError(r.scanPost(row.Scan, post))
+		}
+
+		editor := author
+		if args.Editor != consts.EmptyString {
+			row = tx.queryRow(SelectUserNicknameByNicknameStatement, &args.Editor)
+			if err := row.Scan(&editor); err != nil {
+				return wrapNotFoundError(err, r.editorNotFoundErr)
+			}
+		}
+
+		if _, err := tx.exec(InsertOriginalPostRevisionStatement, &post.ID); err != nil {
+			return wrapError(err)
+		}
+		_, err := tx.exec(InsertPostRevisionStatement, &post.ID, &post.Message, &editor, &args.Timestamp)
+		if err != nil {
+			return wrapError(err)
+		}
+
+		row = tx.queryRow(UpdatePostByIDStatement, &post.ID, &post.Message)
+		return wrapError(r.scanPost(row.Scan, post))
+	})
+}
+
+func (r *PostRepository) FindPostRevisions(ctx context.Context, id int64) (*models.PostRevisions, *errs.Error) {
+	rows, err := r.conn.query(ctx, SelectPostRevisionsStatement, &id)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	defer rows.Close()
+
+	revisions := make([]models.PostRevision, 0)
+	for rows.Next() {
+		var rev models.PostRevision
+		if err := rows.Scan(&rev.Revision, &rev.Message, &rev.Editor, &rev.CreatedTimestamp); err != nil {
+			return nil, wrapError(err)
+		}
+		revisions = append(revisions, rev)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, wrapError(err)
+	}
+
+	if len(revisions) == 0 {
+		var isDeleted bool
+		row := r.conn.queryRow(ctx, SelectPostIsDeletedStatement, &id)
+		if err = row.Scan(&isDeleted); err != nil {
+			return nil, wrapNotFoundError(err, r.notFoundErr)
+		}
+		if isDeleted {
+			return nil, r.deletedErr
+		}
+		return nil, r.notFoundErr
+	}
+
+	return (*models.PostRevisions)(&revisions), nil
+}
+
+func (r *PostRepository) DeletePost(ctx context.Context, post *models.Post) *errs.Error {
+	row := r.conn.queryRow(ctx, DeletePostByIDStatement, &post.ID)
+	return wrapNotFoundError(r.scanPost(row.Scan, post), r.notFoundErr)
+}
+
+func (r *PostRepository) PurgePostSubtree(ctx context.Context, deletion *models.PostDeletion, moderator string) *errs.Error {
+	return r.conn.performTxOp(ctx, pgx.ReadCommitted, func(tx *Tx) *errs.Error {
+		var forum string
+		var pathRoot int64
+		var threadDeleted bool
+		row := tx.queryRow(SelectPostForPurgeStatement, &deletion.ID)
+		if err := row.Scan(&forum, &pathRoot, &threadDeleted); err != nil {
+			return wrapNotFoundError(err, r.notFoundErr)
+		}
+
+		var isAdmin bool
+		row = tx.queryRow(SelectForumAdminExistsStatement, &forum, &moderator)
+		if err := row.Scan(&isAdmin); err != nil {
+			return wrapError(err)
+		}
+		if !isAdmin {
+			return r.notModeratorErr
+		}
+
+		var authors []string
+		row = tx.queryRow(DeletePostSubtreeStatement, &deletion.ID, &pathRoot)
+		if err := row.Scan(&deletion.Deleted, &authors); err != nil {
+			return wrapError(err)
+		}
+
+		if !threadDeleted {
+			n := -deletion.Deleted
+			if _, err := tx.exec(UpdateForumNumPostsStatement, &forum, &n); err != nil {
+				return wrapError(err)
+			}
+		}
+
+		_, err := tx.exec(DeleteStaleForumUsersStatement, &forum, &authors)
+		return wrapError(err)
 	})
 }
 
@@ -562,7 +942,8 @@ func (r *PostRepository) scanPost(f ScanFunc, post *models.Post) error {
 	err := f(
 		&post.ID, &post.ParentID, &post.Author,
 		&post.Forum, &post.Thread, &post.Message,
-		&post.CreatedTimestamp, &post.IsEdited,
+		&post.CreatedTimestamp, &post.IsEdited, &post.IsDeleted,
+		&post.SplitTo,
 	)
 	if err != nil {
 		return err
@@ -0,0 +1,152 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+	"tp-project-db/consts"
+	"tp-project-db/errs"
+	"tp-project-db/models"
+)
+
+// ts_headline marks matches with control characters that are stripped from
+// the document beforehand; the text is HTML-escaped before they become <b>
+// tags, so stored markup never reaches the snippet unescaped.
+const (
+	searchMatchStart      = "\x02"
+	searchMatchStop       = "\x03"
+	SearchHeadlineOptions = `StartSel="` + searchMatchStart + `", StopSel="` + searchMatchStop + `", MaxWords=35, MinWords=15, MaxFragments=2`
+)
+
+var searchMatchReplacer = strings.NewReplacer(searchMatchStart, "<b>", searchMatchStop, "</b>")
+
+func searchSnippet(headline string) string {
+	return searchMatchReplacer.Replace(html.EscapeString(headline))
+}
+
+type SearchCursor struct {
+	Rank float32
+	Kind string
+	ID   int64
+}
+
+type SearchArgs struct {
+	Query  string
+	Kind   string
+	Forum  string
+	Thread models.Thread
+	Author string
+	Since  models.NullTimestamp
+	Until  models.NullTimestamp
+	After  *SearchCursor
+	Limit  int
+}
+
+type SearchRepository struct {
+	conn *Connection
+}
+
+func NewSearchRepository(conn *Connection) *SearchRepository {
+	return &SearchRepository{
+		conn: conn,
+	}
+}
+
+func (r *SearchRepository) Search(ctx context.Context, args *SearchArgs) (*models.SearchResults, *errs.Error) {
+	qArgs := make([]interface{}, 0, 8)
+	param := func(v interface{}) string {
+		qArgs = append(qArgs, v)
+		return fmt.Sprintf("$%d", len(qArgs))
+	}
+
+	q := param(&args.Query)
+	var tsQuery string
+	if args.Forum != consts.EmptyString {
+		tsQuery = fmt.Sprintf(`plainto_tsquery(forum_search_config(%s), %s)`, param(&args.Forum), q)
+	} else {
+		tsQuery = fmt.Sprintf(`(plainto_tsquery('english', %[1]s) || plainto_tsquery('russian', %[1]s))`, q)
+	}
+
+	var filters string
+	if args.Forum != consts.EmptyString {
+		filters += fmt.Sprintf(` AND th."forum" = %s`, param(&args.Forum))
+	}
+	if args.Thread.ID != 0 {
+		filters += fmt.Sprintf(` AND th."id" = %s`, param(&args.Thread.ID))
+	} else if args.Thread.Slug.Valid {
+		filters += fmt.Sprintf(` AND th."id" = thread_id_by_slug(%s)`, param(&args.Thread.Slug.String))
+	}
+	if args.Since.Valid {
+		filters += fmt.Sprintf(` AND %%[1]s."created_timestamp" >= %s`, param(&args.Since.Timestamp))
+	}
+	if args.Until.Valid {
+		filters += fmt.Sprintf(` AND %%[1]s."created_timestamp" < %s`, param(&args.Until.Timestamp))
+	}
+	if args.Author != consts.EmptyString {
+		filters += fmt.Sprintf(` AND %%[1]s."author" = %s`, param(&args.Author))
+	}
+
+	branches := make([]string, 0, 2)
+	if args.Kind != models.SearchKindThread {
+		branches = append(branches, fmt.Sprintf(`
+            SELECT 'post' AS "kind", p."id", p."thread", p."forum", p."author",
+                th."title", p."message" AS "body", p."created_timestamp",
+                ts_rank_cd(p."search_vector", %[2]s) AS "rank"
+            FROM "post" p
+            JOIN "thread" th ON th."id" = p."thread"
+            WHERE p."search_vector" @@ %[2]s AND NOT p."is_deleted" AND NOT th."is_deleted"`+filters,
+			"p", tsQuery,
+		))
+	}
+	if args.Kind != models.SearchKindPost {
+		branches = append(branches, fmt.Sprintf(`
+            SELECT 'thread' AS "kind", th."id", th."id", th."forum", th."author",
+                th."title", th."message" AS "body", th."created_timestamp",
+                ts_rank_cd(th."search_vector", %[2]s) AS "rank"
+            FROM "thread" th
+            WHERE th."search_vector" @@ %[2]s AND NOT th."is_deleted"`+filters,
+			"th", tsQuery,
+		))
+	}
+
+	markers, options := param(searchMatchStart+searchMatchStop), param(SearchHeadlineOptions)
+	query := `
+        SELECT r."kind", r."id", r."thread", r."forum", r."author", r."title",
+            ts_headline(forum_search_config(r."forum"), translate(r."body", ` + markers + `, ''), ` + tsQuery + `, ` + options + `),
+            r."created_timestamp", r."rank"
+        FROM (` + strings.Join(branches, ` UNION ALL `) + `) r`
+	if args.After != nil {
+		query += fmt.Sprintf(` WHERE (r."rank", r."kind", r."id") < (%s::REAL, %s::TEXT, %s::BIGINT)`,
+			param(&args.After.Rank), param(&args.After.Kind), param(&args.After.ID),
+		)
+	}
+	query += ` ORDER BY r."rank" DESC, r."kind" DESC, r."id" DESC`
+	query += fmt.Sprintf(` LIMIT %s;`, param(&args.Limit))
+
+	rows, err := r.conn.query(ctx, query, qArgs...)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	defer rows.Close()
+
+	results := models.SearchResults{
+		Results: make([]models.SearchResult, 0),
+	}
+	for rows.Next() {
+		var res models.SearchResult
+		err = rows.Scan(
+			&res.Kind, &res.ID, &res.Thread, &res.Forum, &res.Author, &res.Title,
+			&res.Snippet, &res.CreatedTimestamp, &res.Rank,
+		)
+		if err != nil {
+			return nil, wrapError(err)
+		}
+		res.Snippet = searchSnippet(res.Snippet)
+		results.Results = append(results.Results, res)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, wrapError(err)
+	}
+	return &results, nil
+}
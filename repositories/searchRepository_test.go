@@ -0,0 +1,19 @@
+package repositories
+
+import "testing"
+
+func TestSearchSnippet(t *testing.T) {
+	tests := []struct {
+		headline string
+		want     string
+	}{
+		{"a \x02parrot\x03 talks", "a <b>parrot</b> talks"},
+		{"<img src=x onerror=\"\x02alert\x03(1)\">", `&lt;img src=x onerror=&#34;<b>alert</b>(1)&#34;&gt;`},
+		{"<b>fake</b> & \x02real\x03", "&lt;b&gt;fake&lt;/b&gt; &amp; <b>real</b>"},
+	}
+	for _, tt := range tests {
+		if got := searchSnippet(tt.headline); got != tt.want {
+			t.Errorf("searchSnippet(%q) = %q, want %q", tt.headline, got, tt.want)
+		}
+	}
+}
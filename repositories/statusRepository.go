@@ -1,6 +1,8 @@
 package repositories
 
 import (
+	"context"
+	"tp-project-db/errs"
 	"tp-project-db/models"
 )
 
@@ -22,11 +24,12 @@ func NewStatusRepository(conn *Connection) *StatusRepository {
 func (r *StatusRepository) Init() error {
 	err := r.conn.prepareStmt(SelectStatus, `
         SELECT
-            (SELECT COUNT(*) FROM "user" u) AS "num_users",
-            0 AS "num_forums",
-            0 AS "num_threads",
-            0::BIGINT AS "num_posts";
-	`)
+            (SELECT COALESCE(SUM(s."num_users"), 0) FROM "status" s)::INTEGER AS "num_users",
+            COUNT(*)::INTEGER AS "num_forums",
+            COALESCE(SUM(f."num_threads"), 0)::INTEGER AS "num_threads",
+            COALESCE(SUM(f."num_posts"), 0)::BIGINT AS "num_posts"
+        FROM "forum" f;
+    `)
 	if err != nil {
 		return err
 	}
@@ -41,19 +44,16 @@ func (r *StatusRepository) Init() error {
 	return nil
 }
 
-func (r *StatusRepository) GetStatus(status *models.Status) {
-	row := r.conn.conn.QueryRow(SelectStatus)
+func (r *StatusRepository) GetStatus(ctx context.Context, status *models.Status) *errs.Error {
+	row := r.conn.queryRow(ctx, SelectStatus)
 	err := row.Scan(
 		&status.NumUsers, &status.NumForums,
 		&status.NumThreads, &status.NumPosts,
 	)
-	if err != nil {
-		panic(err)
-	}
+	return wrapError(err)
 }
 
-func (r *StatusRepository) ClearDatabase() {
-	if _, err := r.conn.conn.Exec(ClearDatabase); err != nil {
-		panic(err)
-	}
+func (r *StatusRepository) ClearDatabase(ctx context.Context) *errs.Error {
+	_, err := r.conn.exec(ctx, ClearDatabase)
+	return wrapError(err)
 }
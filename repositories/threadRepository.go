@@ -1,9 +1,11 @@
 package repositories
 
 import (
+	"context"
 	"database/sql"
 	"database/sql/driver"
 	"fmt"
+	"github.com/go-openapi/strfmt"
 	"github.com/jackc/pgx"
 	"net/http"
 	"tp-project-db/errs"
@@ -11,115 +13,75 @@ import (
 )
 
 const (
-	ThreadNotFoundErrMessage           = "thread not found"
-	ThreadAuthorNotFoundErrMessage     = "thread author not found"
-	ThreadForumNotFoundErrMessage      = "thread forum not found"
-	ThreadAttributeDuplicateErrMessage = "thread attribute duplicate"
+	ThreadNotFoundErrMessage            = "thread not found"
+	ThreadAuthorNotFoundErrMessage      = "thread author not found"
+	ThreadForumNotFoundErrMessage       = "thread forum not found"
+	ThreadAttributeDuplicateErrMessage  = "thread attribute duplicate"
+	ThreadDeletedErrMessage             = "thread is deleted"
+	ThreadArchivedErrMessage            = "thread is archived"
+	ThreadNotModeratorErrMessage        = "only the forum admin can moderate threads"
+	ThreadClosedErrMessage              = "thread is closed"
+	ThreadTargetForumNotFoundErrMessage = "target forum not found"
+	ThreadTargetNotFoundErrMessage      = "target thread not found"
+	ThreadMergeSelfErrMessage           = "cannot merge a thread into itself"
+	ThreadForumMismatchErrMessage       = "threads belong to different forums"
 )
 
 const (
-	CreateThreadTableQuery = `
-	    CREATE TABLE IF NOT EXISTS "thread" (
-            "id" SERIAL
-                CONSTRAINT "thread_id_pk" PRIMARY KEY,
-            "slug" CITEXT
-                CONSTRAINT "thread_slug_nullable" NULL,
-            "title" TEXT
-                CONSTRAINT "thread_title_not_null" NOT NULL,
-            "forum" CITEXT
-                CONSTRAINT "thread_forum_not_null" NOT NULL
-                CONSTRAINT "thread_forum_fk" REFERENCES "forum"("slug"),
-            "author" CITEXT
-                CONSTRAINT "thread_author_not_null" NOT NULL
-                CONSTRAINT "thread_author_fk" REFERENCES "user"("nickname"),
-            "created_timestamp" TIMESTAMPTZ
-                CONSTRAINT "thread_created_timestamp_nullable" NULL,
-            "message" TEXT
-                CONSTRAINT "thread_message_not_null" NOT NULL,
-            "num_votes" INTEGER
-                DEFAULT(0)
-                CONSTRAINT "thread_num_votes_not_null" NOT NULL
-        );
-
-        CREATE INDEX IF NOT EXISTS "thread_forum_idx" ON "thread"("forum");
-        CREATE INDEX IF NOT EXISTS "thread_author_idx" ON "thread"("author");
-        CREATE UNIQUE INDEX IF NOT EXISTS "thread_slug_idx" ON "thread"("slug");
-
-        CREATE OR REPLACE FUNCTION insert_thread(
-            _slug_ CITEXT, _title_ TEXT, _forum_ CITEXT, _author_ CITEXT,
-            _created_timestamp_ TIMESTAMPTZ, _message_ TEXT
-        )
-        RETURNS "query_result"
-        AS $$
-        DECLARE _forum_slug_ CITEXT;
-        DECLARE _author_nickname_ CITEXT;
-        DECLARE _existing_ JSON;
-        BEGIN
-            SELECT u."nickname"
-            FROM "user" u
-            WHERE u."nickname" = _author_
-            INTO _author_nickname_;
-
-            IF _author_nickname_ IS NULL THEN
-                RETURN (404, _existing_);
-            END IF;
-
-            SELECT f."slug"
-            FROM "forum" f
-            WHERE f."slug" = _forum_
-            INTO _forum_slug_;
-
-            IF _forum_slug_ IS NULL THEN
-                 RETURN (404, _existing_);
-            END IF;
-
-            SELECT json_build_object(
-                'id', th."id", 'slug', th."slug",
-                'title', th."title", 'forum', th."forum",
-                'author', th."author",
-                'created', th."created_timestamp",
-                'message', th."message", 'votes', th."num_votes"
-            )
-            FROM "thread" th
-            WHERE th."slug" = _slug_
-            INTO _existing_;
-
-            IF _existing_ IS NOT NULL THEN
-                RETURN (409, _existing_);
-            END IF;
-
-            INSERT INTO "thread"("slug","title","forum","author","created_timestamp","message")
-            VALUES(_slug_,_title_,_forum_slug_,_author_nickname_,_created_timestamp_, _message_)
-            RETURNING json_build_object(
-                'id', "id", 'slug', "slug",
-                'title', "title", 'forum', "forum",
-                'author', "author",
-                'created', "created_timestamp",
-                'message', "message", 'votes', "num_votes"
-            ) INTO _existing_;
-
-            UPDATE "forum" SET
-                "num_threads" = "num_threads" + 1
-            WHERE "slug" = _forum_slug_;
-
-            INSERT INTO "forum_user"("forum","user")
-            VALUES(_forum_slug_,_author_nickname_)
-            ON CONFLICT DO NOTHING;
-
-            RETURN (201, _existing_);
-        END;
-        $$ LANGUAGE PLPGSQL;
-    `
-
-	InsertThreadStatement                 = "insert_thread_statement"
-	SelectThreadExistsByIDStatement       = "select_thread_exists_by_id_statement"
-	SelectThreadExistsBySlugStatement     = "select_thread_exists_by_slug_statement"
-	SelectThreadByIDStatement             = "select_thread_by_id_statement"
-	SelectThreadBySlugStatement           = "select_thread_by_slug_statement"
-	SelectThreadForumByIDStatement        = "select_thread_forum_by_id_statement"
-	SelectThreadIDAndForumBySlugStatement = "select_thread_id_and_forum_by_slug_statement"
-	UpdateThreadByIDStatement             = "update_thread_by_id_statement"
-	UpdateThreadBySlugStatement           = "update_thread_by_slug_statement"
+	ThreadNotFoundErrCode            = "thread_not_found"
+	ThreadAuthorNotFoundErrCode      = "thread_author_not_found"
+	ThreadForumNotFoundErrCode       = "thread_forum_not_found"
+	ThreadAttributeDuplicateErrCode  = "thread_attribute_duplicate"
+	ThreadDeletedErrCode             = "thread_deleted"
+	ThreadArchivedErrCode            = "thread_archived"
+	ThreadNotModeratorErrCode        = "not_moderator"
+	ThreadClosedErrCode              = "thread_closed"
+	ThreadTargetForumNotFoundErrCode = "thread_target_forum_not_found"
+	ThreadTargetNotFoundErrCode      = "thread_target_not_found"
+	ThreadMergeSelfErrCode           = "thread_merge_self"
+	ThreadForumMismatchErrCode       = "thread_forum_mismatch"
+)
+
+const (
+	InsertThreadStatement                  = "insert_thread_statement"
+	SelectThreadExistsByIDStatement        = "select_thread_exists_by_id_statement"
+	SelectThreadExistsBySlugStatement      = "select_thread_exists_by_slug_statement"
+	SelectThreadByIDStatement              = "select_thread_by_id_statement"
+	SelectThreadBySlugStatement            = "select_thread_by_slug_statement"
+	SelectThreadForumByIDStatement         = "select_thread_forum_by_id_statement"
+	SelectThreadIDAndForumBySlugStatement  = "select_thread_id_and_forum_by_slug_statement"
+	UpdateThreadByIDStatement              = "update_thread_by_id_statement"
+	UpdateThreadBySlugStatement            = "update_thread_by_slug_statement"
+	SelectThreadIsDeletedByIDStatement     = "select_thread_is_deleted_by_id_statement"
+	SelectThreadIsDeletedBySlugStatement   = "select_thread_is_deleted_by_slug_statement"
+	SelectThreadForUpdateByIDStatement     = "select_thread_for_update_by_id_statement"
+	SelectThreadForUpdateBySlugStatement   = "select_thread_for_update_by_slug_statement"
+	SelectThreadNumPostsStatement          = "select_thread_num_posts_statement"
+	UpdateForumCountersStatement           = "update_forum_counters_statement"
+	UpdateThreadIsDeletedStatement         = "update_thread_is_deleted_statement"
+	UpdateThreadIsArchivedStatement        = "update_thread_is_archived_statement"
+	DeleteThreadVotesStatement             = "delete_thread_votes_statement"
+	DeleteThreadPostsStatement             = "delete_thread_posts_statement"
+	DeleteThreadStatement                  = "delete_thread_statement"
+	SelectThreadCloseReasonByIDStatement   = "select_thread_close_reason_by_id_statement"
+	SelectThreadCloseReasonBySlugStatement = "select_thread_close_reason_by_slug_statement"
+	UpdateThreadClosedStatement            = "update_thread_closed_statement"
+	UpdateThreadPinnedStatement            = "update_thread_pinned_statement"
+	SelectForumSlugBySlugStatement         = "select_forum_slug_by_slug_statement"
+	UpdateThreadPostsForumStatement        = "update_thread_posts_forum_statement"
+	UpdateThreadForumStatement             = "update_thread_forum_statement"
+	InsertForumUsersStatement              = "insert_forum_users_statement"
+	SelectPostForSplitStatement            = "select_post_for_split_statement"
+	InsertSplitThreadStatement             = "insert_split_thread_statement"
+	UpdateSplitPostsStatement              = "update_split_posts_statement"
+	UpdatePostSplitToStatement             = "update_post_split_to_statement"
+	SelectPostPathInThreadStatement        = "select_post_path_in_thread_statement"
+	UpdateMergedPostsStatement             = "update_merged_posts_statement"
+	InsertMergedVotesStatement             = "insert_merged_votes_statement"
+	UpdateThreadNumVotesStatement          = "update_thread_num_votes_statement"
+	UpdateThreadRedirectsStatement         = "update_thread_redirects_statement"
+	UpdatePostsSplitToStatement            = "update_posts_split_to_statement"
+	InsertThreadRedirectStatement          = "insert_thread_redirect_statement"
 )
 
 type ThreadRepository struct {
@@ -128,25 +90,62 @@ type ThreadRepository struct {
 	authorNotFoundErr *errs.Error
 	forumNotFoundErr  *errs.Error
 	conflictErr       *errs.Error
+	deletedErr        *errs.Error
+	archivedErr       *errs.Error
+	notModeratorErr   *errs.Error
+	targetNotFoundErr *errs.Error
+	postNotFoundErr   *errs.Error
+	postDeletedErr    *errs.Error
+	parentNotFoundErr *errs.Error
+	mergeTargetErr    *errs.Error
+	mergeSelfErr      *errs.Error
+	forumMismatchErr  *errs.Error
+	userNotFoundErr   *errs.Error
 }
 
 func NewThreadRepository(conn *Connection) *ThreadRepository {
 	return &ThreadRepository{
-		conn:              conn,
-		notFoundErr:       errs.NewNotFoundError(ThreadNotFoundErrMessage),
-		conflictErr:       errs.NewConflictError(ThreadAttributeDuplicateErrMessage),
-		authorNotFoundErr: errs.NewNotFoundError(ThreadAuthorNotFoundErrMessage),
-		forumNotFoundErr:  errs.NewNotFoundError(ThreadForumNotFoundErrMessage),
+		conn: conn,
+		notFoundErr: errs.NewNotFoundError(ThreadNotFoundErrMessage).
+			WithCode(ThreadNotFoundErrCode).WithEntity("thread", "slug_or_id"),
+		conflictErr: errs.NewConflictError(ThreadAttributeDuplicateErrMessage).
+			WithCode(ThreadAttributeDuplicateErrCode).WithEntity("thread", "slug"),
+		authorNotFoundErr: errs.NewNotFoundError(ThreadAuthorNotFoundErrMessage).
+			WithCode(ThreadAuthorNotFoundErrCode).WithEntity("user", "author"),
+		forumNotFoundErr: errs.NewNotFoundError(ThreadForumNotFoundErrMessage).
+			WithCode(ThreadForumNotFoundErrCode).WithEntity("forum", "forum"),
+		deletedErr: errs.NewConflictError(ThreadDeletedErrMessage).
+			WithCode(ThreadDeletedErrCode).WithEntity("thread", "slug_or_id"),
+		archivedErr: errs.NewConflictError(ThreadArchivedErrMessage).
+			WithCode(ThreadArchivedErrCode).WithEntity("thread", "slug_or_id"),
+		notModeratorErr: errs.NewForbiddenError(ThreadNotModeratorErrMessage).
+			WithCode(ThreadNotModeratorErrCode).WithEntity("user", "moderator"),
+		targetNotFoundErr: errs.NewNotFoundError(ThreadTargetForumNotFoundErrMessage).
+			WithCode(ThreadTargetForumNotFoundErrCode).WithEntity("forum", "forum"),
+		postNotFoundErr: errs.NewNotFoundError(PostNotFoundErrMessage).
+			WithCode(PostNotFoundErrCode).WithEntity("post", "id"),
+		postDeletedErr: errs.NewConflictError(PostDeletedErrMessage).
+			WithCode(PostDeletedErrCode).WithEntity("post", "id"),
+		parentNotFoundErr: errs.NewConflictError(PostParentNotFoundErrMessage).
+			WithCode(PostParentNotFoundErrCode).WithEntity("post", "parent"),
+		mergeTargetErr: errs.NewNotFoundError(ThreadTargetNotFoundErrMessage).
+			WithCode(ThreadTargetNotFoundErrCode).WithEntity("thread", "target"),
+		mergeSelfErr: errs.NewBadRequestError(ThreadMergeSelfErrMessage).
+			WithCode(ThreadMergeSelfErrCode).WithEntity("thread", "target"),
+		forumMismatchErr: errs.NewConflictError(ThreadForumMismatchErrMessage).
+			WithCode(ThreadForumMismatchErrCode).WithEntity("thread", "target"),
+		userNotFoundErr: errs.NewNotFoundError(UserNotFoundErrMessage).
+			WithCode(UserNotFoundErrCode).WithEntity("user", "nickname"),
 	}
 }
 
-func (r *ThreadRepository) Init() error {
-	err := r.conn.execInit(CreateThreadTableQuery)
-	if err != nil {
-		return err
-	}
+func NewThreadClosedError(reason string) *errs.Error {
+	return errs.NewForbiddenError(ThreadClosedErrMessage).
+		WithCode(ThreadClosedErrCode).WithEntity("thread", "slug_or_id").WithReason(reason)
+}
 
-	err = r.conn.prepareStmt(InsertThreadStatement, `
+func (r *ThreadRepository) Init() error {
+	err := r.conn.prepareStmt(InsertThreadStatement, `
         SELECT * FROM insert_thread($1,$2,$3,$4,$5,$6);
     `)
 	if err != nil {
@@ -161,20 +160,14 @@ func (r *ThreadRepository) Init() error {
 	}
 
 	err = r.conn.prepareStmt(SelectThreadExistsBySlugStatement, `
-        SELECT EXISTS(SELECT * FROM "thread" WHERE "slug" = $1);
+        SELECT thread_id_by_slug($1) IS NOT NULL;
     `)
 	if err != nil {
 		return err
 	}
 
 	err = r.conn.prepareStmt(SelectThreadByIDStatement, `
-        SELECT json_build_object(
-            'id', "id", 'slug', "slug",
-            'title', "title", 'forum', "forum",
-            'author', "author",
-            'created', "created_timestamp",
-            'message', "message", 'votes', "num_votes"
-        )
+        SELECT thread_json(th)
         FROM "thread" th
         WHERE th."id" = $1;
     `)
@@ -183,22 +176,17 @@ func (r *ThreadRepository) Init() error {
 	}
 
 	err = r.conn.prepareStmt(SelectThreadBySlugStatement, `
-        SELECT json_build_object(
-            'id', "id", 'slug', "slug",
-            'title', "title", 'forum', "forum",
-            'author', "author",
-            'created', "created_timestamp",
-            'message', "message", 'votes', "num_votes"
-        )
+        SELECT thread_json(th)
         FROM "thread" th
-        WHERE th."slug" = $1;
+        WHERE th."id" = thread_id_by_slug($1);
     `)
 	if err != nil {
 		return err
 	}
 
 	err = r.conn.prepareStmt(SelectThreadForumByIDStatement, `
-        SELECT th."forum"
+        SELECT th."forum", th."is_deleted", th."is_archived",
+            th."is_closed", COALESCE(th."close_reason", '')
         FROM "thread" th
         WHERE th."id" = $1;
     `)
@@ -207,39 +195,340 @@ func (r *ThreadRepository) Init() error {
 	}
 
 	err = r.conn.prepareStmt(SelectThreadIDAndForumBySlugStatement, `
-        SELECT th."id", th."forum"
+        SELECT th."id", th."forum", th."is_deleted", th."is_archived",
+            th."is_closed", COALESCE(th."close_reason", '')
         FROM "thread" th
-        WHERE th."slug" = $1;
+        WHERE th."id" = thread_id_by_slug($1);
     `)
 	if err != nil {
 		return err
 	}
 
 	err = r.conn.prepareStmt(UpdateThreadByIDStatement, `
-        UPDATE "thread" SET
+        UPDATE "thread" th SET
             ("title","message") = (
                 replace_if_empty($2,"title"),
                 replace_if_empty($3,"message")
             )
-        WHERE "id" = $1
-        RETURNING
-            "id","slug","title","forum","author",
-            "created_timestamp","message","num_votes";
+        WHERE th."id" = $1
+        RETURNING `+ThreadAttributes+`;
     `)
 	if err != nil {
 		return err
 	}
 
 	err = r.conn.prepareStmt(UpdateThreadBySlugStatement, `
-        UPDATE "thread" SET
+        UPDATE "thread" th SET
             ("title","message") = (
                 replace_if_empty($2,"title"),
                 replace_if_empty($3,"message")
             )
-        WHERE "slug" = $1
-        RETURNING
-            "id","slug","title","forum","author",
-            "created_timestamp","message","num_votes";
+        WHERE th."id" = thread_id_by_slug($1)
+        RETURNING `+ThreadAttributes+`;
+    `)
+	if err != nil {
+		return err
+	}
+
+	err = r.conn.prepareStmt(SelectThreadIsDeletedByIDStatement, `
+        SELECT th."is_deleted" FROM "thread" th WHERE th."id" = $1;
+    `)
+	if err != nil {
+		return err
+	}
+
+	err = r.conn.prepareStmt(SelectThreadIsDeletedBySlugStatement, `
+        SELECT th."is_deleted" FROM "thread" th WHERE th."id" = thread_id_by_slug($1);
+    `)
+	if err != nil {
+		return err
+	}
+
+	err = r.conn.prepareStmt(SelectThreadForUpdateByIDStatement, `
+        SELECT `+ThreadAttributes+`
+        FROM "thread" th
+        WHERE th."id" = $1
+        FOR UPDATE;
+    `)
+	if err != nil {
+		return err
+	}
+
+	err = r.conn.prepareStmt(SelectThreadForUpdateBySlugStatement, `
+        SELECT `+ThreadAttributes+`
+        FROM "thread" th
+        WHERE th."id" = thread_id_by_slug($1)
+        FOR UPDATE;
+    `)
+	if err != nil {
+		return err
+	}
+
+	err = r.conn.prepareStmt(SelectThreadNumPostsStatement, `
+        SELECT COUNT(*) FROM "post" p WHERE p."thread" = $1;
+    `)
+	if err != nil {
+		return err
+	}
+
+	err = r.conn.prepareStmt(UpdateForumCountersStatement, `
+        UPDATE "forum" SET
+            "num_threads" = "num_threads" + $2,
+            "num_posts" = "num_posts" + $3
+        WHERE "slug" = $1;
+    `)
+	if err != nil {
+		return err
+	}
+
+	err = r.conn.prepareStmt(UpdateThreadIsDeletedStatement, `
+        UPDATE "thread" th SET
+            "is_deleted" = $2
+        WHERE th."id" = $1
+        RETURNING `+ThreadAttributes+`;
+    `)
+	if err != nil {
+		return err
+	}
+
+	err = r.conn.prepareStmt(UpdateThreadIsArchivedStatement, `
+        UPDATE "thread" th SET
+            "is_archived" = $2
+        WHERE th."id" = $1
+        RETURNING `+ThreadAttributes+`;
+    `)
+	if err != nil {
+		return err
+	}
+
+	err = r.conn.prepareStmt(DeleteThreadVotesStatement, `
+        DELETE FROM "vote" v WHERE v."thread" = $1;
+    `)
+	if err != nil {
+		return err
+	}
+
+	err = r.conn.prepareStmt(DeleteThreadPostsStatement, `
+        WITH "deleted" AS (
+            DELETE FROM "post" p
+            WHERE p."thread" = $1
+            RETURNING p."author"
+        )
+        SELECT COUNT(*), COALESCE(array_agg(DISTINCT d."author"::TEXT), '{}')
+        FROM "deleted" d;
+    `)
+	if err != nil {
+		return err
+	}
+
+	err = r.conn.prepareStmt(DeleteThreadStatement, `
+        DELETE FROM "thread" th WHERE th."id" = $1;
+    `)
+	if err != nil {
+		return err
+	}
+
+	err = r.conn.prepareStmt(SelectThreadCloseReasonByIDStatement, `
+        SELECT COALESCE(th."close_reason", '') FROM "thread" th WHERE th."id" = $1;
+    `)
+	if err != nil {
+		return err
+	}
+
+	err = r.conn.prepareStmt(SelectThreadCloseReasonBySlugStatement, `
+        SELECT COALESCE(th."close_reason", '') FROM "thread" th WHERE th."id" = thread_id_by_slug($1);
+    `)
+	if err != nil {
+		return err
+	}
+
+	err = r.conn.prepareStmt(UpdateThreadClosedStatement, `
+        UPDATE "thread" th SET
+            "is_closed" = $2::BOOLEAN,
+            "closed_by" = CASE WHEN $2::BOOLEAN THEN (
+                SELECT u."nickname" FROM "user" u WHERE u."nickname" = $5
+            ) END,
+            "closed_at" = CASE WHEN $2::BOOLEAN THEN $3::TIMESTAMPTZ END,
+            "close_reason" = CASE WHEN $2::BOOLEAN THEN $4::TEXT END
+        WHERE th."id" = $1
+        RETURNING `+ThreadAttributes+`;
+    `)
+	if err != nil {
+		return err
+	}
+
+	err = r.conn.prepareStmt(UpdateThreadPinnedStatement, `
+        UPDATE "thread" th SET
+            "is_pinned" = $2::BOOLEAN,
+            "pinned_until" = CASE WHEN $2::BOOLEAN THEN $3::TIMESTAMPTZ END
+        WHERE th."id" = $1
+        RETURNING `+ThreadAttributes+`;
+    `)
+	if err != nil {
+		return err
+	}
+
+	err = r.conn.prepareStmt(SelectForumSlugBySlugStatement, `
+        SELECT f."slug" FROM "forum" f WHERE f."slug" = $1;
+    `)
+	if err != nil {
+		return err
+	}
+
+	err = r.conn.prepareStmt(UpdateThreadPostsForumStatement, `
+        WITH "moved" AS (
+            UPDATE "post" p SET
+                "forum" = $2
+            WHERE p."thread" = $1
+            RETURNING p."author"
+        )
+        SELECT COUNT(*), COALESCE(array_agg(DISTINCT m."author"::TEXT), '{}')
+        FROM "moved" m;
+    `)
+	if err != nil {
+		return err
+	}
+
+	err = r.conn.prepareStmt(UpdateThreadForumStatement, `
+        UPDATE "thread" th SET
+            "forum" = $2
+        WHERE th."id" = $1
+        RETURNING `+ThreadAttributes+`;
+    `)
+	if err != nil {
+		return err
+	}
+
+	err = r.conn.prepareStmt(InsertForumUsersStatement, `
+        INSERT INTO "forum_user"("forum","user")
+        SELECT $1, u."user" FROM unnest($2::CITEXT[]) u("user")
+        ON CONFLICT DO NOTHING;
+    `)
+	if err != nil {
+		return err
+	}
+
+	err = r.conn.prepareStmt(SelectPostForSplitStatement, `
+        SELECT p."thread", p."forum", p."author", p."message", p."created_timestamp",
+            p."path", p."path_root", p."is_deleted", th."is_deleted"
+        FROM "post" p
+        JOIN "thread" th ON th."id" = p."thread"
+        WHERE p."id" = $1
+        FOR UPDATE OF p, th;
+    `)
+	if err != nil {
+		return err
+	}
+
+	err = r.conn.prepareStmt(InsertSplitThreadStatement, `
+        INSERT INTO "thread" AS th ("slug","title","forum","author","created_timestamp","message")
+        SELECT $1,$2,$3,$4,$5,$6
+        WHERE NOT EXISTS(SELECT * FROM "thread_redirect" tr WHERE tr."slug" = $1)
+        ON CONFLICT DO NOTHING
+        RETURNING `+ThreadAttributes+`;
+    `)
+	if err != nil {
+		return err
+	}
+
+	err = r.conn.prepareStmt(UpdateSplitPostsStatement, `
+        UPDATE "post" p SET
+            "thread" = $3,
+            "parent_id" = CASE WHEN p."parent_id" = $1 THEN 0 ELSE p."parent_id" END,
+            "path" = p."path"[$4::INTEGER + 1 : array_length(p."path", 1)],
+            "path_root" = p."path"[$4::INTEGER + 1]
+        WHERE p."path_root" = $2 AND p."path" @> ARRAY[$1::BIGINT] AND p."id" <> $1;
+    `)
+	if err != nil {
+		return err
+	}
+
+	err = r.conn.prepareStmt(UpdatePostSplitToStatement, `
+        WITH "original" AS (
+            INSERT INTO "post_revision"("post","revision","message","editor","created_timestamp")
+            SELECT p."id", 1, p."message", p."author", p."created_timestamp"
+            FROM "post" p
+            WHERE p."id" = $1
+                AND NOT EXISTS(SELECT * FROM "post_revision" r WHERE r."post" = p."id")
+        )
+        UPDATE "post" p SET
+            "message" = '',
+            "is_deleted" = TRUE,
+            "split_to" = $2
+        WHERE p."id" = $1;
+    `)
+	if err != nil {
+		return err
+	}
+
+	err = r.conn.prepareStmt(SelectPostPathInThreadStatement, `
+        SELECT p."path", p."path_root"
+        FROM "post" p
+        WHERE p."id" = $1 AND p."thread" = $2
+        FOR SHARE;
+    `)
+	if err != nil {
+		return err
+	}
+
+	err = r.conn.prepareStmt(UpdateMergedPostsStatement, `
+        UPDATE "post" p SET
+            "thread" = $2,
+            "parent_id" = CASE
+                WHEN $3::BIGINT <> 0 AND COALESCE(p."parent_id", 0) = 0 THEN $3
+                ELSE p."parent_id"
+            END,
+            "path" = $4::BIGINT[] || p."path",
+            "path_root" = CASE WHEN $3::BIGINT <> 0 THEN $5 ELSE p."path_root" END
+        WHERE p."thread" = $1;
+    `)
+	if err != nil {
+		return err
+	}
+
+	err = r.conn.prepareStmt(InsertMergedVotesStatement, `
+        INSERT INTO "vote"("user","thread","voice")
+        SELECT v."user", $2, v."voice"
+        FROM "vote" v
+        WHERE v."thread" = $1
+        ON CONFLICT DO NOTHING;
+    `)
+	if err != nil {
+		return err
+	}
+
+	err = r.conn.prepareStmt(UpdateThreadNumVotesStatement, `
+        UPDATE "thread" th SET
+            "num_votes" = (SELECT COALESCE(SUM(v."voice"), 0) FROM "vote" v WHERE v."thread" = th."id")
+        WHERE th."id" = $1
+        RETURNING `+ThreadAttributes+`;
+    `)
+	if err != nil {
+		return err
+	}
+
+	err = r.conn.prepareStmt(UpdateThreadRedirectsStatement, `
+        UPDATE "thread_redirect" tr SET
+            "thread" = $2
+        WHERE tr."thread" = $1;
+    `)
+	if err != nil {
+		return err
+	}
+
+	err = r.conn.prepareStmt(UpdatePostsSplitToStatement, `
+        UPDATE "post" p SET
+            "split_to" = $2
+        WHERE p."split_to" = $1;
+    `)
+	if err != nil {
+		return err
+	}
+
+	err = r.conn.prepareStmt(InsertThreadRedirectStatement, `
+        INSERT INTO "thread_redirect"("slug","thread")
+        VALUES($1,$2)
+        ON CONFLICT ("slug") DO UPDATE SET "thread" = EXCLUDED."thread";
     `)
 	if err != nil {
 		return err
@@ -248,7 +537,7 @@ func (r *ThreadRepository) Init() error {
 	return nil
 }
 
-func (r *ThreadRepository) CreateThread(thread *models.Thread, existing *sql.NullString) int {
+func (r *ThreadRepository) CreateThread(ctx context.Context, thread *models.Thread, existing *sql.NullString) (int, *errs.Error) {
 	var status int
 
 	var slug driver.Value
@@ -265,21 +554,29 @@ func (r *ThreadRepository) CreateThread(thread *models.Thread, existing *sql.Nul
 		createdTimestamp = nil
 	}
 
-	row := r.conn.conn.QueryRow(InsertThreadStatement,
+	row := r.conn.queryRow(ctx, InsertThreadStatement,
 		slug, &thread.Title, &thread.Forum, &thread.Author,
 		createdTimestamp, &thread.Message,
 	)
 	if err := row.Scan(&status, existing); err != nil {
-		panic(err)
+		return 0, wrapError(err)
+	}
+	if status == http.StatusNotFound {
+		var author string
+		row = r.conn.queryRow(ctx, SelectUserNicknameByNicknameStatement, &thread.Author)
+		if err := row.Scan(&author); err != nil {
+			return status, wrapNotFoundError(err, r.authorNotFoundErr)
+		}
+		return status, r.forumNotFoundErr
 	}
 
-	return status
+	return status, nil
 }
 
-func (r *ThreadRepository) FindThreadByID(id int32, existing *string) int {
-	rows, err := r.conn.conn.Query(SelectThreadByIDStatement, &id)
+func (r *ThreadRepository) FindThreadByID(ctx context.Context, id int32, existing *string) *errs.Error {
+	rows, err := r.conn.query(ctx, SelectThreadByIDStatement, &id)
 	if err != nil {
-		panic(err)
+		return wrapError(err)
 	}
 	defer rows.Close()
 
@@ -288,21 +585,24 @@ func (r *ThreadRepository) FindThreadByID(id int32, existing *string) int {
 		found = true
 		err = rows.Scan(existing)
 		if err != nil {
-			panic(err)
+			return wrapError(err)
 		}
 	}
+	if err = rows.Err(); err != nil {
+		return wrapError(err)
+	}
 
 	if !found {
-		return http.StatusNotFound
+		return r.notFoundErr
 	}
 
-	return http.StatusOK
+	return nil
 }
 
-func (r *ThreadRepository) FindThreadBySlug(slug *string, existing *string) int {
-	rows, err := r.conn.conn.Query(SelectThreadBySlugStatement, &slug)
+func (r *ThreadRepository) FindThreadBySlug(ctx context.Context, slug *string, existing *string) *errs.Error {
+	rows, err := r.conn.query(ctx, SelectThreadBySlugStatement, &slug)
 	if err != nil {
-		panic(err)
+		return wrapError(err)
 	}
 	defer rows.Close()
 
@@ -311,45 +611,92 @@ func (r *ThreadRepository) FindThreadBySlug(slug *string, existing *string) int
 		found = true
 		err = rows.Scan(existing)
 		if err != nil {
-			panic(err)
+			return wrapError(err)
 		}
 	}
+	if err = rows.Err(); err != nil {
+		return wrapError(err)
+	}
 
 	if !found {
-		return http.StatusNotFound
+		return r.notFoundErr
 	}
 
-	return http.StatusOK
+	return nil
 }
 
-func (r *ThreadRepository) FindThreadForumByID(args *CreatePostArgs) *errs.Error {
-	row := r.conn.conn.QueryRow(SelectThreadForumByIDStatement, &args.ThreadID)
-	if row.Scan(&args.ThreadForum) != nil {
-		return r.notFoundErr
+func (r *ThreadRepository) FindThreadForumByID(ctx context.Context, args *CreatePostArgs) *errs.Error {
+	var th models.Thread
+	row := r.conn.queryRow(ctx, SelectThreadForumByIDStatement, &args.ThreadID)
+	err := row.Scan(&args.ThreadForum, &th.IsDeleted, &th.IsArchived, &th.IsClosed, &th.CloseReason)
+	if err != nil {
+		return wrapNotFoundError(err, r.notFoundErr)
 	}
-	return nil
+	return r.checkWritable(&th)
 }
 
-func (r *ThreadRepository) FindThreadIDAndForumBySlug(args *CreatePostArgs) *errs.Error {
-	row := r.conn.conn.QueryRow(SelectThreadIDAndForumBySlugStatement, &args.ThreadSlug)
-	if row.Scan(&args.ThreadID, &args.ThreadForum) != nil {
-		return r.notFoundErr
+func (r *ThreadRepository) FindThreadIDAndForumBySlug(ctx context.Context, args *CreatePostArgs) *errs.Error {
+	var th models.Thread
+	row := r.conn.queryRow(ctx, SelectThreadIDAndForumBySlugStatement, &args.ThreadSlug)
+	err := row.Scan(&args.ThreadID, &args.ThreadForum, &th.IsDeleted, &th.IsArchived, &th.IsClosed, &th.CloseReason)
+	if err != nil {
+		return wrapNotFoundError(err, r.notFoundErr)
+	}
+	return r.checkWritable(&th)
+}
+
+func (r *ThreadRepository) checkWritable(th *models.Thread) *errs.Error {
+	if th.IsDeleted {
+		return r.deletedErr
+	}
+	if th.IsArchived {
+		return r.archivedErr
+	}
+	if th.IsClosed {
+		return NewThreadClosedError(th.CloseReason)
 	}
 	return nil
 }
 
 type ForumThreadsSearchArgs struct {
-	Forum string
-	Since models.NullTimestamp
-	Desc  bool
-	Limit int
+	Forum         string
+	Since         models.NullTimestamp
+	Desc          bool
+	Limit         int
+	ExcludePinned bool
 }
 
-func (r *ThreadRepository) FindThreadsByForum(args *ForumThreadsSearchArgs) (*models.Threads, *errs.Error) {
+func (r *ThreadRepository) FindThreadsByForum(ctx context.Context, args *ForumThreadsSearchArgs) (*models.Threads, *errs.Error) {
+	var sortOrd string
+	if args.Desc {
+		sortOrd = ` DESC`
+	} else {
+		sortOrd = ` ASC`
+	}
+
+	threads := make([]models.Thread, 0)
+	if !args.ExcludePinned {
+		pinnedArgs := []interface{}{args.Forum}
+		query := `SELECT ` + ThreadAttributes + ` FROM "thread" th
+            WHERE th."forum" = $1 AND NOT th."is_deleted" AND ` + ThreadIsPinnedCondition + `
+            ORDER BY th."created_timestamp"` + sortOrd
+		if args.Limit != 0 {
+			pinnedArgs = append(pinnedArgs, args.Limit)
+			query += ` LIMIT $2`
+		}
+		if err := r.queryThreads(ctx, &threads, query+`;`, pinnedArgs...); err != nil {
+			return nil, err
+		}
+		if args.Limit != 0 && len(threads) == args.Limit {
+			return (*models.Threads)(&threads), nil
+		}
+	}
+
 	queryArgs := []interface{}{args.Forum}
 	queryArgsCounter := 1
 
-	query := `SELECT ` + ThreadAttributes + ` FROM "thread" th WHERE th."forum" = $1 `
+	query := `SELECT ` + ThreadAttributes + ` FROM "thread" th
+        WHERE th."forum" = $1 AND NOT th."is_deleted" AND NOT (` + ThreadIsPinnedCondition + `) `
 	if args.Since.Valid {
 		queryArgsCounter++
 		queryArgs = append(queryArgs, args.Since.Timestamp)
@@ -363,73 +710,498 @@ func (r *ThreadRepository) FindThreadsByForum(args *ForumThreadsSearchArgs) (*mo
 
 		query += fmt.Sprintf(`AND th."created_timestamp" %s $%d`, eqOp, queryArgsCounter)
 	}
-	query += ` ORDER BY th."created_timestamp"`
-	if args.Desc {
-		query += ` DESC`
-	} else {
-		query += ` ASC`
-	}
+	query += ` ORDER BY th."created_timestamp"` + sortOrd
 	if args.Limit != 0 {
 		queryArgsCounter++
-		queryArgs = append(queryArgs, args.Limit)
+		queryArgs = append(queryArgs, args.Limit-len(threads))
 		query += fmt.Sprintf(` LIMIT $%d;`, queryArgsCounter)
 	}
 
-	rows, err := r.conn.conn.Query(query, queryArgs...)
-	if err != nil {
-		return nil, r.forumNotFoundErr
+	if err := r.queryThreads(ctx, &threads, query, queryArgs...); err != nil {
+		return nil, err
 	}
-	defer rows.Close()
 
-	threads := make([]models.Thread, 0)
-	for rows.Next() {
-		var thread models.Thread
-		err = r.scanThread(rows.Scan, &thread)
-		if err != nil {
-			panic(err)
+	if len(threads) == 0 {
+		var exists bool
+		row := r.conn.queryRow(ctx, SelectForumExistsBySlugStatement, &args.Forum)
+		if err := row.Scan(&exists); err != nil {
+			return nil, wrapError(err)
+		}
+		if !exists {
+			return nil, r.forumNotFoundErr
 		}
-		threads = append(threads, thread)
+	}
+
+	return (*models.Threads)(&threads), nil
+}
+
+func (r *ThreadRepository) FindThreadsByAuthor(ctx context.Context, args *AuthorSearchArgs) (*models.Threads, *errs.Error) {
+	qArgs := []interface{}{args.Author}
+	query := `SELECT ` + ThreadAttributes + ` FROM "thread" th
+        WHERE th."author" = $1 AND NOT th."is_deleted"` + args.keysetClause("th", &qArgs)
+
+	threads := make([]models.Thread, 0)
+	if err := r.queryThreads(ctx, &threads, query, qArgs...); err != nil {
+		return nil, err
 	}
 
 	if len(threads) == 0 {
-		var exists bool
-		row := r.conn.conn.QueryRow(SelectForumExistsBySlugStatement, &args.Forum)
-		if _ = row.Scan(&exists); !exists {
-			return nil, r.forumNotFoundErr
+		var author string
+		row := r.conn.queryRow(ctx, SelectUserNicknameByNicknameStatement, &args.Author)
+		if err := row.Scan(&author); err != nil {
+			return nil, wrapNotFoundError(err, r.userNotFoundErr)
 		}
 	}
 
 	return (*models.Threads)(&threads), nil
 }
 
-func (r *ThreadRepository) UpdateThreadByID(thread *models.Thread) *errs.Error {
-	return r.conn.performTxOp(func(tx *pgx.Tx) *errs.Error {
-		row := tx.QueryRow(UpdateThreadByIDStatement,
+func (r *ThreadRepository) queryThreads(ctx context.Context, threads *[]models.Thread, query string, args ...interface{}) *errs.Error {
+	rows, err := r.conn.query(ctx, query, args...)
+	if err != nil {
+		return wrapError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var thread models.Thread
+		if err = r.scanThread(rows.Scan, &thread); err != nil {
+			return wrapError(err)
+		}
+		*threads = append(*threads, thread)
+	}
+	return wrapError(rows.Err())
+}
+
+func (r *ThreadRepository) UpdateThreadByID(ctx context.Context, thread *models.Thread) *errs.Error {
+	return r.conn.performTxOp(ctx, pgx.ReadCommitted, func(tx *Tx) *errs.Error {
+		row := tx.queryRow(UpdateThreadByIDStatement,
 			&thread.ID, &thread.Title, &thread.Message,
 		)
+		return wrapNotFoundError(r.scanThread(row.Scan, thread), r.notFoundErr)
+	})
+}
+
+func (r *ThreadRepository) UpdateThreadBySlug(ctx context.Context, thread *models.Thread) *errs.Error {
+	return r.conn.performTxOp(ctx, pgx.ReadCommitted, func(tx *Tx) *errs.Error {
+		row := tx.queryRow(UpdateThreadBySlugStatement,
+			&thread.Slug.String, &thread.Title, &thread.Message,
+		)
+		return wrapNotFoundError(r.scanThread(row.Scan, thread), r.notFoundErr)
+	})
+}
+
+func (r *ThreadRepository) DeleteThread(ctx context.Context, thread *models.Thread, moderator string) *errs.Error {
+	return r.setThreadDeleted(ctx, thread, moderator, true)
+}
+
+func (r *ThreadRepository) RestoreThread(ctx context.Context, thread *models.Thread, moderator string) *errs.Error {
+	return r.setThreadDeleted(ctx, thread, moderator, false)
+}
+
+func (r *ThreadRepository) setThreadDeleted(ctx context.Context, thread *models.Thread, moderator string, deleted bool) *errs.Error {
+	return r.conn.performTxOp(ctx, pgx.ReadCommitted, func(tx *Tx) *errs.Error {
+		if err := r.lockThread(tx, thread); err != nil {
+			return err
+		}
+
+		var isAdmin bool
+		row := tx.queryRow(SelectForumAdminExistsStatement, &thread.Forum, &moderator)
+		if err := row.Scan(&isAdmin); err != nil {
+			return wrapError(err)
+		}
+		if !isAdmin {
+			return r.notModeratorErr
+		}
+		if thread.IsDeleted == deleted {
+			return nil
+		}
+
+		var numPosts int64
+		row = tx.queryRow(SelectThreadNumPostsStatement, &thread.ID)
+		if err := row.Scan(&numPosts); err != nil {
+			return wrapError(err)
+		}
+
+		numThreads := int32(1)
+		if deleted {
+			numThreads, numPosts = -numThreads, -numPosts
+		}
+		_, err := tx.exec(UpdateForumCountersStatement, &thread.Forum, &numThreads, &numPosts)
+		if err != nil {
+			return wrapError(err)
+		}
+
+		row = tx.queryRow(UpdateThreadIsDeletedStatement, &thread.ID, &deleted)
+		return wrapError(r.scanThread(row.Scan, thread))
+	})
+}
+
+func (r *ThreadRepository) ArchiveThread(ctx context.Context, thread *models.Thread, archived bool, moderator string) *errs.Error {
+	return r.conn.performTxOp(ctx, pgx.ReadCommitted, func(tx *Tx) *errs.Error {
+		if err := r.lockThread(tx, thread); err != nil {
+			return err
+		}
+
+		var isAdmin bool
+		row := tx.queryRow(SelectForumAdminExistsStatement, &thread.Forum, &moderator)
+		if err := row.Scan(&isAdmin); err != nil {
+			return wrapError(err)
+		}
+		if !isAdmin {
+			return r.notModeratorErr
+		}
+		if thread.IsArchived == archived {
+			return nil
+		}
+
+		row = tx.queryRow(UpdateThreadIsArchivedStatement, &thread.ID, &archived)
+		return wrapError(r.scanThread(row.Scan, thread))
+	})
+}
+
+type CloseThreadArgs struct {
+	Moderator string
+	Closed    bool
+	Reason    string
+	Timestamp strfmt.DateTime
+}
+
+func (r *ThreadRepository) CloseThread(ctx context.Context, thread *models.Thread, args *CloseThreadArgs) *errs.Error {
+	return r.conn.performTxOp(ctx, pgx.ReadCommitted, func(tx *Tx) *errs.Error {
+		if err := r.lockThread(tx, thread); err != nil {
+			return err
+		}
+
+		var isAdmin bool
+		row := tx.queryRow(SelectForumAdminExistsStatement, &thread.Forum, &args.Moderator)
+		if err := row.Scan(&isAdmin); err != nil {
+			return wrapError(err)
+		}
+		if !isAdmin {
+			return r.notModeratorErr
+		}
+		if thread.IsClosed == args.Closed {
+			return nil
+		}
+
+		row = tx.queryRow(UpdateThreadClosedStatement,
+			&thread.ID, &args.Closed, &args.Timestamp, &args.Reason, &args.Moderator,
+		)
+		return wrapError(r.scanThread(row.Scan, thread))
+	})
+}
+
+type PinThreadArgs struct {
+	Moderator string
+	Pinned    bool
+	Until     models.NullTimestamp
+}
+
+func (r *ThreadRepository) PinThread(ctx context.Context, thread *models.Thread, args *PinThreadArgs) *errs.Error {
+	return r.conn.performTxOp(ctx, pgx.ReadCommitted, func(tx *Tx) *errs.Error {
+		if err := r.lockThread(tx, thread); err != nil {
+			return err
+		}
+
+		var isAdmin bool
+		row := tx.queryRow(SelectForumAdminExistsStatement, &thread.Forum, &args.Moderator)
+		if err := row.Scan(&isAdmin); err != nil {
+			return wrapError(err)
+		}
+		if !isAdmin {
+			return r.notModeratorErr
+		}
+
+		var until interface{}
+		if args.Until.Valid {
+			until = &args.Until.Timestamp
+		}
+		row = tx.queryRow(UpdateThreadPinnedStatement, &thread.ID, &args.Pinned, until)
+		return wrapError(r.scanThread(row.Scan, thread))
+	})
+}
+
+type MoveThreadArgs struct {
+	Moderator string
+	Forum     string
+}
+
+func (r *ThreadRepository) MoveThread(ctx context.Context, thread *models.Thread, args *MoveThreadArgs) *errs.Error {
+	return r.conn.performTxOp(ctx, pgx.ReadCommitted, func(tx *Tx) *errs.Error {
+		if err := r.lockThread(tx, thread); err != nil {
+			return err
+		}
+		source := thread.Forum
+
+		var isAdmin bool
+		row := tx.queryRow(SelectForumAdminExistsStatement, &source, &args.Moderator)
+		if err := row.Scan(&isAdmin); err != nil {
+			return wrapError(err)
+		}
+		if !isAdmin {
+			return r.notModeratorErr
+		}
+
+		var target string
+		row = tx.queryRow(SelectForumSlugBySlugStatement, &args.Forum)
+		if err := row.Scan(&target); err != nil {
+			return wrapNotFoundError(err, r.targetNotFoundErr)
+		}
+		if target == source {
+			return nil
+		}
+
+		var numPosts int64
+		var authors []string
+		row = tx.queryRow(UpdateThreadPostsForumStatement, &thread.ID, &target)
+		if err := row.Scan(&numPosts, &authors); err != nil {
+			return wrapError(err)
+		}
+
+		row = tx.queryRow(UpdateThreadForumStatement, &thread.ID, &target)
 		if err := r.scanThread(row.Scan, thread); err != nil {
-			return r.notFoundErr
+			return wrapError(err)
 		}
-		return nil
+
+		if !thread.IsDeleted {
+			numThreads := int32(1)
+			_, err := tx.exec(UpdateForumCountersStatement, &target, &numThreads, &numPosts)
+			if err != nil {
+				return wrapError(err)
+			}
+
+			numThreads, numPosts = -numThreads, -numPosts
+			_, err = tx.exec(UpdateForumCountersStatement, &source, &numThreads, &numPosts)
+			if err != nil {
+				return wrapError(err)
+			}
+		}
+
+		authors = append(authors, thread.Author)
+		if _, err := tx.exec(InsertForumUsersStatement, &target, &authors); err != nil {
+			return wrapError(err)
+		}
+		_, err := tx.exec(DeleteStaleForumUsersStatement, &source, &authors)
+		return wrapError(err)
 	})
 }
 
-func (r *ThreadRepository) UpdateThreadBySlug(thread *models.Thread) *errs.Error {
-	return r.conn.performTxOp(func(tx *pgx.Tx) *errs.Error {
-		row := tx.QueryRow(UpdateThreadBySlugStatement,
-			&thread.Slug.String, &thread.Title, &thread.Message,
+type SplitThreadArgs struct {
+	Post      int64
+	Moderator string
+}
+
+func (r *ThreadRepository) SplitThread(ctx context.Context, thread *models.Thread, args *SplitThreadArgs) *errs.Error {
+	return r.conn.performTxOp(ctx, pgx.ReadCommitted, func(tx *Tx) *errs.Error {
+		var source int32
+		var path []int64
+		var pathRoot int64
+		var postDeleted, threadDeleted bool
+		row := tx.queryRow(SelectPostForSplitStatement, &args.Post)
+		err := row.Scan(&source, &thread.Forum, &thread.Author, &thread.Message, &thread.CreatedTimestamp,
+			&path, &pathRoot, &postDeleted, &threadDeleted,
+		)
+		if err != nil {
+			return wrapNotFoundError(err, r.postNotFoundErr)
+		}
+
+		var isAdmin bool
+		row = tx.queryRow(SelectForumAdminExistsStatement, &thread.Forum, &args.Moderator)
+		if err := row.Scan(&isAdmin); err != nil {
+			return wrapError(err)
+		}
+		if !isAdmin {
+			return r.notModeratorErr
+		}
+		if postDeleted {
+			return r.postDeletedErr
+		}
+		if threadDeleted {
+			return r.deletedErr
+		}
+
+		var slug driver.Value
+		if thread.Slug.Valid {
+			slug = &thread.Slug.String
+		}
+		row = tx.queryRow(InsertSplitThreadStatement,
+			slug, &thread.Title, &thread.Forum, &thread.Author,
+			&thread.CreatedTimestamp.Timestamp, &thread.Message,
 		)
 		if err := r.scanThread(row.Scan, thread); err != nil {
-			return r.notFoundErr
+			return wrapNotFoundError(err, r.conflictErr)
 		}
-		return nil
+
+		depth := len(path)
+		if _, err := tx.exec(UpdateSplitPostsStatement, &args.Post, &pathRoot, &thread.ID, &depth); err != nil {
+			return wrapError(err)
+		}
+		if _, err := tx.exec(UpdatePostSplitToStatement, &args.Post, &thread.ID); err != nil {
+			return wrapError(err)
+		}
+
+		numThreads, numPosts := int32(1), int64(0)
+		_, err = tx.exec(UpdateForumCountersStatement, &thread.Forum, &numThreads, &numPosts)
+		return wrapError(err)
 	})
 }
 
+type MergeThreadArgs struct {
+	Moderator string
+	Target    models.Thread
+	Parent    int64
+}
+
+func (r *ThreadRepository) MergeThread(ctx context.Context, thread *models.Thread, args *MergeThreadArgs) *errs.Error {
+	return r.conn.performTxOp(ctx, pgx.ReadCommitted, func(tx *Tx) *errs.Error {
+		source := *thread
+		if err := r.lockThread(tx, &source); err != nil {
+			return err
+		}
+		target := args.Target
+		if err := r.lockThread(tx, &target); err != nil {
+			if err == r.notFoundErr {
+				return r.mergeTargetErr
+			}
+			return err
+		}
+		if source.ID == target.ID {
+			return r.mergeSelfErr
+		}
+
+		var isAdmin bool
+		row := tx.queryRow(SelectForumAdminExistsStatement, &source.Forum, &args.Moderator)
+		if err := row.Scan(&isAdmin); err != nil {
+			return wrapError(err)
+		}
+		if !isAdmin {
+			return r.notModeratorErr
+		}
+		if source.Forum != target.Forum {
+			return r.forumMismatchErr
+		}
+		if source.IsDeleted || target.IsDeleted {
+			return r.deletedErr
+		}
+
+		prefix := []int64{}
+		var pathRoot int64
+		if args.Parent != 0 {
+			row = tx.queryRow(SelectPostPathInThreadStatement, &args.Parent, &target.ID)
+			if err := row.Scan(&prefix, &pathRoot); err != nil {
+				return wrapNotFoundError(err, r.parentNotFoundErr)
+			}
+		}
+
+		_, err := tx.exec(UpdateMergedPostsStatement, &source.ID, &target.ID, &args.Parent, &prefix, &pathRoot)
+		if err != nil {
+			return wrapError(err)
+		}
+
+		if _, err := tx.exec(InsertMergedVotesStatement, &source.ID, &target.ID); err != nil {
+			return wrapError(err)
+		}
+		if _, err := tx.exec(DeleteThreadVotesStatement, &source.ID); err != nil {
+			return wrapError(err)
+		}
+
+		if _, err := tx.exec(UpdateThreadRedirectsStatement, &source.ID, &target.ID); err != nil {
+			return wrapError(err)
+		}
+		if _, err := tx.exec(UpdatePostsSplitToStatement, &source.ID, &target.ID); err != nil {
+			return wrapError(err)
+		}
+		if _, err := tx.exec(DeleteThreadStatement, &source.ID); err != nil {
+			return wrapError(err)
+		}
+		if source.Slug.Valid {
+			if _, err := tx.exec(InsertThreadRedirectStatement, &source.Slug.String, &target.ID); err != nil {
+				return wrapError(err)
+			}
+		}
+
+		row = tx.queryRow(UpdateThreadNumVotesStatement, &target.ID)
+		if err := r.scanThread(row.Scan, thread); err != nil {
+			return wrapError(err)
+		}
+
+		numThreads, numPosts := int32(-1), int64(0)
+		if _, err := tx.exec(UpdateForumCountersStatement, &source.Forum, &numThreads, &numPosts); err != nil {
+			return wrapError(err)
+		}
+
+		authors := []string{source.Author}
+		_, err = tx.exec(DeleteStaleForumUsersStatement, &source.Forum, &authors)
+		return wrapError(err)
+	})
+}
+
+func (r *ThreadRepository) PurgeThread(ctx context.Context, deletion *models.ThreadDeletion, moderator string) *errs.Error {
+	return r.conn.performTxOp(ctx, pgx.ReadCommitted, func(tx *Tx) *errs.Error {
+		thread := models.Thread{
+			ID:   deletion.ID,
+			Slug: deletion.Slug,
+		}
+		if err := r.lockThread(tx, &thread); err != nil {
+			return err
+		}
+		deletion.ID, deletion.Slug = thread.ID, thread.Slug
+
+		var isAdmin bool
+		row := tx.queryRow(SelectForumAdminExistsStatement, &thread.Forum, &moderator)
+		if err := row.Scan(&isAdmin); err != nil {
+			return wrapError(err)
+		}
+		if !isAdmin {
+			return r.notModeratorErr
+		}
+
+		if _, err := tx.exec(DeleteThreadVotesStatement, &thread.ID); err != nil {
+			return wrapError(err)
+		}
+
+		var authors []string
+		row = tx.queryRow(DeleteThreadPostsStatement, &thread.ID)
+		if err := row.Scan(&deletion.Posts, &authors); err != nil {
+			return wrapError(err)
+		}
+
+		if _, err := tx.exec(DeleteThreadStatement, &thread.ID); err != nil {
+			return wrapError(err)
+		}
+
+		if !thread.IsDeleted {
+			numThreads, numPosts := int32(-1), -deletion.Posts
+			_, err := tx.exec(UpdateForumCountersStatement, &thread.Forum, &numThreads, &numPosts)
+			if err != nil {
+				return wrapError(err)
+			}
+		}
+
+		authors = append(authors, thread.Author)
+		_, err := tx.exec(DeleteStaleForumUsersStatement, &thread.Forum, &authors)
+		return wrapError(err)
+	})
+}
+
+func (r *ThreadRepository) lockThread(tx *Tx, thread *models.Thread) *errs.Error {
+	var row *pooledRow
+	if thread.ID != 0 {
+		row = tx.queryRow(SelectThreadForUpdateByIDStatement, &thread.ID)
+	} else {
+		row = tx.queryRow(SelectThreadForUpdateBySlugStatement, &thread.Slug.String)
+	}
+	return wrapNotFoundError(r.scanThread(row.Scan, thread), r.notFoundErr)
+}
+
 func (r *ThreadRepository) scanThread(f ScanFunc, thread *models.Thread) error {
 	return f(
 		&thread.ID, &thread.Slug, &thread.Title,
 		&thread.Forum, &thread.Author, &thread.CreatedTimestamp,
 		&thread.Message, &thread.NumVotes,
+		&thread.IsArchived, &thread.IsDeleted,
+		&thread.IsClosed, &thread.ClosedBy, &thread.ClosedAt, &thread.CloseReason,
+		&thread.IsPinned, &thread.PinnedUntil,
 	)
 }
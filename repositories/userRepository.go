@@ -1,8 +1,10 @@
 package repositories
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"net/http"
 	"tp-project-db/consts"
 	"tp-project-db/errs"
 	"tp-project-db/models"
@@ -14,132 +16,78 @@ const (
 )
 
 const (
-	CreateUserTableQuery = `
-	    CREATE TABLE IF NOT EXISTS "user" (
-            "nickname" CITEXT COLLATE "ucs_basic"
-                CONSTRAINT "user_nickname_pk" PRIMARY KEY,
-            "email" CITEXT COLLATE "ucs_basic"
-                CONSTRAINT "user_email_not_null" NOT NULL,
-            "fullname" TEXT
-                CONSTRAINT "user_fullname_not_null" NOT NULL,
-            "about" TEXT
-                CONSTRAINT "user_about_not_null" NOT NULL
-        );
-
-        CREATE UNIQUE INDEX IF NOT EXISTS "user_email_idx" ON "user"("email");
-
-        CREATE OR REPLACE FUNCTION insert_user(
-             _nickname_ CITEXT, _email_ CITEXT, _full_name_ TEXT, _about_ TEXT
-        )
-        RETURNS "query_result"
-        AS $$
-        DECLARE _existing_ JSON;
-        BEGIN
-            SELECT json_agg(json_build_object(
-                'nickname', u."nickname",
-                'email', u."email",
-                'fullname', u."fullname",
-                'about', u."about"
-            ))
-            FROM (
-                SELECT u.*
-                FROM "user" u
-                WHERE u."nickname" = _nickname_
-                UNION
-                SELECT u.*
-                FROM "user" u
-                WHERE u."email" = _email_
-            ) u
-            INTO _existing_;
-
-            IF _existing_ IS NOT NULL THEN
-                RETURN (409, _existing_);
-            END IF;
-
-            INSERT INTO "user"("nickname","email","fullname","about")
-            VALUES(_nickname_,_email_,_full_name_,_about_)
-            RETURNING json_build_object(
-                'nickname', "nickname", 'email', "email",
-                'fullname', "fullname", 'about', "about"
-            ) INTO _existing_;
-
-            RETURN (201, _existing_);
-        END;
-        $$ LANGUAGE PLPGSQL;
-
-        CREATE OR REPLACE FUNCTION update_user(
-             _nickname_ CITEXT, _email_ CITEXT, _full_name_ TEXT, _about_ TEXT
-        )
-        RETURNS "query_result"
-        AS $$
-        DECLARE _existing_ JSON;
-        BEGIN
-            SELECT json_build_object(
-                'nickname', u."nickname",
-                'email', u."email",
-                'fullname', u."fullname",
-                'about', u."about"
-            )
-            FROM (
-                SELECT u.*
-                FROM "user" u
-                WHERE u."email" = _email_
-            ) u
-            INTO _existing_;
-
-            IF _existing_ IS NOT NULL THEN
-                RETURN (409, _existing_);
-            END IF;
-
-            UPDATE "user" SET
-                "email" = CASE
-                              WHEN _email_ = '' THEN "email"
-                              ELSE _email_
-                          END,
-                "fullname" = replace_if_empty(_full_name_,"fullname"),
-                "about" = replace_if_empty(_about_,"about")
-            WHERE "nickname" = _nickname_
-            RETURNING json_build_object(
-                'nickname', "nickname", 'email', "email",
-                'fullname', "fullname", 'about', "about"
-            ) INTO _existing_;
-
-            IF _existing_ IS NULL THEN
-                RETURN (404, _existing_);
-            END IF;
-
-            RETURN (200, _existing_);
-        END;
-        $$ LANGUAGE PLPGSQL;
-    `
+	UserNotFoundErrCode           = "user_not_found"
+	UserAttributeDuplicateErrCode = "user_attribute_duplicate"
+)
 
+const (
 	InsertUserStatement                   = "insert_user_statement"
 	SelectUserNicknameByNicknameStatement = "select_user_nickname_by_nickname"
 	SelectUserByNicknameStatement         = "select_user_by_nickname_statement"
 	UpdateUserStatement                   = "update_user_statement"
 )
 
+type AuthorSearchArgs struct {
+	Author string
+	Forum  string
+	Since  int
+	Desc   bool
+	Limit  int
+}
+
+func (args *AuthorSearchArgs) keysetClause(column string, qArgs *[]interface{}) string {
+	var clause string
+	if args.Forum != consts.EmptyString {
+		*qArgs = append(*qArgs, args.Forum)
+		clause += fmt.Sprintf(` AND %s."forum" = $%d`, column, len(*qArgs))
+	}
+	if args.Since > 0 {
+		*qArgs = append(*qArgs, args.Since)
+
+		var eqOp string
+		if args.Desc {
+			eqOp = "<"
+		} else {
+			eqOp = ">"
+		}
+
+		clause += fmt.Sprintf(` AND %s."id" %s $%d`, column, eqOp, len(*qArgs))
+	}
+
+	clause += fmt.Sprintf(` ORDER BY %s."id"`, column)
+	if args.Desc {
+		clause += ` DESC`
+	} else {
+		clause += ` ASC`
+	}
+	if args.Limit > 0 {
+		*qArgs = append(*qArgs, args.Limit)
+		clause += fmt.Sprintf(` LIMIT $%d`, len(*qArgs))
+	}
+	return clause + `;`
+}
+
 type UserRepository struct {
-	conn        *Connection
-	notFoundErr *errs.Error
-	conflictErr *errs.Error
+	conn             *Connection
+	notFoundErr      *errs.Error
+	forumNotFoundErr *errs.Error
+	conflictErr      *errs.Error
 }
 
 func NewUserRepository(conn *Connection) *UserRepository {
 	return &UserRepository{
-		conn:        conn,
-		notFoundErr: errs.NewNotFoundError(UserNotFoundErrMessage),
-		conflictErr: errs.NewConflictError(UserAttributeDuplicateErrMessage),
+		conn: conn,
+		notFoundErr: errs.NewNotFoundError(UserNotFoundErrMessage).
+			WithCode(UserNotFoundErrCode).WithEntity("user", "nickname"),
+		forumNotFoundErr: errs.NewNotFoundError(ForumNotFoundErrMessage).
+			WithCode(ForumNotFoundErrCode).WithEntity("forum", "slug"),
+		conflictErr: errs.NewConflictError(UserAttributeDuplicateErrMessage).
+			WithCode(UserAttributeDuplicateErrCode).WithEntity("user", "email"),
 	}
 }
 
 func (r *UserRepository) Init() error {
-	err := r.conn.execInit(CreateUserTableQuery)
-	if err != nil {
-		return err
-	}
-
-	err = r.conn.prepareStmt(InsertUserStatement, `
+	err := r.conn.prepareStmt(InsertUserStatement, `
         SELECT * FROM insert_user($1,$2,$3,$4);
     `)
 	if err != nil {
@@ -172,23 +120,23 @@ func (r *UserRepository) Init() error {
 	return nil
 }
 
-func (r *UserRepository) CreateUser(user *models.User, existing *string) int {
+func (r *UserRepository) CreateUser(ctx context.Context, user *models.User, existing *string) (int, *errs.Error) {
 	var status int
 
-	row := r.conn.conn.QueryRow(InsertUserStatement,
+	row := r.conn.queryRow(ctx, InsertUserStatement,
 		&user.Nickname, &user.Email, &user.FullName, &user.About,
 	)
 	if err := row.Scan(&status, existing); err != nil {
-		panic(err)
+		return 0, wrapError(err)
 	}
 
-	return status
+	return status, nil
 }
 
-func (r *UserRepository) FindUser(user *models.User) *errs.Error {
-	rows, err := r.conn.conn.Query(SelectUserByNicknameStatement, &user.Nickname)
+func (r *UserRepository) FindUser(ctx context.Context, user *models.User) *errs.Error {
+	rows, err := r.conn.query(ctx, SelectUserByNicknameStatement, &user.Nickname)
 	if err != nil {
-		panic(err)
+		return wrapError(err)
 	}
 	defer rows.Close()
 
@@ -199,9 +147,12 @@ func (r *UserRepository) FindUser(user *models.User) *errs.Error {
 			&user.Nickname, &user.Email, &user.FullName, &user.About,
 		)
 		if err != nil {
-			panic(err)
+			return wrapError(err)
 		}
 	}
+	if err = rows.Err(); err != nil {
+		return wrapError(err)
+	}
 
 	if !found {
 		return r.notFoundErr
@@ -217,7 +168,7 @@ type UsersByForumSearchArgs struct {
 	Limit int
 }
 
-func (r *UserRepository) FindUsersByForum(args *UsersByForumSearchArgs) (*models.Users, *errs.Error) {
+func (r *UserRepository) FindUsersByForum(ctx context.Context, args *UsersByForumSearchArgs) (*models.Users, *errs.Error) {
 	query := `
         SELECT ` + UserAttributes + `
         FROM "user" u
@@ -253,9 +204,9 @@ func (r *UserRepository) FindUsersByForum(args *UsersByForumSearchArgs) (*models
 	}
 	query += `;`
 
-	rows, err := r.conn.conn.Query(query, qArgs...)
+	rows, err := r.conn.query(ctx, query, qArgs...)
 	if err != nil {
-		return nil, r.notFoundErr
+		return nil, wrapError(err)
 	}
 	defer rows.Close()
 
@@ -264,33 +215,42 @@ func (r *UserRepository) FindUsersByForum(args *UsersByForumSearchArgs) (*models
 		var user models.User
 		err = r.scanUser(rows.Scan, &user)
 		if err != nil {
-			panic(err)
+			return nil, wrapError(err)
 		}
 		users = append(users, user)
 	}
+	if err = rows.Err(); err != nil {
+		return nil, wrapError(err)
+	}
 
 	if len(users) == 0 {
 		var exists bool
-		row := r.conn.conn.QueryRow(SelectForumExistsBySlugStatement, &args.Forum)
-		if _ = row.Scan(&exists); !exists {
-			return nil, r.notFoundErr
+		row := r.conn.queryRow(ctx, SelectForumExistsBySlugStatement, &args.Forum)
+		if err = row.Scan(&exists); err != nil {
+			return nil, wrapError(err)
+		}
+		if !exists {
+			return nil, r.forumNotFoundErr
 		}
 	}
 
 	return (*models.Users)(&users), nil
 }
 
-func (r *UserRepository) UpdateUser(user *models.User, existing *sql.NullString) int {
+func (r *UserRepository) UpdateUser(ctx context.Context, user *models.User, existing *sql.NullString) (int, *errs.Error) {
 	var status int
 
-	row := r.conn.conn.QueryRow(UpdateUserStatement,
+	row := r.conn.queryRow(ctx, UpdateUserStatement,
 		&user.Nickname, &user.Email, &user.FullName, &user.About,
 	)
 	if err := row.Scan(&status, existing); err != nil {
-		panic(err)
+		return 0, wrapError(err)
+	}
+	if status == http.StatusNotFound {
+		return status, r.notFoundErr
 	}
 
-	return status
+	return status, nil
 }
 
 func (r *UserRepository) scanUser(f ScanFunc, user *models.User) error {
@@ -1,7 +1,9 @@
 package repositories
 
 import (
+	"context"
 	"database/sql"
+	"net/http"
 	"tp-project-db/errs"
 	"tp-project-db/models"
 )
@@ -12,91 +14,11 @@ const (
 )
 
 const (
-	CreateVoteTableQuery = `
-	    CREATE TABLE IF NOT EXISTS "vote" (
-            "user" CITEXT COLLATE "ucs_basic"
-                CONSTRAINT "vote_user_not_null" NOT NULL
-                CONSTRAINT "vote_user_fk" REFERENCES "user"("nickname"),
-            "thread" INTEGER
-                CONSTRAINT "vote_thread_not_null" NOT NULL
-                CONSTRAINT "vote_thread_fk" REFERENCES "thread"("id"),
-            "voice" INTEGER
-                CONSTRAINT "vote_voice_not_null" NOT NULL,
-            CONSTRAINT "vote_user_thread_pk" PRIMARY KEY("user","thread")
-        );
-
-        CREATE OR REPLACE FUNCTION add_vote(
-            _user_ CITEXT, _voice_ INTEGER,
-            _thread_id_ INTEGER, _thread_slug_ CITEXT
-        ) RETURNS "query_result"
-        AS $$
-        DECLARE _prev_ INTEGER;
-        DECLARE _thread_ JSON;
-        BEGIN
-            IF _thread_id_ IS NULL THEN
-                SELECT th."id" FROM "thread" th
-                WHERE th."slug" = _thread_slug_
-                INTO _thread_id_;
-
-                IF _thread_id_ IS NULL THEN
-                    RETURN (404,_thread_);
-                END IF;
-            ELSE
-                IF NOT EXISTS (SELECT * FROM "thread" WHERE "id" = _thread_id_) THEN
-                    RETURN (404,_thread_);
-                END IF;
-            END IF;
-
-            IF NOT EXISTS (SELECT * FROM "user" WHERE "nickname" = _user_) THEN
-                RETURN (404,_thread_);
-            END IF;
-
-            SELECT v."voice"
-            FROM "vote" v
-            WHERE v."user" = _user_ AND
-                  v."thread" = _thread_id_
-            INTO _prev_;
-
-            IF _prev_ IS NULL THEN
-                INSERT INTO "vote"("user","thread","voice")
-                VALUES(_user_,_thread_id_,_voice_);
-
-                UPDATE "thread" SET
-                    "num_votes" = "num_votes" + _voice_
-                WHERE "id" = _thread_id_
-                RETURNING json_build_object(
-                    'id', "id",'slug', "slug",'title', "title",
-                    'forum', "forum",'author', "author",'created',"created_timestamp",
-                    'message',"message", 'votes', "num_votes")
-                INTO _thread_;
-            ELSE
-                IF _prev_ = _voice_ THEN
-                    SELECT json_build_object(
-                        'id', "id",'slug', "slug",'title', "title",
-                        'forum', "forum",'author', "author",'created',"created_timestamp",
-                        'message',"message", 'votes', "num_votes")
-                    FROM "thread" WHERE "id" = _thread_id_
-                    INTO _thread_;
-                ELSE
-                    UPDATE "vote" SET "voice" = _voice_
-                    WHERE "user" = _user_ AND "thread" = _thread_id_;
-
-                    UPDATE "thread" SET
-                        "num_votes" = "num_votes" + (2 * _voice_)
-                    WHERE "id" = _thread_id_
-                    RETURNING json_build_object(
-                        'id', "id",'slug', "slug",'title', "title",
-                        'forum', "forum",'author', "author",'created',"created_timestamp",
-                        'message',"message", 'votes', "num_votes")
-                    INTO _thread_;
-                END IF;
-            END IF;
-
-            RETURN (200,_thread_);
-        END;
-        $$ LANGUAGE PLPGSQL;
-    `
+	VoteAuthorNotFoundErrCode = "vote_author_not_found"
+	VoteThreadNotFoundErrCode = "vote_thread_not_found"
+)
 
+const (
 	AddVoteStatement = "add_vote_statement"
 )
 
@@ -104,23 +26,26 @@ type VoteRepository struct {
 	conn              *Connection
 	authorNotFoundErr *errs.Error
 	threadNotFoundErr *errs.Error
+	threadDeletedErr  *errs.Error
+	threadArchivedErr *errs.Error
 }
 
 func NewVoteRepository(conn *Connection) *VoteRepository {
 	return &VoteRepository{
-		conn:              conn,
-		authorNotFoundErr: errs.NewNotFoundError(VoteAuthorNotFoundErrMessage),
-		threadNotFoundErr: errs.NewNotFoundError(VoteThreadNotFoundErrMessage),
+		conn: conn,
+		authorNotFoundErr: errs.NewNotFoundError(VoteAuthorNotFoundErrMessage).
+			WithCode(VoteAuthorNotFoundErrCode).WithEntity("user", "nickname"),
+		threadNotFoundErr: errs.NewNotFoundError(VoteThreadNotFoundErrMessage).
+			WithCode(VoteThreadNotFoundErrCode).WithEntity("thread", "slug_or_id"),
+		threadDeletedErr: errs.NewConflictError(ThreadDeletedErrMessage).
+			WithCode(ThreadDeletedErrCode).WithEntity("thread", "slug_or_id"),
+		threadArchivedErr: errs.NewConflictError(ThreadArchivedErrMessage).
+			WithCode(ThreadArchivedErrCode).WithEntity("thread", "slug_or_id"),
 	}
 }
 
 func (r *VoteRepository) Init() error {
-	err := r.conn.execInit(CreateVoteTableQuery)
-	if err != nil {
-		return err
-	}
-
-	err = r.conn.prepareStmt(AddVoteStatement, `
+	err := r.conn.prepareStmt(AddVoteStatement, `
         SELECT * FROM add_vote($1,$2,$3,$4);
     `)
 	if err != nil {
@@ -130,18 +55,60 @@ func (r *VoteRepository) Init() error {
 	return nil
 }
 
-func (r *VoteRepository) AddVote(vote *models.Vote, thread *sql.NullString) (status int) {
+func (r *VoteRepository) AddVote(ctx context.Context, vote *models.Vote, thread *sql.NullString) (status int, err *errs.Error) {
 	var id interface{} = nil
 	if vote.ThreadID != 0 {
 		id = &vote.ThreadID
 	}
 
-	row := r.conn.conn.QueryRow(AddVoteStatement,
+	row := r.conn.queryRow(ctx, AddVoteStatement,
 		&vote.User, &vote.Voice, id, &vote.ThreadSlug,
 	)
-	if err := row.Scan(&status, thread); err != nil {
-		panic(err)
+	if scanErr := row.Scan(&status, thread); scanErr != nil {
+		return 0, wrapError(scanErr)
+	}
+	if status == http.StatusNotFound {
+		var exists bool
+		if id != nil {
+			row = r.conn.queryRow(ctx, SelectThreadExistsByIDStatement, id)
+		} else {
+			row = r.conn.queryRow(ctx, SelectThreadExistsBySlugStatement, &vote.ThreadSlug)
+		}
+		if scanErr := row.Scan(&exists); scanErr != nil {
+			return status, wrapError(scanErr)
+		}
+		if !exists {
+			return status, r.threadNotFoundErr
+		}
+		return status, r.authorNotFoundErr
+	}
+	if status == http.StatusConflict {
+		var isDeleted bool
+		if id != nil {
+			row = r.conn.queryRow(ctx, SelectThreadIsDeletedByIDStatement, id)
+		} else {
+			row = r.conn.queryRow(ctx, SelectThreadIsDeletedBySlugStatement, &vote.ThreadSlug)
+		}
+		if scanErr := row.Scan(&isDeleted); scanErr != nil {
+			return status, wrapError(scanErr)
+		}
+		if isDeleted {
+			return status, r.threadDeletedErr
+		}
+		return status, r.threadArchivedErr
+	}
+	if status == http.StatusForbidden {
+		var reason string
+		if id != nil {
+			row = r.conn.queryRow(ctx, SelectThreadCloseReasonByIDStatement, id)
+		} else {
+			row = r.conn.queryRow(ctx, SelectThreadCloseReasonBySlugStatement, &vote.ThreadSlug)
+		}
+		if scanErr := row.Scan(&reason); scanErr != nil {
+			return status, wrapError(scanErr)
+		}
+		return status, NewThreadClosedError(reason)
 	}
 
-	return status
+	return status, nil
 }
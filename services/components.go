@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"tp-project-db/errs"
+	"tp-project-db/models"
+	"tp-project-db/repositories"
+)
+
+type UserRepository interface {
+	CreateUser(ctx context.Context, user *models.User, existing *string) (int, *errs.Error)
+	FindUser(ctx context.Context, user *models.User) *errs.Error
+	FindUsersByForum(ctx context.Context, args *repositories.UsersByForumSearchArgs) (*models.Users, *errs.Error)
+	UpdateUser(ctx context.Context, user *models.User, existing *sql.NullString) (int, *errs.Error)
+}
+
+type ForumRepository interface {
+	CreateForum(ctx context.Context, forum *models.Forum, existing *sql.NullString) (int, *errs.Error)
+	FindForum(ctx context.Context, forum *models.Forum) *errs.Error
+	UpdateForumSearchConfig(ctx context.Context, config *models.ForumSearchConfig) *errs.Error
+}
+
+type ThreadRepository interface {
+	CreateThread(ctx context.Context, thread *models.Thread, existing *sql.NullString) (int, *errs.Error)
+	FindThreadByID(ctx context.Context, id int32, existing *string) *errs.Error
+	FindThreadBySlug(ctx context.Context, slug *string, existing *string) *errs.Error
+	FindThreadForumByID(ctx context.Context, args *repositories.CreatePostArgs) *errs.Error
+	FindThreadIDAndForumBySlug(ctx context.Context, args *repositories.CreatePostArgs) *errs.Error
+	FindThreadsByForum(ctx context.Context, args *repositories.ForumThreadsSearchArgs) (*models.Threads, *errs.Error)
+	FindThreadsByAuthor(ctx context.Context, args *repositories.AuthorSearchArgs) (*models.Threads, *errs.Error)
+	UpdateThreadByID(ctx context.Context, thread *models.Thread) *errs.Error
+	UpdateThreadBySlug(ctx context.Context, thread *models.Thread) *errs.Error
+	DeleteThread(ctx context.Context, thread *models.Thread, moderator string) *errs.Error
+	RestoreThread(ctx context.Context, thread *models.Thread, moderator string) *errs.Error
+	ArchiveThread(ctx context.Context, thread *models.Thread, archived bool, moderator string) *errs.Error
+	CloseThread(ctx context.Context, thread *models.Thread, args *repositories.CloseThreadArgs) *errs.Error
+	PinThread(ctx context.Context, thread *models.Thread, args *repositories.PinThreadArgs) *errs.Error
+	MoveThread(ctx context.Context, thread *models.Thread, args *repositories.MoveThreadArgs) *errs.Error
+	SplitThread(ctx context.Context, thread *models.Thread, args *repositories.SplitThreadArgs) *errs.Error
+	MergeThread(ctx context.Context, thread *models.Thread, args *repositories.MergeThreadArgs) *errs.Error
+	PurgeThread(ctx context.Context, deletion *models.ThreadDeletion, moderator string) *errs.Error
+}
+
+type PostRepository interface {
+	CreatePosts(ctx context.Context, posts *models.Posts, args *repositories.CreatePostArgs) *errs.Error
+	FindPost(ctx context.Context, post *models.Post) *errs.Error
+	FindFullPost(ctx context.Context, post *models.PostFull) *errs.Error
+	FindPostsByThread(ctx context.Context, args *repositories.PostsByThreadSearchArgs) (*models.Posts, *errs.Error)
+	FindPostsByAuthor(ctx context.Context, args *repositories.AuthorSearchArgs) (*models.Posts, *errs.Error)
+	FindPostSubtree(ctx context.Context, args *repositories.PostSubtreeArgs) (*models.Posts, *errs.Error)
+	FindPostAncestors(ctx context.Context, id int64) (*models.Posts, *errs.Error)
+	UpdatePost(ctx context.Context, post *models.Post, args *repositories.UpdatePostArgs) *errs.Error
+	FindPostRevisions(ctx context.Context, id int64) (*models.PostRevisions, *errs.Error)
+	DeletePost(ctx context.Context, post *models.Post) *errs.Error
+	PurgePostSubtree(ctx context.Context, deletion *models.PostDeletion, moderator string) *errs.Error
+}
+
+type SearchRepository interface {
+	Search(ctx context.Context, args *repositories.SearchArgs) (*models.SearchResults, *errs.Error)
+}
+
+type VoteRepository interface {
+	AddVote(ctx context.Context, vote *models.Vote, thread *sql.NullString) (int, *errs.Error)
+}
+
+type StatusRepository interface {
+	GetStatus(ctx context.Context, status *models.Status) *errs.Error
+	ClearDatabase(ctx context.Context) *errs.Error
+}
+
+type HealthRepository interface {
+	CheckReadiness(ctx context.Context, health *models.Health)
+}
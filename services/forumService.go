@@ -5,37 +5,60 @@ import (
 	"github.com/valyala/fasthttp"
 	"net/http"
 	"tp-project-db/models"
+	"tp-project-db/validation"
 )
 
 func (srv *Server) createForum(ctx *fasthttp.RequestCtx) {
 	var forum models.Forum
-	srv.ReadBody(ctx, &forum)
+	if err := srv.ReadBody(ctx, &forum); err != nil {
+		srv.WriteError(ctx, err)
+		return
+	}
+	if err := validation.ValidateForum(&forum); err != nil {
+		srv.WriteError(ctx, err)
+		return
+	}
 
 	var existing sql.NullString
-	status := srv.components.ForumRepository.CreateForum(&forum, &existing)
-
-	if status == http.StatusCreated {
-		srv.rwMtx.Lock()
-		srv.status.NumForums++
-		srv.rwMtx.Unlock()
+	status, err := srv.components.ForumRepository.CreateForum(requestContext(ctx), &forum, &existing)
+	if err != nil {
+		srv.WriteError(ctx, err)
+		return
 	}
 
-	if existing.Valid {
-		ctx.SetStatusCode(status)
-		ctx.Response.Header.SetContentType(JsonType)
-		ctx.Response.SetBody([]byte(existing.String))
-	} else {
-		srv.WriteError(ctx, status)
-	}
+	ctx.SetStatusCode(status)
+	ctx.Response.Header.SetContentType(JsonType)
+	ctx.Response.SetBody([]byte(existing.String))
 }
 
 func (srv *Server) findForum(ctx *fasthttp.RequestCtx) {
 	forum := models.Forum{
 		Slug: ctx.UserValue("slug").(string),
 	}
-	if err := srv.components.ForumRepository.FindForum(&forum); err != nil {
-		srv.WriteError(ctx, err.HttpStatus)
+	if err := srv.components.ForumRepository.FindForum(requestContext(ctx), &forum); err != nil {
+		srv.WriteError(ctx, err)
 		return
 	}
 	srv.WriteJSON(ctx, http.StatusOK, &forum)
 }
+
+func (srv *Server) updateForumSearchConfig(ctx *fasthttp.RequestCtx) {
+	var config models.ForumSearchConfig
+	if err := srv.ReadBody(ctx, &config); err != nil {
+		srv.WriteError(ctx, err)
+		return
+	}
+	if err := validation.ValidateForumSearchConfig(&config); err != nil {
+		srv.WriteError(ctx, err)
+		return
+	}
+
+	config.Forum = ctx.UserValue("slug").(string)
+	if err := srv.components.ForumRepository.UpdateForumSearchConfig(requestContext(ctx), &config); err != nil {
+		srv.WriteError(ctx, err)
+		return
+	}
+
+	config.Moderator = ""
+	srv.WriteJSON(ctx, http.StatusOK, &config)
+}
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"github.com/valyala/fasthttp"
+	"net/http"
+	"tp-project-db/models"
+)
+
+const (
+	ServerComponent = "server"
+)
+
+const (
+	DrainingMessage = "server is draining for shutdown"
+)
+
+func (srv *Server) getHealth(ctx *fasthttp.RequestCtx) {
+	srv.WriteJSON(ctx, http.StatusOK, &models.Health{Status: models.HealthStatusOK})
+}
+
+func (srv *Server) getReadiness(ctx *fasthttp.RequestCtx) {
+	health := models.Health{
+		Status:     models.HealthStatusOK,
+		Components: make(map[string]models.ComponentHealth),
+	}
+
+	srv.connsMtx.Lock()
+	draining := srv.draining
+	srv.connsMtx.Unlock()
+
+	if draining {
+		health.Components[ServerComponent] = models.ComponentHealth{
+			Status:  models.HealthStatusUnavailable,
+			Message: DrainingMessage,
+		}
+	} else {
+		health.Components[ServerComponent] = models.ComponentHealth{Status: models.HealthStatusOK}
+	}
+
+	srv.components.HealthRepository.CheckReadiness(requestContext(ctx), &health)
+
+	status := http.StatusOK
+	for _, component := range health.Components {
+		if component.Status != models.HealthStatusOK {
+			health.Status = models.HealthStatusUnavailable
+			status = http.StatusServiceUnavailable
+		}
+	}
+	srv.WriteJSON(ctx, status, &health)
+}
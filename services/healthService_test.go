@@ -0,0 +1,36 @@
+package services
+
+import (
+	"net/http"
+	"testing"
+	"tp-project-db/models"
+)
+
+func TestHealthAndReadiness(t *testing.T) {
+	srv := newTestServer(t)
+
+	var health models.Health
+	srv.decode("GET", HealthPath, "", http.StatusOK, &health)
+	if health.Status != models.HealthStatusOK {
+		t.Errorf("health = %+v", health)
+	}
+
+	health = models.Health{}
+	srv.decode("GET", ReadinessPath, "", http.StatusOK, &health)
+	if health.Status != models.HealthStatusOK || health.Components[ServerComponent].Status != models.HealthStatusOK ||
+		health.Components["storage"].Status != models.HealthStatusOK {
+		t.Errorf("readiness = %+v", health)
+	}
+
+	if err := srv.Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+
+	health = models.Health{}
+	srv.decode("GET", ReadinessPath, "", http.StatusServiceUnavailable, &health)
+	server := health.Components[ServerComponent]
+	if health.Status != models.HealthStatusUnavailable || server.Status != models.HealthStatusUnavailable || server.Message != DrainingMessage {
+		t.Errorf("readiness while draining = %+v", health)
+	}
+	srv.decode("GET", HealthPath, "", http.StatusOK, &health)
+}
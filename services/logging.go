@@ -0,0 +1,75 @@
+package services
+
+import (
+	"context"
+	"github.com/valyala/fasthttp"
+	"time"
+	"tp-project-db/consts"
+	"tp-project-db/logging"
+)
+
+const (
+	ContextUserValue = "context"
+)
+
+func requestContext(ctx *fasthttp.RequestCtx) context.Context {
+	if c, ok := ctx.UserValue(ContextUserValue).(context.Context); ok {
+		return c
+	}
+	return context.Background()
+}
+
+func requestID(ctx *fasthttp.RequestCtx) string {
+	return logging.RequestID(requestContext(ctx))
+}
+
+func withRequestContext(base context.Context, h fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		id := string(ctx.Request.Header.Peek(logging.RequestIDHeader))
+		if id == consts.EmptyString {
+			id = logging.NewRequestID()
+		}
+		ctx.SetUserValue(ContextUserValue, logging.WithRequestID(base, id))
+		ctx.Response.Header.Set(logging.RequestIDHeader, id)
+
+		h(ctx)
+	}
+}
+
+func withAccessLog(h fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		t1 := time.Now()
+		h(ctx)
+		dt := time.Since(t1)
+
+		if !logging.Default.Enabled(logging.LevelInfo) {
+			return
+		}
+
+		route, ok := ctx.UserValue(RouteUserValue).(string)
+		if !ok {
+			route = UnmatchedRoute
+		}
+		params := make(map[string]interface{})
+		ctx.VisitUserValues(func(key []byte, value interface{}) {
+			switch k := string(key); k {
+			case RouteUserValue, ContextUserValue:
+			default:
+				params[k] = value
+			}
+		})
+
+		logging.Default.Info("request", logging.Fields{
+			logging.RequestIDField: requestID(ctx),
+			"method":               string(ctx.Method()),
+			"route":                route,
+			"path":                 string(ctx.Path()),
+			"params":               params,
+			"query":                string(ctx.QueryArgs().QueryString()),
+			"status":               ctx.Response.StatusCode(),
+			"latency_ms":           float64(dt) / float64(time.Millisecond),
+			"bytes":                len(ctx.Response.Body()),
+			"remote_addr":          ctx.RemoteAddr().String(),
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package services
+
+import (
+	"github.com/valyala/fasthttp"
+	"net/http"
+	"tp-project-db/logging"
+	"tp-project-db/metrics"
+)
+
+func (srv *Server) getMetrics(ctx *fasthttp.RequestCtx) {
+	ctx.SetStatusCode(http.StatusOK)
+	ctx.Response.Header.SetContentType(metrics.ContentType)
+	if err := metrics.DefaultRegistry.Write(ctx); err != nil {
+		logging.Default.Error("metrics not written", logging.Fields{
+			logging.RequestIDField: requestID(ctx),
+			"error":                err,
+		})
+	}
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"database/sql"
+	"fmt"
 	"github.com/go-openapi/strfmt"
 	"github.com/valyala/fasthttp"
 	"net/http"
@@ -9,8 +10,24 @@ import (
 	"strings"
 	"time"
 	"tp-project-db/consts"
+	"tp-project-db/diff"
+	"tp-project-db/errs"
 	"tp-project-db/models"
 	"tp-project-db/repositories"
+	"tp-project-db/validation"
+)
+
+const (
+	RevisionNotFoundErrMessage = "post revision not found"
+	RevisionNotFoundErrCode    = "revision_not_found"
+)
+
+const (
+	PostsFormatFlat   = "flat"
+	PostsFormatNested = "nested"
+
+	NonNegativeIntErrMessage = "must be a non-negative integer"
+	NestedSortErrMessage     = "must be parent_tree when format is nested"
 )
 
 func (srv *Server) createPosts(ctx *fasthttp.RequestCtx) {
@@ -22,19 +39,26 @@ func (srv *Server) createPosts(ctx *fasthttp.RequestCtx) {
 
 	if id, err := strconv.ParseInt(args.ThreadSlug, 10, 32); err == nil {
 		args.ThreadID = int32(id)
-		if srv.components.ThreadRepository.FindThreadForumByID(&args) != nil {
-			srv.WriteError(ctx, http.StatusNotFound)
+		if err := srv.components.ThreadRepository.FindThreadForumByID(requestContext(ctx), &args); err != nil {
+			srv.WriteError(ctx, err)
 			return
 		}
 	} else {
-		if srv.components.ThreadRepository.FindThreadIDAndForumBySlug(&args) != nil {
-			srv.WriteError(ctx, http.StatusNotFound)
+		if err := srv.components.ThreadRepository.FindThreadIDAndForumBySlug(requestContext(ctx), &args); err != nil {
+			srv.WriteError(ctx, err)
 			return
 		}
 	}
 
 	var posts models.Posts
-	srv.ReadBody(ctx, &posts)
+	if err := srv.ReadBody(ctx, &posts); err != nil {
+		srv.WriteError(ctx, err)
+		return
+	}
+	if err := validation.ValidatePosts(&posts); err != nil {
+		srv.WriteError(ctx, err)
+		return
+	}
 
 	n := len(([]models.Post)(posts))
 	if n == 0 {
@@ -42,15 +66,11 @@ func (srv *Server) createPosts(ctx *fasthttp.RequestCtx) {
 		return
 	}
 
-	if err := srv.components.PostRepository.CreatePosts(&posts, &args); err != nil {
-		srv.WriteError(ctx, err.HttpStatus)
+	if err := srv.components.PostRepository.CreatePosts(requestContext(ctx), &posts, &args); err != nil {
+		srv.WriteError(ctx, err)
 		return
 	}
 
-	srv.rwMtx.Lock()
-	srv.status.NumPosts += int64(n)
-	srv.rwMtx.Unlock()
-
 	srv.WriteJSON(ctx, http.StatusCreated, &posts)
 }
 
@@ -75,12 +95,15 @@ func (srv *Server) findPost(ctx *fasthttp.RequestCtx) {
 		case "user":
 			var user models.User
 			postMap["author"] = &user
+		case "revisions":
+			var count int64
+			postMap["revisions"] = &count
 		}
 	}
 
 	postPtr := (*models.PostFull)(&postMap)
-	if err := srv.components.PostRepository.FindFullPost(postPtr); err != nil {
-		srv.WriteError(ctx, err.HttpStatus)
+	if err := srv.components.PostRepository.FindFullPost(requestContext(ctx), postPtr); err != nil {
+		srv.WriteError(ctx, err)
 		return
 	}
 
@@ -91,6 +114,33 @@ func (srv *Server) findPostsByThread(ctx *fasthttp.RequestCtx) {
 	slugOrID := ctx.UserValue("slug_or_id").(string)
 
 	sortType := string(ctx.QueryArgs().Peek("sort"))
+
+	var v validation.Validator
+	var maxDepth sql.NullInt64
+	format := string(ctx.QueryArgs().Peek("format"))
+	switch format {
+	case consts.EmptyString, PostsFormatFlat:
+	case PostsFormatNested:
+		if sortType != consts.EmptyString && sortType != "parent_tree" {
+			v.Fail("sort", NestedSortErrMessage)
+		}
+		sortType = "parent_tree"
+
+		if value := ctx.QueryArgs().Peek("max_depth"); len(value) != 0 {
+			depth, err := strconv.ParseUint(string(value), 10, 31)
+			if err != nil {
+				v.Fail("max_depth", NonNegativeIntErrMessage)
+			}
+			maxDepth = sql.NullInt64{Valid: true, Int64: int64(depth)}
+		}
+	default:
+		v.Fail("format", fmt.Sprintf("must be one of [%s %s]", PostsFormatFlat, PostsFormatNested))
+	}
+	if err := v.Err(); err != nil {
+		srv.WriteError(ctx, err)
+		return
+	}
+
 	if sortType == consts.EmptyString {
 		sortType = "flat"
 	}
@@ -112,6 +162,10 @@ func (srv *Server) findPostsByThread(ctx *fasthttp.RequestCtx) {
 		Desc:       ctx.QueryArgs().GetBool("desc"),
 		SortType:   sortType,
 	}
+	if maxDepth.Valid {
+		// One level past the cut is fetched so the deepest shown posts get exact child counts.
+		searchArgs.MaxDepth = sql.NullInt64{Valid: true, Int64: maxDepth.Int64 + 1}
+	}
 
 	if id, err := strconv.ParseInt(slugOrID, 10, 32); err == nil {
 		searchArgs.ThreadID = sql.NullInt64{
@@ -119,9 +173,86 @@ func (srv *Server) findPostsByThread(ctx *fasthttp.RequestCtx) {
 		}
 	}
 
-	posts, err := srv.components.PostRepository.FindPostsByThread(&searchArgs)
+	posts, err := srv.components.PostRepository.FindPostsByThread(requestContext(ctx), &searchArgs)
 	if err != nil {
-		srv.WriteError(ctx, err.HttpStatus)
+		srv.WriteError(ctx, err)
+		return
+	}
+
+	if format == PostsFormatNested {
+		srv.WriteJSON(ctx, http.StatusOK, nestPosts(*posts, maxDepth))
+		return
+	}
+	srv.WriteJSON(ctx, http.StatusOK, posts)
+}
+
+// nestPosts expects parents to precede their children, as parent_tree orders them.
+func nestPosts(posts models.Posts, maxDepth sql.NullInt64) *models.PostNodes {
+	roots := make(models.PostNodes, 0)
+	nodes := make(map[int64]*models.PostNode, len(posts))
+
+	for _, p := range posts {
+		node := &models.PostNode{
+			Post:     p,
+			Children: make(models.PostNodes, 0),
+		}
+
+		if p.ParentID == 0 {
+			roots = append(roots, node)
+			nodes[p.ID] = node
+			continue
+		}
+
+		parent, ok := nodes[p.ParentID]
+		if !ok {
+			continue
+		}
+		parent.ChildCount++
+
+		node.Depth = parent.Depth + 1
+		if maxDepth.Valid && int64(node.Depth) > maxDepth.Int64 {
+			parent.MoreReplies = true
+			continue
+		}
+		parent.Children = append(parent.Children, node)
+		nodes[p.ID] = node
+	}
+
+	return &roots
+}
+
+func (srv *Server) findPostSubtree(ctx *fasthttp.RequestCtx) {
+	id, _ := strconv.ParseInt(ctx.UserValue("id").(string), 10, 64)
+	args := repositories.PostSubtreeArgs{
+		ID: id,
+	}
+
+	if value := ctx.QueryArgs().Peek("depth"); len(value) != 0 {
+		depth, err := strconv.ParseUint(string(value), 10, 31)
+		if err != nil {
+			var v validation.Validator
+			v.Fail("depth", NonNegativeIntErrMessage)
+			srv.WriteError(ctx, v.Err())
+			return
+		}
+		args.Depth = sql.NullInt64{Valid: true, Int64: int64(depth)}
+	}
+
+	posts, err := srv.components.PostRepository.FindPostSubtree(requestContext(ctx), &args)
+	if err != nil {
+		srv.WriteError(ctx, err)
+		return
+	}
+
+	srv.WriteJSON(ctx, http.StatusOK, posts)
+}
+
+func (srv *Server) findPostAncestors(ctx *fasthttp.RequestCtx) {
+	id, _ := strconv.ParseInt(ctx.UserValue("id").(string), 10, 64)
+
+	posts, err := srv.components.PostRepository.FindPostAncestors(requestContext(ctx), id)
+	if err != nil {
+		srv.WriteError(ctx, err)
 		return
 	}
 
@@ -135,20 +266,166 @@ func (srv *Server) updatePost(ctx *fasthttp.RequestCtx) {
 	}
 
 	var postUpdate models.PostUpdate
-	srv.ReadBody(ctx, &postUpdate)
+	if err := srv.ReadBody(ctx, &postUpdate); err != nil {
+		srv.WriteError(ctx, err)
+		return
+	}
+	if err := validation.ValidatePostUpdate(&postUpdate); err != nil {
+		srv.WriteError(ctx, err)
+		return
+	}
 
 	if postUpdate.Message == consts.EmptyString {
-		if err := srv.components.PostRepository.FindPost(&post); err != nil {
-			srv.WriteError(ctx, err.HttpStatus)
+		if err := srv.components.PostRepository.FindPost(requestContext(ctx), &post); err != nil {
+			srv.WriteError(ctx, err)
 			return
 		}
 	} else {
 		post.Message = postUpdate.Message
-		if err := srv.components.PostRepository.UpdatePost(&post); err != nil {
-			srv.WriteError(ctx, err.HttpStatus)
+		args := repositories.UpdatePostArgs{
+			Editor:    postUpdate.Editor,
+			Timestamp: strfmt.DateTime(time.Now()),
+		}
+		if err := srv.components.PostRepository.UpdatePost(requestContext(ctx), &post, &args); err != nil {
+			srv.WriteError(ctx, err)
 			return
 		}
 	}
 
 	srv.WriteJSON(ctx, http.StatusOK, &post)
 }
+
+func (srv *Server) deletePost(ctx *fasthttp.RequestCtx) {
+	id, _ := strconv.ParseInt(ctx.UserValue("id").(string), 10, 64)
+
+	if ctx.QueryArgs().GetBool("hard") {
+		moderator := string(ctx.QueryArgs().Peek("moderator"))
+		if err := validation.ValidateModerator(moderator); err != nil {
+			srv.WriteError(ctx, err)
+			return
+		}
+
+		deletion := models.PostDeletion{
+			ID: id,
+		}
+		if err := srv.components.PostRepository.PurgePostSubtree(requestContext(ctx), &deletion, moderator); err != nil {
+			srv.WriteError(ctx, err)
+			return
+		}
+
+		srv.WriteJSON(ctx, http.StatusOK, &deletion)
+		return
+	}
+
+	post := models.Post{
+		ID: id,
+	}
+	if err := srv.components.PostRepository.DeletePost(requestContext(ctx), &post); err != nil {
+		srv.WriteError(ctx, err)
+		return
+	}
+
+	srv.WriteJSON(ctx, http.StatusOK, &post)
+}
+
+func (srv *Server) splitPost(ctx *fasthttp.RequestCtx) {
+	id, _ := strconv.ParseInt(ctx.UserValue("id").(string), 10, 64)
+
+	var split models.PostSplit
+	if err := srv.ReadBody(ctx, &split); err != nil {
+		srv.WriteError(ctx, err)
+		return
+	}
+	if err := validation.ValidatePostSplit(&split); err != nil {
+		srv.WriteError(ctx, err)
+		return
+	}
+
+	args := repositories.SplitThreadArgs{
+		Post:      id,
+		Moderator: split.Moderator,
+	}
+	thread := models.Thread{
+		Slug:  split.Slug,
+		Title: split.Title,
+	}
+	if err := srv.components.ThreadRepository.SplitThread(requestContext(ctx), &thread, &args); err != nil {
+		srv.WriteError(ctx, err)
+		return
+	}
+
+	srv.WriteJSON(ctx, http.StatusCreated, &thread)
+}
+
+func (srv *Server) findPostRevisions(ctx *fasthttp.RequestCtx) {
+	id, _ := strconv.ParseInt(ctx.UserValue("id").(string), 10, 64)
+
+	revisions, err := srv.components.PostRepository.FindPostRevisions(requestContext(ctx), id)
+	if err != nil {
+		srv.WriteError(ctx, err)
+		return
+	}
+
+	srv.WriteJSON(ctx, http.StatusOK, revisions)
+}
+
+func (srv *Server) diffPostRevisions(ctx *fasthttp.RequestCtx) {
+	id, _ := strconv.ParseInt(ctx.UserValue("id").(string), 10, 64)
+
+	revisions, err := srv.components.PostRepository.FindPostRevisions(requestContext(ctx), id)
+	if err != nil {
+		srv.WriteError(ctx, err)
+		return
+	}
+	arr := []models.PostRevision(*revisions)
+
+	to, parErr := ctx.QueryArgs().GetUint("to")
+	if parErr != nil {
+		to = len(arr)
+	}
+	from, parErr := ctx.QueryArgs().GetUint("from")
+	if parErr != nil {
+		from = to - 1
+		if from < 1 {
+			from = to
+		}
+	}
+
+	fromRev := findRevision(arr, from)
+	if fromRev == nil {
+		srv.WriteError(ctx, revisionNotFoundErr("from"))
+		return
+	}
+	toRev := findRevision(arr, to)
+	if toRev == nil {
+		srv.WriteError(ctx, revisionNotFoundErr("to"))
+		return
+	}
+
+	changes := diff.Lines(fromRev.Message, toRev.Message)
+	result := models.PostRevisionDiff{
+		Post:    id,
+		From:    fromRev.Revision,
+		To:      toRev.Revision,
+		Changes: make([]models.DiffChange, 0, len(changes)),
+	}
+	for _, c := range changes {
+		result.Changes = append(result.Changes, models.DiffChange{Op: c.Op, Text: c.Text})
+	}
+
+	srv.WriteJSON(ctx, http.StatusOK, &result)
+}
+
+func findRevision(revisions []models.PostRevision, revision int) *models.PostRevision {
+	for i := range revisions {
+		if int(revisions[i].Revision) == revision {
+			return &revisions[i]
+		}
+	}
+	return nil
+}
+
+func revisionNotFoundErr(field string) *errs.Error {
+	return errs.NewNotFoundError(RevisionNotFoundErrMessage).
+		WithCode(RevisionNotFoundErrCode).WithEntity("revision", field)
+}
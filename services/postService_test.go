@@ -0,0 +1,256 @@
+package services
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"tp-project-db/models"
+)
+
+// thread creates a thread by bob in forum "pirate" and returns its id.
+func (srv *testServer) thread(slug string) int32 {
+	srv.t.Helper()
+
+	body := `{"author":"bob","title":"title","message":"message"}`
+	if slug != "" {
+		body = fmt.Sprintf(`{"author":"bob","title":"title","message":"message","slug":%q}`, slug)
+	}
+
+	var th models.Thread
+	srv.decode("POST", "/api/forum/pirate/create", body, http.StatusCreated, &th)
+	return th.ID
+}
+
+// post adds a post by author under parent (0 for a root post) and returns its
+// id.
+func (srv *testServer) post(thread int32, parent int64, author, message string) int64 {
+	srv.t.Helper()
+
+	var posts []models.Post
+	body := fmt.Sprintf(`[{"parent":%d,"author":%q,"message":%q}]`, parent, author, message)
+	srv.decode("POST", fmt.Sprintf("/api/thread/%d/create", thread), body, http.StatusCreated, &posts)
+	return posts[0].ID
+}
+
+func TestDeletePostHandler(t *testing.T) {
+	srv := newTestServer(t)
+	th := srv.thread("")
+	root := srv.post(th, 0, "alice", "root")
+	child := srv.post(th, root, "bob", "child")
+
+	var p models.Post
+	srv.decode("DELETE", fmt.Sprintf("/api/post/%d", root), "", http.StatusOK, &p)
+	if !p.IsDeleted || p.Author != "" || p.Message != "" {
+		t.Errorf("deleted post = %+v", p)
+	}
+
+	var full struct {
+		Post   models.Post  `json:"post"`
+		Author *models.User `json:"author"`
+	}
+	srv.decode("GET", fmt.Sprintf("/api/post/%d/details?related=user", root), "", http.StatusOK, &full)
+	if !full.Post.IsDeleted || full.Author != nil {
+		t.Errorf("details of a deleted post = %+v, want a tombstone without author", full)
+	}
+
+	srv.mustFail("POST", fmt.Sprintf("/api/post/%d/details", root), `{"message":"back"}`, http.StatusConflict, "post_deleted")
+
+	var posts []models.Post
+	srv.decode("GET", fmt.Sprintf("/api/thread/%d/posts?sort=tree", th), "", http.StatusOK, &posts)
+	if len(posts) != 2 || posts[1].ID != child || posts[1].ParentID != root {
+		t.Errorf("thread posts = %+v, want the reply kept under the tombstone", posts)
+	}
+
+	srv.mustFail("DELETE", fmt.Sprintf("/api/post/%d?hard=true&moderator=bob", root), "", http.StatusForbidden, "not_moderator")
+
+	var deletion models.PostDeletion
+	srv.decode("DELETE", fmt.Sprintf("/api/post/%d?hard=true&moderator=alice", root), "", http.StatusOK, &deletion)
+	if deletion.Deleted != 2 {
+		t.Errorf("purged = %d, want 2", deletion.Deleted)
+	}
+	srv.mustFail("GET", fmt.Sprintf("/api/post/%d/details", child), "", http.StatusNotFound, "post_not_found")
+}
+
+func TestPostRevisionsHandler(t *testing.T) {
+	srv := newTestServer(t)
+	th := srv.thread("")
+	id := srv.post(th, 0, "alice", "one\ntwo")
+
+	srv.must("POST", fmt.Sprintf("/api/post/%d/details", id), `{"message":"one\nthree","editor":"bob"}`, http.StatusOK)
+
+	var revisions []models.PostRevision
+	srv.decode("GET", fmt.Sprintf("/api/post/%d/revisions", id), "", http.StatusOK, &revisions)
+	if len(revisions) != 2 || revisions[0].Editor != "alice" || revisions[1].Editor != "bob" {
+		t.Fatalf("revisions = %+v", revisions)
+	}
+
+	var full struct {
+		Revisions int64 `json:"revisions"`
+	}
+	srv.decode("GET", fmt.Sprintf("/api/post/%d/details?related=revisions", id), "", http.StatusOK, &full)
+	if full.Revisions != 2 {
+		t.Errorf("revision count = %d, want 2", full.Revisions)
+	}
+
+	var d models.PostRevisionDiff
+	srv.decode("GET", fmt.Sprintf("/api/post/%d/revisions/diff", id), "", http.StatusOK, &d)
+	want := []models.DiffChange{{Op: "equal", Text: "one"}, {Op: "delete", Text: "two"}, {Op: "insert", Text: "three"}}
+	if d.From != 1 || d.To != 2 || fmt.Sprint(d.Changes) != fmt.Sprint(want) {
+		t.Errorf("diff = %+v, want 1..2 %+v", d, want)
+	}
+
+	srv.mustFail("GET", fmt.Sprintf("/api/post/%d/revisions/diff?from=1&to=5", id), "", http.StatusNotFound, RevisionNotFoundErrCode)
+	srv.mustFail("POST", fmt.Sprintf("/api/post/%d/details", id), `{"message":"x","editor":"carol"}`, http.StatusNotFound, "post_editor_not_found")
+}
+
+func postIDs(posts []models.Post) []int64 {
+	ids := make([]int64, 0, len(posts))
+	for _, p := range posts {
+		ids = append(ids, p.ID)
+	}
+	return ids
+}
+
+func TestPostTreeHandlers(t *testing.T) {
+	srv := newTestServer(t)
+	th := srv.thread("")
+	root := srv.post(th, 0, "alice", "root")
+	a := srv.post(th, root, "bob", "a")
+	a1 := srv.post(th, a, "alice", "a1")
+	b := srv.post(th, root, "bob", "b")
+
+	tests := []struct {
+		uri  string
+		want []int64
+	}{
+		{fmt.Sprintf("/api/post/%d/tree", root), []int64{root, a, a1, b}},
+		{fmt.Sprintf("/api/post/%d/tree?depth=1", root), []int64{root, a, b}},
+		{fmt.Sprintf("/api/post/%d/tree", a), []int64{a, a1}},
+		{fmt.Sprintf("/api/post/%d/ancestors", a1), []int64{root, a, a1}},
+		{fmt.Sprintf("/api/post/%d/ancestors", root), []int64{root}},
+	}
+	for _, tt := range tests {
+		var posts []models.Post
+		srv.decode("GET", tt.uri, "", http.StatusOK, &posts)
+		if got := postIDs(posts); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("GET %s = %v, want %v", tt.uri, got, tt.want)
+		}
+	}
+
+	srv.mustFail("GET", fmt.Sprintf("/api/post/%d/tree?depth=-1", root), "", http.StatusUnprocessableEntity, "validation_failed")
+	srv.mustFail("GET", "/api/post/99/tree", "", http.StatusNotFound, "post_not_found")
+	srv.mustFail("GET", "/api/post/99/ancestors", "", http.StatusNotFound, "post_not_found")
+}
+
+type postNode struct {
+	ID          int64      `json:"id"`
+	Depth       int32      `json:"depth"`
+	ChildCount  int32      `json:"childCount"`
+	MoreReplies bool       `json:"moreReplies"`
+	Children    []postNode `json:"children"`
+}
+
+// shape renders nodes as "id(childCount)[children]", with a "+" after the
+// count when replies were cut off.
+func shape(nodes []postNode) string {
+	s := ""
+	for i, n := range nodes {
+		if i > 0 {
+			s += " "
+		}
+		s += fmt.Sprintf("%d(%d", n.ID, n.ChildCount)
+		if n.MoreReplies {
+			s += "+"
+		}
+		s += ")"
+		if len(n.Children) > 0 {
+			s += "[" + shape(n.Children) + "]"
+		}
+	}
+	return s
+}
+
+func TestNestedPostsHandler(t *testing.T) {
+	srv := newTestServer(t)
+	th := srv.thread("")
+	r1 := srv.post(th, 0, "alice", "r1")
+	a := srv.post(th, r1, "bob", "a")
+	a1 := srv.post(th, a, "alice", "a1")
+	a1x := srv.post(th, a1, "bob", "a1x")
+	b := srv.post(th, r1, "bob", "b")
+	r2 := srv.post(th, 0, "bob", "r2")
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"format=nested", fmt.Sprintf("%d(2)[%d(1)[%d(1)[%d(0)]] %d(0)] %d(0)", r1, a, a1, a1x, b, r2)},
+		{"format=nested&max_depth=1", fmt.Sprintf("%d(2)[%d(1+) %d(0)] %d(0)", r1, a, b, r2)},
+		{"format=nested&max_depth=0", fmt.Sprintf("%d(2+) %d(0)", r1, r2)},
+		{"format=nested&sort=parent_tree&limit=1&desc=true", fmt.Sprintf("%d(0)", r2)},
+	}
+	for _, tt := range tests {
+		var nodes []postNode
+		srv.decode("GET", fmt.Sprintf("/api/thread/%d/posts?%s", th, tt.query), "", http.StatusOK, &nodes)
+		if got := shape(nodes); got != tt.want {
+			t.Errorf("%s: tree = %s, want %s", tt.query, got, tt.want)
+		}
+	}
+
+	var nodes []postNode
+	srv.decode("GET", fmt.Sprintf("/api/thread/%d/posts?format=nested&max_depth=1", th), "", http.StatusOK, &nodes)
+	if d := nodes[0].Children[0].Depth; d != 1 {
+		t.Errorf("depth of a reply = %d, want 1", d)
+	}
+
+	for _, query := range []string{"format=nested&sort=flat", "format=nested&max_depth=x", "format=xml"} {
+		srv.mustFail("GET", fmt.Sprintf("/api/thread/%d/posts?%s", th, query), "", http.StatusUnprocessableEntity, "validation_failed")
+	}
+
+	var posts []models.Post
+	srv.decode("GET", fmt.Sprintf("/api/thread/%d/posts?format=flat&limit=2", th), "", http.StatusOK, &posts)
+	if got := postIDs(posts); fmt.Sprint(got) != fmt.Sprint([]int64{r1, a}) {
+		t.Errorf("flat posts = %v, want [%d %d]", got, r1, a)
+	}
+}
+
+func TestSplitPostHandler(t *testing.T) {
+	srv := newTestServer(t)
+	srv.thread("jolly")
+	th := srv.thread("")
+	root := srv.post(th, 0, "alice", "root")
+	target := srv.post(th, root, "bob", "split here")
+	child := srv.post(th, target, "alice", "child")
+
+	uri := fmt.Sprintf("/api/post/%d/split", target)
+	srv.mustFail("POST", uri, `{"moderator":"alice"}`, http.StatusUnprocessableEntity, "validation_failed")
+	srv.mustFail("POST", uri, `{"moderator":"bob","title":"new"}`, http.StatusForbidden, "not_moderator")
+	srv.mustFail("POST", uri, `{"moderator":"alice","title":"new","slug":"jolly"}`, http.StatusConflict, "thread_attribute_duplicate")
+
+	var thread models.Thread
+	srv.decode("POST", uri, `{"moderator":"alice","title":"new","slug":"split"}`, http.StatusCreated, &thread)
+	if thread.Author != "bob" || thread.Message != "split here" || thread.Forum != "pirate" {
+		t.Errorf("new thread = %+v, want the split post's author and message", thread)
+	}
+
+	var full struct {
+		Post models.Post `json:"post"`
+	}
+	srv.decode("GET", fmt.Sprintf("/api/post/%d/details", target), "", http.StatusOK, &full)
+	if p := full.Post; !p.IsDeleted || p.SplitTo != thread.ID || p.Thread != th {
+		t.Errorf("split post = %+v, want a tombstone pointing at thread %d", p, thread.ID)
+	}
+
+	var posts []models.Post
+	srv.decode("GET", "/api/thread/split/posts", "", http.StatusOK, &posts)
+	if got := postIDs(posts); !reflect.DeepEqual(got, []int64{child}) {
+		t.Errorf("new thread posts = %v, want [%d]", got, child)
+	}
+	srv.decode("GET", fmt.Sprintf("/api/thread/%d/posts", th), "", http.StatusOK, &posts)
+	if got := postIDs(posts); !reflect.DeepEqual(got, []int64{root, target}) {
+		t.Errorf("source thread posts = %v, want [%d %d]", got, root, target)
+	}
+
+	srv.mustFail("POST", uri, `{"moderator":"alice","title":"again"}`, http.StatusConflict, "post_deleted")
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"encoding/base64"
+	"fmt"
+	"github.com/valyala/fasthttp"
+	"net/http"
+	"strconv"
+	"strings"
+	"tp-project-db/consts"
+	"tp-project-db/models"
+	"tp-project-db/repositories"
+	"tp-project-db/validation"
+)
+
+const (
+	SearchDefaultLimit = 20
+	SearchMaxLimit     = 100
+)
+
+const (
+	InvalidTimestampErrMessage = "must be an RFC 3339 timestamp"
+	InvalidCursorErrMessage    = "is not a valid cursor"
+)
+
+func (srv *Server) search(ctx *fasthttp.RequestCtx) {
+	query := ctx.QueryArgs()
+	var v validation.Validator
+
+	args := repositories.SearchArgs{
+		Query:  string(query.Peek("q")),
+		Kind:   string(query.Peek("type")),
+		Forum:  string(query.Peek("forum")),
+		Author: string(query.Peek("author")),
+		Limit:  SearchDefaultLimit,
+	}
+	v.Required("q", args.Query)
+	if args.Kind != consts.EmptyString && args.Kind != models.SearchKindPost && args.Kind != models.SearchKindThread {
+		v.Fail("type", fmt.Sprintf("must be one of [%s %s]", models.SearchKindPost, models.SearchKindThread))
+	}
+	if thread := string(query.Peek("thread")); thread != consts.EmptyString {
+		args.Thread = parseSlugOrID(thread)
+	}
+
+	parseTimestampArg(query, "since", &args.Since, &v)
+	parseTimestampArg(query, "until", &args.Until, &v)
+
+	if limit, err := query.GetUint("limit"); err == nil && limit > 0 {
+		args.Limit = limit
+		if args.Limit > SearchMaxLimit {
+			args.Limit = SearchMaxLimit
+		}
+	}
+
+	if value := query.Peek("cursor"); len(value) != 0 {
+		cursor, ok := decodeSearchCursor(string(value))
+		if !ok {
+			v.Fail("cursor", InvalidCursorErrMessage)
+		}
+		args.After = cursor
+	}
+
+	if err := v.Err(); err != nil {
+		srv.WriteError(ctx, err)
+		return
+	}
+
+	results, err := srv.components.SearchRepository.Search(requestContext(ctx), &args)
+	if err != nil {
+		srv.WriteError(ctx, err)
+		return
+	}
+
+	if n := len(results.Results); n == args.Limit {
+		results.Next = models.NullString{
+			Valid:  true,
+			String: encodeSearchCursor(&results.Results[n-1]),
+		}
+	}
+
+	srv.WriteJSON(ctx, http.StatusOK, results)
+}
+
+func parseTimestampArg(query *fasthttp.Args, field string, ts *models.NullTimestamp, v *validation.Validator) {
+	value := query.Peek(field)
+	if len(value) == 0 {
+		return
+	}
+	if err := ts.Timestamp.UnmarshalText(value); err != nil {
+		v.Fail(field, InvalidTimestampErrMessage)
+		return
+	}
+	ts.Valid = true
+}
+
+func encodeSearchCursor(res *models.SearchResult) string {
+	rank := strconv.FormatFloat(float64(res.Rank), 'g', -1, 32)
+	return base64.RawURLEncoding.EncodeToString([]byte(rank + ":" + res.Kind + ":" + strconv.FormatInt(res.ID, 10)))
+}
+
+func decodeSearchCursor(s string) (*repositories.SearchCursor, bool) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, false
+	}
+
+	parts := strings.Split(string(b), ":")
+	if len(parts) != 3 {
+		return nil, false
+	}
+	rank, err := strconv.ParseFloat(parts[0], 32)
+	if err != nil {
+		return nil, false
+	}
+	id, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return nil, false
+	}
+
+	return &repositories.SearchCursor{
+		Rank: float32(rank),
+		Kind: parts[1],
+		ID:   id,
+	}, true
+}
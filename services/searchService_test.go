@@ -0,0 +1,66 @@
+package services
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+	"tp-project-db/models"
+)
+
+func TestSearchHandler(t *testing.T) {
+	srv := newTestServer(t)
+	th := srv.thread("")
+	once := srv.post(th, 0, "alice", "The parrot talks")
+	twice := srv.post(th, 0, "bob", "Parrot, parrot!")
+	srv.post(th, 0, "alice", "No birds here")
+
+	search := func(query string) models.SearchResults {
+		t.Helper()
+
+		var results models.SearchResults
+		srv.decode("GET", "/api/search?"+query, "", http.StatusOK, &results)
+		return results
+	}
+	ids := func(results models.SearchResults) []int64 {
+		ids := make([]int64, 0, len(results.Results))
+		for _, res := range results.Results {
+			ids = append(ids, res.ID)
+		}
+		return ids
+	}
+
+	if got := ids(search("q=parrot&type=post")); !reflect.DeepEqual(got, []int64{twice, once}) {
+		t.Errorf("q=parrot = %v, want [%d %d]", got, twice, once)
+	}
+	if got := ids(search(fmt.Sprintf("q=parrot&type=post&author=alice&thread=%d", th))); !reflect.DeepEqual(got, []int64{once}) {
+		t.Errorf("q=parrot by alice = %v, want [%d]", got, once)
+	}
+
+	first := search("q=parrot&type=post&limit=1")
+	if got := ids(first); !reflect.DeepEqual(got, []int64{twice}) || !first.Next.Valid {
+		t.Fatalf("first page = %v, next %+v, want [%d] and a cursor", got, first.Next, twice)
+	}
+	second := search("q=parrot&type=post&limit=1&cursor=" + url.QueryEscape(first.Next.String))
+	if got := ids(second); !reflect.DeepEqual(got, []int64{once}) {
+		t.Errorf("second page = %v, want [%d]", got, once)
+	}
+
+	tests := []struct {
+		query string
+		field string
+	}{
+		{"type=post", "q"},
+		{"q=parrot&type=forum", "type"},
+		{"q=parrot&since=yesterday", "since"},
+		{"q=parrot&cursor=!!", "cursor"},
+	}
+	for _, tt := range tests {
+		var e errorBody
+		srv.decode("GET", "/api/search?"+tt.query, "", http.StatusUnprocessableEntity, &e)
+		if e.Code != "validation_failed" || len(e.Details) != 1 || e.Details[0].Field != tt.field {
+			t.Errorf("search?%s error = %+v, want a problem with %s", tt.query, e, tt.field)
+		}
+	}
+}
@@ -1,107 +1,336 @@
 package services
 
 import (
+	"context"
+	"fmt"
 	"github.com/fasthttp/router"
-	"github.com/mailru/easyjson"
 	"github.com/valyala/fasthttp"
 	"log"
-	"net/http"
+	"net"
+	"runtime/debug"
+	"sort"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 	"tp-project-db/errs"
-	"tp-project-db/models"
-	"tp-project-db/repositories"
+	"tp-project-db/logging"
+	"tp-project-db/metrics"
+)
+
+const (
+	InternalErrMessage = "internal error"
+)
+
+const (
+	ShutdownTimeoutErrMessage = "shutdown deadline exceeded"
+	StorageNotReadyErrMessage = "storage is not initialized yet"
+)
+
+const (
+	MetricsPath    = "/metrics"
+	HealthPath     = "/healthz"
+	ReadinessPath  = "/readyz"
+	RouteUserValue = "route"
+	UnmatchedRoute = "unmatched"
+)
+
+var (
+	requestsTotal = metrics.DefaultRegistry.NewCounterVec(
+		"http_requests_total",
+		"Number of handled HTTP requests.",
+		"method", "route", "code",
+	)
+	requestDuration = metrics.DefaultRegistry.NewHistogramVec(
+		"http_request_duration_seconds",
+		"Time spent handling HTTP requests.",
+		metrics.DefaultBuckets,
+		"method", "route", "code",
+	)
 )
 
 type ServerConfig struct {
-	Host string
-	Port string
+	Host                 string
+	Port                 string
+	ShutdownTimeout      time.Duration
+	SlowRequestThreshold time.Duration
+	RequestTimeout       time.Duration
+	RouteTimeouts        map[string]time.Duration
+	EnableMetrics        bool
+	EnableAccessLog      bool
 }
 
 type ServerComponents struct {
-	UserRepository   *repositories.UserRepository
-	ForumRepository  *repositories.ForumRepository
-	ThreadRepository *repositories.ThreadRepository
-	PostRepository   *repositories.PostRepository
-	VoteRepository   *repositories.VoteRepository
-	StatusRepository *repositories.StatusRepository
+	UserRepository   UserRepository
+	ForumRepository  ForumRepository
+	ThreadRepository ThreadRepository
+	PostRepository   PostRepository
+	SearchRepository SearchRepository
+	VoteRepository   VoteRepository
+	StatusRepository StatusRepository
+	HealthRepository HealthRepository
 }
 
 type Server struct {
+	ready int32
+
 	handler fasthttp.RequestHandler
+	server  *fasthttp.Server
+
+	connsMtx *sync.Mutex
+	conns    map[net.Conn]fasthttp.ConnState
+	draining bool
+
+	// baseCtx is the parent of every request context; Shutdown cancels it
+	// once ShutdownTimeout has passed.
+	baseCtx        context.Context
+	cancelRequests context.CancelFunc
 
 	config     ServerConfig
 	components ServerComponents
+	routes     map[string]struct{}
 
-	rwMtx  *sync.RWMutex
-	status models.Status
-
-	commonErr []byte
+	internalErr *errs.Error
+	notReadyErr *errs.Error
 }
 
 func NewServer(config ServerConfig, components ServerComponents) *Server {
+	baseCtx, cancelRequests := context.WithCancel(context.Background())
 	srv := &Server{
 		config:     config,
 		components: components,
+		routes:     make(map[string]struct{}),
+
+		connsMtx: &sync.Mutex{},
+		conns:    make(map[net.Conn]fasthttp.ConnState),
 
-		status: models.Status{},
-		rwMtx:  &sync.RWMutex{},
+		baseCtx:        baseCtx,
+		cancelRequests: cancelRequests,
 
-		commonErr: func() []byte {
-			err := errs.NewError(http.StatusInternalServerError, "error")
-			b, _ := easyjson.Marshal(err)
-			return b
-		}(),
+		internalErr: errs.NewInternalError(InternalErrMessage),
+		notReadyErr: errs.NewUnavailableError(StorageNotReadyErrMessage),
 	}
 
 	r := router.New()
-	components.StatusRepository.GetStatus(&srv.status)
-
-	r.POST("/api/forum/:slug/create", srv.createThread)
-	r.GET("/api/forum/:slug/details", withTM("findForum", srv.findForum))
-	r.GET("/api/forum/:slug/threads", withTM("findThreadsByForum", srv.findThreadsByForum))
-	r.GET("/api/forum/:slug/users", withTM("findUsersByForum", srv.findUsersByForum))
-	r.GET("/api/post/:id/details", withTM("findPost",srv.findPost))
-	r.POST("/api/post/:id/details", srv.updatePost)
-	r.POST("/api/thread/:slug_or_id/create", srv.createPosts)
-	r.POST("/api/thread/:slug_or_id/vote", srv.addVote)
-	r.GET("/api/thread/:slug_or_id/details", withTM("findThread", srv.findThread))
-	r.GET("/api/thread/:slug_or_id/posts", srv.findPostsByThread)
-	r.POST("/api/thread/:slug_or_id/details", srv.updateThread)
-	r.POST("/api/user/:nickname/create", srv.createUser)
-	r.GET("/api/user/:nickname/profile", withTM("findUser",srv.findUser))
-	r.POST("/api/user/:nickname/profile", srv.updateUser)
-	r.POST("/api/service/clear", srv.clearDatabase)
-	r.GET("/api/service/status", srv.getStatus)
-
-	srv.handler = func(r *router.Router) fasthttp.RequestHandler {
+
+	srv.handle(r, "POST", "/api/forum/:slug/create", srv.createThread)
+	srv.handle(r, "GET", "/api/forum/:slug/details", srv.withTM("findForum", srv.findForum))
+	srv.handle(r, "GET", "/api/forum/:slug/threads", srv.withTM("findThreadsByForum", srv.findThreadsByForum))
+	srv.handle(r, "GET", "/api/forum/:slug/users", srv.withTM("findUsersByForum", srv.findUsersByForum))
+	srv.handle(r, "POST", "/api/forum/:slug/search-config", srv.updateForumSearchConfig)
+	srv.handle(r, "GET", "/api/post/:id/details", srv.withTM("findPost", srv.findPost))
+	srv.handle(r, "POST", "/api/post/:id/details", srv.updatePost)
+	srv.handle(r, "DELETE", "/api/post/:id", srv.deletePost)
+	srv.handle(r, "GET", "/api/post/:id/tree", srv.withTM("findPostSubtree", srv.findPostSubtree))
+	srv.handle(r, "GET", "/api/post/:id/ancestors", srv.withTM("findPostAncestors", srv.findPostAncestors))
+	srv.handle(r, "GET", "/api/post/:id/revisions", srv.findPostRevisions)
+	srv.handle(r, "GET", "/api/post/:id/revisions/diff", srv.diffPostRevisions)
+	srv.handle(r, "POST", "/api/post/:id/split", srv.splitPost)
+	srv.handle(r, "POST", "/api/thread/:slug_or_id/create", srv.createPosts)
+	srv.handle(r, "POST", "/api/thread/:slug_or_id/vote", srv.addVote)
+	srv.handle(r, "GET", "/api/thread/:slug_or_id/details", srv.withTM("findThread", srv.findThread))
+	srv.handle(r, "GET", "/api/thread/:slug_or_id/posts", srv.findPostsByThread)
+	srv.handle(r, "POST", "/api/thread/:slug_or_id/details", srv.updateThread)
+	srv.handle(r, "DELETE", "/api/thread/:slug_or_id", srv.deleteThread)
+	srv.handle(r, "POST", "/api/thread/:slug_or_id/restore", srv.restoreThread)
+	srv.handle(r, "POST", "/api/thread/:slug_or_id/archive", srv.archiveThread)
+	srv.handle(r, "POST", "/api/thread/:slug_or_id/unarchive", srv.unarchiveThread)
+	srv.handle(r, "POST", "/api/thread/:slug_or_id/moderate", srv.moderateThread)
+	srv.handle(r, "POST", "/api/thread/:slug_or_id/pin", srv.pinThread)
+	srv.handle(r, "POST", "/api/thread/:slug_or_id/move", srv.moveThread)
+	srv.handle(r, "POST", "/api/thread/:slug_or_id/merge", srv.mergeThread)
+	srv.handle(r, "GET", "/api/search", srv.withTM("search", srv.search))
+	srv.handle(r, "POST", "/api/user/:nickname/create", srv.createUser)
+	srv.handle(r, "GET", "/api/user/:nickname/profile", srv.withTM("findUser", srv.findUser))
+	srv.handle(r, "POST", "/api/user/:nickname/profile", srv.updateUser)
+	srv.handle(r, "GET", "/api/user/:nickname/posts", srv.withTM("findPostsByAuthor", srv.findPostsByAuthor))
+	srv.handle(r, "GET", "/api/user/:nickname/threads", srv.withTM("findThreadsByAuthor", srv.findThreadsByAuthor))
+	srv.handle(r, "POST", "/api/service/clear", srv.clearDatabase)
+	srv.handle(r, "GET", "/api/service/status", srv.getStatus)
+	srv.handle(r, "GET", HealthPath, srv.getHealth)
+	srv.handle(r, "GET", ReadinessPath, srv.getReadiness)
+	if config.EnableMetrics {
+		srv.handle(r, "GET", MetricsPath, srv.getMetrics)
+	}
+
+	createForum := srv.withRoute("/api/forum/create", srv.createForum)
+	srv.handler = srv.withRecover(func(r *router.Router) fasthttp.RequestHandler {
 		return func(ctx *fasthttp.RequestCtx) {
 			if string(ctx.Path()) == "/api/forum/create" {
-				srv.createForum(ctx)
+				createForum(ctx)
 				return
 			}
 			r.Handler(ctx)
 		}
-	}(r)
+	}(r))
+	srv.handler = srv.withStorageReady(srv.handler)
+	if config.EnableMetrics {
+		srv.handler = withMetrics(srv.handler)
+	}
+	if config.EnableAccessLog {
+		srv.handler = withAccessLog(srv.handler)
+	}
+	srv.handler = withRequestContext(srv.baseCtx, srv.handler)
+
+	srv.server = &fasthttp.Server{
+		Handler:   srv.handler,
+		ConnState: srv.trackConn,
+	}
 	return srv
 }
 
 func (srv *Server) Run() error {
-	addr := ":" + srv.config.Port
-	return fasthttp.ListenAndServe(addr, srv.handler)
+	addr := srv.config.Host + ":" + srv.config.Port
+	return srv.server.ListenAndServe(addr)
+}
+
+func (srv *Server) Routes() []string {
+	routes := make([]string, 0, len(srv.routes))
+	for route := range srv.routes {
+		routes = append(routes, route)
+	}
+	sort.Strings(routes)
+	return routes
+}
+
+func (srv *Server) MarkReady() {
+	atomic.StoreInt32(&srv.ready, 1)
 }
 
 func (srv *Server) Shutdown() error {
-	return nil //TODO
+	srv.connsMtx.Lock()
+	srv.draining = true
+	for c, state := range srv.conns {
+		switch state {
+		case fasthttp.StateIdle:
+			_ = c.Close()
+		case fasthttp.StateNew:
+			// fasthttp leaves a connection in StateNew while its first request
+			// is being handled, so only stop it from reading another one.
+			_ = c.SetReadDeadline(time.Now())
+		}
+	}
+	srv.connsMtx.Unlock()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.server.Shutdown()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(srv.config.ShutdownTimeout):
+	}
+
+	// Returning now would let the caller close the storage under the
+	// remaining handlers, so cancel their contexts and wait for them instead.
+	srv.cancelRequests()
+	<-done
+	return errs.NewUnavailableError(ShutdownTimeoutErrMessage)
+}
+
+func (srv *Server) trackConn(c net.Conn, state fasthttp.ConnState) {
+	srv.connsMtx.Lock()
+	defer srv.connsMtx.Unlock()
+
+	switch state {
+	case fasthttp.StateClosed, fasthttp.StateHijacked:
+		delete(srv.conns, c)
+	case fasthttp.StateIdle:
+		if srv.draining {
+			_ = c.Close()
+		}
+		srv.conns[c] = state
+	default:
+		srv.conns[c] = state
+	}
+}
+
+func (srv *Server) withRecover(h fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			logging.Default.Error("panic", logging.Fields{
+				logging.RequestIDField: requestID(ctx),
+				"panic":                fmt.Sprint(rec),
+				"stack":                string(debug.Stack()),
+			})
+
+			err, ok := rec.(*errs.Error)
+			if !ok || err.Category != errs.CategoryUnavailable {
+				err = srv.internalErr
+			}
+			srv.WriteJSON(ctx, err.HttpStatus, err)
+		}()
+		h(ctx)
+	}
+}
+
+func (srv *Server) withStorageReady(h fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		if atomic.LoadInt32(&srv.ready) == 0 {
+			path := string(ctx.Path())
+			if path != HealthPath && path != ReadinessPath {
+				srv.WriteError(ctx, srv.notReadyErr)
+				return
+			}
+		}
+		h(ctx)
+	}
+}
+
+func (srv *Server) handle(r *router.Router, method, path string, h fasthttp.RequestHandler) {
+	r.Handle(method, path, srv.withRoute(path, h))
+}
+
+func (srv *Server) withRoute(path string, h fasthttp.RequestHandler) fasthttp.RequestHandler {
+	srv.routes[path] = struct{}{}
+
+	timeout, ok := srv.config.RouteTimeouts[path]
+	if !ok {
+		timeout = srv.config.RequestTimeout
+	}
+
+	return func(ctx *fasthttp.RequestCtx) {
+		ctx.SetUserValue(RouteUserValue, path)
+
+		reqCtx, cancel := context.WithTimeout(requestContext(ctx), timeout)
+		defer cancel()
+		ctx.SetUserValue(ContextUserValue, reqCtx)
+
+		h(ctx)
+	}
+}
+
+func withMetrics(h fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		t1 := time.Now()
+		h(ctx)
+		dt := time.Since(t1)
+
+		route, ok := ctx.UserValue(RouteUserValue).(string)
+		if !ok {
+			route = UnmatchedRoute
+		}
+		method := string(ctx.Method())
+		code := strconv.Itoa(ctx.Response.StatusCode())
+
+		requestsTotal.Inc(method, route, code)
+		requestDuration.Observe(dt.Seconds(), method, route, code)
+	}
 }
 
-func withTM(name string, h fasthttp.RequestHandler) fasthttp.RequestHandler {
+func (srv *Server) withTM(name string, h fasthttp.RequestHandler) fasthttp.RequestHandler {
 	return func(ctx *fasthttp.RequestCtx) {
 		t1 := time.Now()
 		h(ctx)
 		t2 := time.Now()
 		dt := t2.Sub(t1)
-		if dt > time.Millisecond  * 100 {
+		if dt > srv.config.SlowRequestThreshold {
 			log.Println(name, ":", t2.Sub(t1))
 		}
 	}
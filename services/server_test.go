@@ -0,0 +1,379 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/valyala/fasthttp"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+	"tp-project-db/errs"
+	"tp-project-db/logging"
+	"tp-project-db/models"
+	"tp-project-db/repositories/memory"
+)
+
+func newMemoryServer(config ServerConfig) *Server {
+	storage := memory.NewStorage()
+
+	if config.RequestTimeout == 0 {
+		config.RequestTimeout = time.Second
+	}
+	if config.SlowRequestThreshold == 0 {
+		config.SlowRequestThreshold = time.Second
+	}
+	if config.ShutdownTimeout == 0 {
+		config.ShutdownTimeout = time.Second
+	}
+	return NewServer(config, ServerComponents{
+		UserRepository:   memory.NewUserRepository(storage),
+		ForumRepository:  memory.NewForumRepository(storage),
+		ThreadRepository: memory.NewThreadRepository(storage),
+		PostRepository:   memory.NewPostRepository(storage),
+		SearchRepository: memory.NewSearchRepository(storage),
+		VoteRepository:   memory.NewVoteRepository(storage),
+		StatusRepository: memory.NewStatusRepository(storage),
+		HealthRepository: memory.NewHealthRepository(storage),
+	})
+}
+
+// testServer is a ready server on the memory backend with users alice and bob
+// and the forum "pirate" administered by alice.
+type testServer struct {
+	*Server
+	t *testing.T
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+
+	srv := &testServer{Server: newMemoryServer(ServerConfig{}), t: t}
+	srv.MarkReady()
+
+	srv.must("POST", "/api/user/alice/create", `{"fullname":"Alice","email":"alice@example.com"}`, http.StatusCreated)
+	srv.must("POST", "/api/user/bob/create", `{"fullname":"Bob","email":"bob@example.com"}`, http.StatusCreated)
+	srv.must("POST", "/api/forum/create", `{"slug":"pirate","title":"Pirates","user":"alice"}`, http.StatusCreated)
+	return srv
+}
+
+func (srv *Server) do(method, uri, body string) (int, []byte) {
+	var ctx fasthttp.RequestCtx
+	ctx.Request.Header.SetMethod(method)
+	ctx.Request.SetRequestURI(uri)
+	ctx.Request.SetBodyString(body)
+
+	srv.handler(&ctx)
+	return ctx.Response.StatusCode(), append([]byte(nil), ctx.Response.Body()...)
+}
+
+// must performs the request, fails the test unless it answers with status and
+// returns the body.
+func (srv *testServer) must(method, uri, body string, status int) []byte {
+	srv.t.Helper()
+
+	got, b := srv.do(method, uri, body)
+	if got != status {
+		srv.t.Fatalf("%s %s = %d %s, want %d", method, uri, got, b, status)
+	}
+	return b
+}
+
+// decode performs the request like must and unmarshals the body into v.
+func (srv *testServer) decode(method, uri, body string, status int, v interface{}) {
+	srv.t.Helper()
+
+	b := srv.must(method, uri, body, status)
+	if err := json.Unmarshal(b, v); err != nil {
+		srv.t.Fatalf("%s %s: %v in %s", method, uri, err, b)
+	}
+}
+
+type errorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Entity  string `json:"entity"`
+	Field   string `json:"field"`
+	Reason  string `json:"reason"`
+	Details []struct {
+		Field string `json:"field"`
+	} `json:"details"`
+}
+
+func (srv *testServer) mustFail(method, uri, body string, status int, code string) {
+	srv.t.Helper()
+
+	var e errorBody
+	srv.decode(method, uri, body, status, &e)
+	if e.Code != code {
+		srv.t.Errorf("%s %s error code = %q, want %q", method, uri, e.Code, code)
+	}
+}
+
+func TestStorageReadyGate(t *testing.T) {
+	srv := newMemoryServer(ServerConfig{})
+
+	if status, b := srv.do("GET", "/api/service/status", ""); status != http.StatusServiceUnavailable {
+		t.Errorf("status before MarkReady = %d %s, want 503", status, b)
+	}
+	if status, _ := srv.do("POST", "/api/forum/create", `{}`); status != http.StatusServiceUnavailable {
+		t.Errorf("forum create before MarkReady = %d, want 503", status)
+	}
+	for _, path := range []string{HealthPath, ReadinessPath} {
+		if status, b := srv.do("GET", path, ""); status != http.StatusOK {
+			t.Errorf("%s before MarkReady = %d %s, want it exempt", path, status, b)
+		}
+	}
+
+	srv.MarkReady()
+	if status, b := srv.do("GET", "/api/service/status", ""); status != http.StatusOK {
+		t.Errorf("status after MarkReady = %d %s, want 200", status, b)
+	}
+}
+
+func TestRecoverFromPanic(t *testing.T) {
+	srv := newMemoryServer(ServerConfig{})
+	logging.Default.Configure(ioutil.Discard, logging.LevelInfo)
+	defer logging.Default.Configure(os.Stderr, logging.LevelInfo)
+
+	tests := []struct {
+		name   string
+		panic  interface{}
+		status int
+	}{
+		{"plain panic", "boom", http.StatusInternalServerError},
+		{"internal error", errs.NewInternalError("secret detail"), http.StatusInternalServerError},
+		{"unavailable error", errs.NewUnavailableError("database unavailable"), http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		h := srv.withRecover(func(ctx *fasthttp.RequestCtx) {
+			panic(tt.panic)
+		})
+
+		var ctx fasthttp.RequestCtx
+		h(&ctx)
+
+		var e errorBody
+		if err := json.Unmarshal(ctx.Response.Body(), &e); err != nil {
+			t.Fatalf("%s: body %s: %v", tt.name, ctx.Response.Body(), err)
+		}
+		if ctx.Response.StatusCode() != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.name, ctx.Response.StatusCode(), tt.status)
+		}
+		if tt.status == http.StatusInternalServerError && e.Message != InternalErrMessage {
+			t.Errorf("%s: message = %q, want the generic %q", tt.name, e.Message, InternalErrMessage)
+		}
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	srv := newTestServer(t)
+
+	var ctx fasthttp.RequestCtx
+	ctx.Request.Header.SetMethod("GET")
+	ctx.Request.SetRequestURI("/api/service/status")
+	ctx.Request.Header.Set(logging.RequestIDHeader, "req-42")
+	srv.handler(&ctx)
+	if id := string(ctx.Response.Header.Peek(logging.RequestIDHeader)); id != "req-42" {
+		t.Errorf("response request ID = %q, want the one sent", id)
+	}
+
+	ctx = fasthttp.RequestCtx{}
+	ctx.Request.SetRequestURI("/api/service/status")
+	srv.handler(&ctx)
+	if id := string(ctx.Response.Header.Peek(logging.RequestIDHeader)); len(id) != 32 {
+		t.Errorf("generated request ID = %q", id)
+	}
+}
+
+func TestRouteTimeouts(t *testing.T) {
+	srv := newMemoryServer(ServerConfig{
+		RequestTimeout: time.Second,
+		RouteTimeouts:  map[string]time.Duration{"/api/search": time.Minute},
+	})
+
+	deadline := func(route string) time.Duration {
+		var remaining time.Duration
+		h := srv.withRoute(route, func(ctx *fasthttp.RequestCtx) {
+			d, ok := requestContext(ctx).Deadline()
+			if !ok {
+				t.Fatalf("%s: request context has no deadline", route)
+			}
+			remaining = time.Until(d)
+		})
+
+		var ctx fasthttp.RequestCtx
+		h(&ctx)
+		return remaining
+	}
+
+	if d := deadline("/api/search"); d <= time.Second || d > time.Minute {
+		t.Errorf("/api/search deadline in %v, want the 1m route timeout", d)
+	}
+	if d := deadline("/api/service/status"); d <= 0 || d > time.Second {
+		t.Errorf("/api/service/status deadline in %v, want the 1s default", d)
+	}
+
+	routes := srv.Routes()
+	for _, route := range []string{"/api/search", "/api/forum/create", "/api/post/:id/tree", HealthPath} {
+		i := sort.SearchStrings(routes, route)
+		if i == len(routes) || routes[i] != route {
+			t.Errorf("Routes() = %v, missing %s", routes, route)
+		}
+	}
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	srv := newMemoryServer(ServerConfig{EnableMetrics: true})
+	srv.MarkReady()
+
+	srv.do("GET", "/api/user/nobody/profile", "")
+	srv.do("GET", "/no/such/route", "")
+
+	status, b := srv.do("GET", MetricsPath, "")
+	if status != http.StatusOK {
+		t.Fatalf("GET %s = %d", MetricsPath, status)
+	}
+	for _, want := range []string{
+		`http_requests_total{method="GET",route="/api/user/:nickname/profile",code="404"}`,
+		`http_requests_total{method="GET",route="unmatched",code="404"}`,
+		`http_request_duration_seconds_count{method="GET",route="/api/user/:nickname/profile",code="404"}`,
+	} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("metrics are missing %s", want)
+		}
+	}
+
+	srv = newMemoryServer(ServerConfig{})
+	srv.MarkReady()
+	if status, _ := srv.do("GET", MetricsPath, ""); status != http.StatusNotFound {
+		t.Errorf("GET %s with metrics disabled = %d, want 404", MetricsPath, status)
+	}
+}
+
+func TestShutdownDrainsInFlightRequests(t *testing.T) {
+	srv := newMemoryServer(ServerConfig{ShutdownTimeout: 5 * time.Second})
+	srv.MarkReady()
+
+	started, release := make(chan struct{}), make(chan struct{})
+	handler := srv.server.Handler
+	srv.server.Handler = func(ctx *fasthttp.RequestCtx) {
+		if string(ctx.Path()) == "/api/service/status" {
+			close(started)
+			<-release
+		}
+		handler(ctx)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.server.Serve(ln)
+
+	type result struct {
+		status int
+		err    error
+	}
+	// A connection that never sends a request must not hold up the drain.
+	idle, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idle.Close()
+
+	requestDone := make(chan result, 1)
+	go func() {
+		status, _, err := fasthttp.Get(nil, "http://"+ln.Addr().String()+"/api/service/status")
+		requestDone <- result{status, err}
+	}()
+	<-started
+
+	shutdownDone := make(chan error, 1)
+	go func() {
+		shutdownDone <- srv.Shutdown()
+	}()
+
+	select {
+	case err := <-shutdownDone:
+		t.Fatalf("Shutdown() = %v before the in-flight request finished", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	close(release)
+	if res := <-requestDone; res.err != nil || res.status != http.StatusOK {
+		t.Errorf("in-flight request = %d, %v, want 200", res.status, res.err)
+	}
+	select {
+	case err := <-shutdownDone:
+		if err != nil {
+			t.Errorf("Shutdown() = %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Error("Shutdown() did not return after the in-flight request finished")
+	}
+}
+
+// stuckStatusRepository blocks GetStatus until the request context is done.
+type stuckStatusRepository struct {
+	StatusRepository
+	started  chan struct{}
+	finished chan struct{}
+}
+
+func (r *stuckStatusRepository) GetStatus(ctx context.Context, status *models.Status) *errs.Error {
+	close(r.started)
+	<-ctx.Done()
+
+	// Give a handler that ignored the deadline a chance to be caught out.
+	time.Sleep(50 * time.Millisecond)
+	close(r.finished)
+	return errs.NewUnavailableError("canceled")
+}
+
+func TestShutdownWaitsForHandlersAfterTimeout(t *testing.T) {
+	srv := newMemoryServer(ServerConfig{ShutdownTimeout: 100 * time.Millisecond, RequestTimeout: time.Minute})
+	srv.MarkReady()
+
+	repo := &stuckStatusRepository{
+		StatusRepository: srv.components.StatusRepository,
+		started:          make(chan struct{}),
+		finished:         make(chan struct{}),
+	}
+	srv.components.StatusRepository = repo
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.server.Serve(ln)
+
+	requestDone := make(chan int, 1)
+	go func() {
+		status, _, _ := fasthttp.Get(nil, "http://"+ln.Addr().String()+"/api/service/status")
+		requestDone <- status
+	}()
+	<-repo.started
+
+	start := time.Now()
+	err = srv.Shutdown()
+	if e, ok := err.(*errs.Error); !ok || e.Message != ShutdownTimeoutErrMessage {
+		t.Errorf("Shutdown() = %v, want %s", err, ShutdownTimeoutErrMessage)
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("Shutdown() took %v, want the stuck request canceled", d)
+	}
+
+	select {
+	case <-repo.finished:
+	default:
+		t.Fatal("Shutdown() returned while a handler was still running")
+	}
+	if status := <-requestDone; status != http.StatusServiceUnavailable {
+		t.Errorf("canceled request = %d, want 503", status)
+	}
+}
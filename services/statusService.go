@@ -3,22 +3,21 @@ package services
 import (
 	"github.com/valyala/fasthttp"
 	"net/http"
+	"tp-project-db/models"
 )
 
 func (srv *Server) getStatus(ctx *fasthttp.RequestCtx) {
-	srv.rwMtx.RLock()
-	status := srv.status
-	srv.rwMtx.RUnlock()
+	var status models.Status
+	if err := srv.components.StatusRepository.GetStatus(requestContext(ctx), &status); err != nil {
+		srv.WriteError(ctx, err)
+		return
+	}
 	srv.WriteJSON(ctx, http.StatusOK, &status)
 }
 
 func (srv *Server) clearDatabase(ctx *fasthttp.RequestCtx) {
-	srv.components.StatusRepository.ClearDatabase()
-
-	srv.rwMtx.RLock()
-	srv.status.NumUsers = 0
-	srv.status.NumForums = 0
-	srv.status.NumThreads = 0
-	srv.status.NumPosts = 0
-	srv.rwMtx.RUnlock()
+	if err := srv.components.StatusRepository.ClearDatabase(requestContext(ctx)); err != nil {
+		srv.WriteError(ctx, err)
+		return
+	}
 }
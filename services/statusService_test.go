@@ -0,0 +1,25 @@
+package services
+
+import (
+	"net/http"
+	"testing"
+	"tp-project-db/models"
+)
+
+func TestStatus(t *testing.T) {
+	srv := newTestServer(t)
+	srv.must("POST", "/api/forum/pirate/create", `{"author":"bob","title":"t","message":"m"}`, http.StatusCreated)
+	srv.must("POST", "/api/thread/1/create", `[{"author":"alice","message":"a"},{"author":"bob","message":"b"}]`, http.StatusCreated)
+
+	var status models.Status
+	srv.decode("GET", "/api/service/status", "", http.StatusOK, &status)
+	if status != (models.Status{NumUsers: 2, NumForums: 1, NumThreads: 1, NumPosts: 2}) {
+		t.Errorf("status = %+v", status)
+	}
+
+	srv.must("POST", "/api/service/clear", "", http.StatusOK)
+	srv.decode("GET", "/api/service/status", "", http.StatusOK, &status)
+	if status != (models.Status{}) {
+		t.Errorf("status after clear = %+v, want zeros", status)
+	}
+}
@@ -2,57 +2,63 @@ package services
 
 import (
 	"database/sql"
+	"github.com/go-openapi/strfmt"
 	"github.com/valyala/fasthttp"
 	"net/http"
 	"strconv"
+	"time"
+	"tp-project-db/errs"
 	"tp-project-db/models"
 	"tp-project-db/repositories"
+	"tp-project-db/validation"
 )
 
 func (srv *Server) createThread(ctx *fasthttp.RequestCtx) {
 	var thread models.Thread
-	srv.ReadBody(ctx, &thread)
+	if err := srv.ReadBody(ctx, &thread); err != nil {
+		srv.WriteError(ctx, err)
+		return
+	}
 
 	thread.Forum = ctx.UserValue("slug").(string)
+	if err := validation.ValidateThread(&thread); err != nil {
+		srv.WriteError(ctx, err)
+		return
+	}
 
 	var existing sql.NullString
-	status := srv.components.ThreadRepository.CreateThread(&thread, &existing)
-
-	if status == http.StatusCreated {
-		srv.rwMtx.Lock()
-		srv.status.NumThreads++
-		srv.rwMtx.Unlock()
+	status, err := srv.components.ThreadRepository.CreateThread(requestContext(ctx), &thread, &existing)
+	if err != nil {
+		srv.WriteError(ctx, err)
+		return
 	}
 
-	if existing.Valid {
-		ctx.SetStatusCode(status)
-		ctx.Response.Header.SetContentType(JsonType)
-		ctx.Response.SetBody([]byte(existing.String))
-	} else {
-		srv.WriteError(ctx, status)
-	}
+	ctx.SetStatusCode(status)
+	ctx.Response.Header.SetContentType(JsonType)
+	ctx.Response.SetBody([]byte(existing.String))
 }
 
 func (srv *Server) findThread(ctx *fasthttp.RequestCtx) {
 	slugOrID := ctx.UserValue("slug_or_id").(string)
-	id, err := strconv.ParseInt(slugOrID, 10, 32)
+	id, parErr := strconv.ParseInt(slugOrID, 10, 32)
 
-	var status int
+	var err *errs.Error
 	var existing string
 
-	if err == nil {
-		status = srv.components.ThreadRepository.FindThreadByID(int32(id), &existing)
+	if parErr == nil {
+		err = srv.components.ThreadRepository.FindThreadByID(requestContext(ctx), int32(id), &existing)
 	} else {
-		status = srv.components.ThreadRepository.FindThreadBySlug(&slugOrID, &existing)
+		err = srv.components.ThreadRepository.FindThreadBySlug(requestContext(ctx), &slugOrID, &existing)
 	}
 
-	if status == http.StatusOK {
-		ctx.SetStatusCode(status)
-		ctx.Response.Header.SetContentType(JsonType)
-		ctx.Response.SetBody([]byte(existing))
-	} else {
-		srv.WriteError(ctx, status)
+	if err != nil {
+		srv.WriteError(ctx, err)
+		return
 	}
+
+	ctx.SetStatusCode(http.StatusOK)
+	ctx.Response.Header.SetContentType(JsonType)
+	ctx.Response.SetBody([]byte(existing))
 }
 
 func (srv *Server) findThreadsByForum(ctx *fasthttp.RequestCtx) {
@@ -71,14 +77,15 @@ func (srv *Server) findThreadsByForum(ctx *fasthttp.RequestCtx) {
 	}
 
 	args := repositories.ForumThreadsSearchArgs{
-		Forum: ctx.UserValue("slug").(string),
-		Since: since,
-		Desc:  ctx.QueryArgs().GetBool("desc"),
-		Limit: limit,
+		Forum:         ctx.UserValue("slug").(string),
+		Since:         since,
+		Desc:          ctx.QueryArgs().GetBool("desc"),
+		Limit:         limit,
+		ExcludePinned: ctx.QueryArgs().GetBool("excludePinned"),
 	}
-	threads, searchErr := srv.components.ThreadRepository.FindThreadsByForum(&args)
+	threads, searchErr := srv.components.ThreadRepository.FindThreadsByForum(requestContext(ctx), &args)
 	if searchErr != nil {
-		srv.WriteError(ctx, searchErr.HttpStatus)
+		srv.WriteError(ctx, searchErr)
 		return
 	}
 
@@ -87,7 +94,14 @@ func (srv *Server) findThreadsByForum(ctx *fasthttp.RequestCtx) {
 
 func (srv *Server) updateThread(ctx *fasthttp.RequestCtx) {
 	var threadUpdate models.ThreadUpdate
-	srv.ReadBody(ctx, &threadUpdate)
+	if err := srv.ReadBody(ctx, &threadUpdate); err != nil {
+		srv.WriteError(ctx, err)
+		return
+	}
+	if err := validation.ValidateThreadUpdate(&threadUpdate); err != nil {
+		srv.WriteError(ctx, err)
+		return
+	}
 
 	thread := models.Thread{
 		Title:   threadUpdate.Title,
@@ -98,8 +112,8 @@ func (srv *Server) updateThread(ctx *fasthttp.RequestCtx) {
 	id, err := strconv.ParseInt(slug, 10, 32)
 	if err == nil {
 		thread.ID = int32(id)
-		if err := srv.components.ThreadRepository.UpdateThreadByID(&thread); err != nil {
-			srv.WriteError(ctx, err.HttpStatus)
+		if err := srv.components.ThreadRepository.UpdateThreadByID(requestContext(ctx), &thread); err != nil {
+			srv.WriteError(ctx, err)
 			return
 		}
 	} else {
@@ -107,11 +121,204 @@ func (srv *Server) updateThread(ctx *fasthttp.RequestCtx) {
 			Valid:  true,
 			String: slug,
 		}
-		if err := srv.components.ThreadRepository.UpdateThreadBySlug(&thread); err != nil {
-			srv.WriteError(ctx, err.HttpStatus)
+		if err := srv.components.ThreadRepository.UpdateThreadBySlug(requestContext(ctx), &thread); err != nil {
+			srv.WriteError(ctx, err)
 			return
 		}
 	}
 
 	srv.WriteJSON(ctx, http.StatusOK, &thread)
 }
+
+func (srv *Server) deleteThread(ctx *fasthttp.RequestCtx) {
+	thread := threadBySlugOrID(ctx)
+
+	moderator := string(ctx.QueryArgs().Peek("moderator"))
+	if err := validation.ValidateModerator(moderator); err != nil {
+		srv.WriteError(ctx, err)
+		return
+	}
+
+	if ctx.QueryArgs().GetBool("hard") {
+		deletion := models.ThreadDeletion{
+			ID:   thread.ID,
+			Slug: thread.Slug,
+		}
+		if err := srv.components.ThreadRepository.PurgeThread(requestContext(ctx), &deletion, moderator); err != nil {
+			srv.WriteError(ctx, err)
+			return
+		}
+
+		srv.WriteJSON(ctx, http.StatusOK, &deletion)
+		return
+	}
+
+	if err := srv.components.ThreadRepository.DeleteThread(requestContext(ctx), &thread, moderator); err != nil {
+		srv.WriteError(ctx, err)
+		return
+	}
+
+	srv.WriteJSON(ctx, http.StatusOK, &thread)
+}
+
+func (srv *Server) restoreThread(ctx *fasthttp.RequestCtx) {
+	moderator := string(ctx.QueryArgs().Peek("moderator"))
+	if err := validation.ValidateModerator(moderator); err != nil {
+		srv.WriteError(ctx, err)
+		return
+	}
+
+	thread := threadBySlugOrID(ctx)
+	if err := srv.components.ThreadRepository.RestoreThread(requestContext(ctx), &thread, moderator); err != nil {
+		srv.WriteError(ctx, err)
+		return
+	}
+
+	srv.WriteJSON(ctx, http.StatusOK, &thread)
+}
+
+func (srv *Server) archiveThread(ctx *fasthttp.RequestCtx) {
+	srv.setThreadArchived(ctx, true)
+}
+
+func (srv *Server) unarchiveThread(ctx *fasthttp.RequestCtx) {
+	srv.setThreadArchived(ctx, false)
+}
+
+func (srv *Server) setThreadArchived(ctx *fasthttp.RequestCtx, archived bool) {
+	moderator := string(ctx.QueryArgs().Peek("moderator"))
+	if err := validation.ValidateModerator(moderator); err != nil {
+		srv.WriteError(ctx, err)
+		return
+	}
+
+	thread := threadBySlugOrID(ctx)
+	if err := srv.components.ThreadRepository.ArchiveThread(requestContext(ctx), &thread, archived, moderator); err != nil {
+		srv.WriteError(ctx, err)
+		return
+	}
+
+	srv.WriteJSON(ctx, http.StatusOK, &thread)
+}
+
+func (srv *Server) moderateThread(ctx *fasthttp.RequestCtx) {
+	var moderation models.ThreadModeration
+	if err := srv.ReadBody(ctx, &moderation); err != nil {
+		srv.WriteError(ctx, err)
+		return
+	}
+	if err := validation.ValidateThreadModeration(&moderation); err != nil {
+		srv.WriteError(ctx, err)
+		return
+	}
+
+	args := repositories.CloseThreadArgs{
+		Moderator: moderation.Moderator,
+		Closed:    moderation.Closed,
+		Reason:    moderation.Reason,
+		Timestamp: strfmt.DateTime(time.Now()),
+	}
+
+	thread := threadBySlugOrID(ctx)
+	if err := srv.components.ThreadRepository.CloseThread(requestContext(ctx), &thread, &args); err != nil {
+		srv.WriteError(ctx, err)
+		return
+	}
+
+	srv.WriteJSON(ctx, http.StatusOK, &thread)
+}
+
+func (srv *Server) pinThread(ctx *fasthttp.RequestCtx) {
+	var pin models.ThreadPin
+	if err := srv.ReadBody(ctx, &pin); err != nil {
+		srv.WriteError(ctx, err)
+		return
+	}
+	if err := validation.ValidateThreadPin(&pin); err != nil {
+		srv.WriteError(ctx, err)
+		return
+	}
+
+	args := repositories.PinThreadArgs{
+		Moderator: pin.Moderator,
+		Pinned:    pin.Pinned,
+		Until:     pin.Until,
+	}
+
+	thread := threadBySlugOrID(ctx)
+	if err := srv.components.ThreadRepository.PinThread(requestContext(ctx), &thread, &args); err != nil {
+		srv.WriteError(ctx, err)
+		return
+	}
+
+	srv.WriteJSON(ctx, http.StatusOK, &thread)
+}
+
+func (srv *Server) moveThread(ctx *fasthttp.RequestCtx) {
+	var move models.ThreadMove
+	if err := srv.ReadBody(ctx, &move); err != nil {
+		srv.WriteError(ctx, err)
+		return
+	}
+	if err := validation.ValidateThreadMove(&move); err != nil {
+		srv.WriteError(ctx, err)
+		return
+	}
+
+	args := repositories.MoveThreadArgs{
+		Moderator: move.Moderator,
+		Forum:     move.Forum,
+	}
+
+	thread := threadBySlugOrID(ctx)
+	if err := srv.components.ThreadRepository.MoveThread(requestContext(ctx), &thread, &args); err != nil {
+		srv.WriteError(ctx, err)
+		return
+	}
+
+	srv.WriteJSON(ctx, http.StatusOK, &thread)
+}
+
+func (srv *Server) mergeThread(ctx *fasthttp.RequestCtx) {
+	var merge models.ThreadMerge
+	if err := srv.ReadBody(ctx, &merge); err != nil {
+		srv.WriteError(ctx, err)
+		return
+	}
+	if err := validation.ValidateThreadMerge(&merge); err != nil {
+		srv.WriteError(ctx, err)
+		return
+	}
+
+	args := repositories.MergeThreadArgs{
+		Moderator: merge.Moderator,
+		Target:    parseSlugOrID(merge.Target),
+		Parent:    merge.Parent,
+	}
+
+	thread := threadBySlugOrID(ctx)
+	if err := srv.components.ThreadRepository.MergeThread(requestContext(ctx), &thread, &args); err != nil {
+		srv.WriteError(ctx, err)
+		return
+	}
+
+	srv.WriteJSON(ctx, http.StatusOK, &thread)
+}
+
+func threadBySlugOrID(ctx *fasthttp.RequestCtx) models.Thread {
+	return parseSlugOrID(ctx.UserValue("slug_or_id").(string))
+}
+
+func parseSlugOrID(slugOrID string) models.Thread {
+	var thread models.Thread
+
+	if id, err := strconv.ParseInt(slugOrID, 10, 32); err == nil {
+		thread.ID = int32(id)
+	} else {
+		thread.Slug = models.NullString{
+			Valid:  true,
+			String: slugOrID,
+		}
+	}
+	return thread
+}
@@ -0,0 +1,220 @@
+package services
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"tp-project-db/models"
+)
+
+func TestThreadDeletionHandlers(t *testing.T) {
+	srv := newTestServer(t)
+	th := srv.thread("jolly")
+	srv.post(th, 0, "alice", "hi")
+
+	for _, req := range []struct{ method, uri string }{
+		{"DELETE", "/api/thread/jolly"},
+		{"POST", "/api/thread/jolly/restore"},
+		{"POST", "/api/thread/jolly/archive"},
+		{"POST", "/api/thread/jolly/unarchive"},
+		{"DELETE", "/api/thread/jolly?hard=true"},
+	} {
+		sep := "?"
+		if strings.Contains(req.uri, "?") {
+			sep = "&"
+		}
+		srv.mustFail(req.method, req.uri, "", http.StatusUnprocessableEntity, "validation_failed")
+		srv.mustFail(req.method, req.uri+sep+"moderator=bob", "", http.StatusForbidden, "not_moderator")
+	}
+
+	var thread models.Thread
+	srv.decode("DELETE", "/api/thread/jolly?moderator=alice", "", http.StatusOK, &thread)
+	if !thread.IsDeleted || thread.ID != th {
+		t.Errorf("deleted thread = %+v", thread)
+	}
+	srv.mustFail("POST", "/api/thread/jolly/create", `[{"author":"alice","message":"late"}]`, http.StatusConflict, "thread_deleted")
+	srv.mustFail("POST", "/api/thread/jolly/vote", `{"nickname":"alice","voice":1}`, http.StatusConflict, "thread_deleted")
+
+	srv.decode("POST", fmt.Sprintf("/api/thread/%d/restore?moderator=alice", th), "", http.StatusOK, &thread)
+	if thread.IsDeleted {
+		t.Errorf("restored thread = %+v", thread)
+	}
+	srv.post(th, 0, "bob", "back")
+
+	srv.decode("POST", "/api/thread/jolly/archive?moderator=ALICE", "", http.StatusOK, &thread)
+	if !thread.IsArchived {
+		t.Errorf("archived thread = %+v", thread)
+	}
+	srv.mustFail("POST", "/api/thread/jolly/create", `[{"author":"alice","message":"late"}]`, http.StatusConflict, "thread_archived")
+
+	srv.decode("POST", "/api/thread/jolly/unarchive?moderator=alice", "", http.StatusOK, &thread)
+	if thread.IsArchived {
+		t.Errorf("unarchived thread = %+v", thread)
+	}
+	srv.post(th, 0, "alice", "again")
+
+	var deletion models.ThreadDeletion
+	srv.decode("DELETE", "/api/thread/jolly?hard=true&moderator=alice", "", http.StatusOK, &deletion)
+	if deletion.ID != th || deletion.Posts != 3 {
+		t.Errorf("purge = %+v, want thread %d with 3 posts", deletion, th)
+	}
+	srv.mustFail("GET", "/api/thread/jolly/details", "", http.StatusNotFound, "thread_not_found")
+}
+
+func TestModerateThreadHandler(t *testing.T) {
+	srv := newTestServer(t)
+	th := srv.thread("jolly")
+
+	srv.mustFail("POST", "/api/thread/jolly/moderate", `{"closed":true}`, http.StatusUnprocessableEntity, "validation_failed")
+	srv.mustFail("POST", "/api/thread/jolly/moderate", `{"moderator":"bob","closed":true,"reason":"spam"}`, http.StatusForbidden, "not_moderator")
+
+	var thread models.Thread
+	srv.decode("POST", "/api/thread/jolly/moderate", `{"moderator":"ALICE","closed":true,"reason":"off topic"}`, http.StatusOK, &thread)
+	if !thread.IsClosed || !thread.ClosedAt.Valid {
+		t.Errorf("closed thread = %+v", thread)
+	}
+	if !thread.ClosedBy.Valid || thread.ClosedBy.String != "alice" {
+		t.Errorf("closedBy = %+v, want the moderator alice", thread.ClosedBy)
+	}
+
+	var e errorBody
+	srv.decode("POST", fmt.Sprintf("/api/thread/%d/create", th), `[{"author":"alice","message":"late"}]`, http.StatusForbidden, &e)
+	if e.Code != "thread_closed" || e.Reason != "off topic" {
+		t.Errorf("posting to a closed thread = %+v, want thread_closed with the reason", e)
+	}
+	srv.mustFail("POST", "/api/thread/jolly/vote", `{"nickname":"bob","voice":1}`, http.StatusForbidden, "thread_closed")
+
+	var reopened models.Thread
+	srv.decode("POST", "/api/thread/jolly/moderate", `{"moderator":"alice","closed":false}`, http.StatusOK, &reopened)
+	if reopened.IsClosed || reopened.ClosedBy.Valid || reopened.ClosedAt.Valid {
+		t.Errorf("reopened thread = %+v", reopened)
+	}
+	srv.post(th, 0, "bob", "open again")
+}
+
+func TestPinThreadHandler(t *testing.T) {
+	srv := newTestServer(t)
+
+	var ids []int32
+	for i := 0; i < 4; i++ {
+		var th models.Thread
+		body := fmt.Sprintf(`{"author":"bob","title":"title","message":"message","created":"2018-11-01T12:0%d:00.000Z"}`, i)
+		srv.decode("POST", "/api/forum/pirate/create", body, http.StatusCreated, &th)
+		ids = append(ids, th.ID)
+	}
+
+	uri := fmt.Sprintf("/api/thread/%d/pin", ids[3])
+	srv.mustFail("POST", uri, `{"moderator":"bob","pinned":true}`, http.StatusForbidden, "not_moderator")
+
+	var thread models.Thread
+	srv.decode("POST", uri, `{"moderator":"alice","pinned":true,"until":"2100-01-01T00:00:00.000Z"}`, http.StatusOK, &thread)
+	if !thread.IsPinned || !thread.PinnedUntil.Valid {
+		t.Errorf("pinned thread = %+v", thread)
+	}
+
+	// Regression: the pinned thread comes first even with since and counts
+	// towards the limit.
+	tests := []struct {
+		query string
+		want  []int32
+	}{
+		{"limit=2", []int32{ids[3], ids[0]}},
+		{"limit=2&since=2018-11-01T12:01:00.000Z", []int32{ids[3], ids[1]}},
+		{"limit=2&since=2018-11-01T12:01:00.000Z&desc=true", []int32{ids[3], ids[1]}},
+		{"limit=2&since=2018-11-01T12:01:00.000Z&excludePinned=true", []int32{ids[1], ids[2]}},
+		{"limit=2&desc=true", []int32{ids[3], ids[2]}},
+		{"limit=2&excludePinned=true", []int32{ids[0], ids[1]}},
+	}
+	for _, tt := range tests {
+		var threads []models.Thread
+		srv.decode("GET", "/api/forum/pirate/threads?"+tt.query, "", http.StatusOK, &threads)
+
+		var got []int32
+		for _, th := range threads {
+			got = append(got, th.ID)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("threads?%s = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+
+	var unpinned models.Thread
+	srv.decode("POST", uri, `{"moderator":"alice","pinned":false}`, http.StatusOK, &unpinned)
+	if unpinned.IsPinned || unpinned.PinnedUntil.Valid {
+		t.Errorf("unpinned thread = %+v", unpinned)
+	}
+}
+
+func TestMoveThreadHandler(t *testing.T) {
+	srv := newTestServer(t)
+	srv.must("POST", "/api/user/carol/create", `{"fullname":"Carol","email":"carol@example.com"}`, http.StatusCreated)
+	srv.must("POST", "/api/forum/create", `{"slug":"navy","title":"Navy","user":"carol"}`, http.StatusCreated)
+	th := srv.thread("jolly")
+	srv.post(th, 0, "alice", "hi")
+
+	srv.mustFail("POST", "/api/thread/jolly/move", `{"moderator":"alice"}`, http.StatusUnprocessableEntity, "validation_failed")
+	srv.mustFail("POST", "/api/thread/jolly/move", `{"moderator":"carol","forum":"navy"}`, http.StatusForbidden, "not_moderator")
+	srv.mustFail("POST", "/api/thread/jolly/move", `{"moderator":"alice","forum":"army"}`, http.StatusNotFound, "thread_target_forum_not_found")
+
+	var thread models.Thread
+	srv.decode("POST", "/api/thread/jolly/move", `{"moderator":"alice","forum":"NAVY"}`, http.StatusOK, &thread)
+	if thread.Forum != "navy" {
+		t.Errorf("moved thread forum = %q, want navy", thread.Forum)
+	}
+
+	var posts []models.Post
+	srv.decode("GET", "/api/thread/jolly/posts", "", http.StatusOK, &posts)
+	if len(posts) != 1 || posts[0].Forum != "navy" {
+		t.Errorf("posts of the moved thread = %+v, want them in navy", posts)
+	}
+
+	for slug, want := range map[string]models.Forum{
+		"pirate": {NumThreads: 0, NumPosts: 0},
+		"navy":   {NumThreads: 1, NumPosts: 1},
+	} {
+		var forum models.Forum
+		srv.decode("GET", "/api/forum/"+slug+"/details", "", http.StatusOK, &forum)
+		if forum.NumThreads != want.NumThreads || forum.NumPosts != want.NumPosts {
+			t.Errorf("%s counters = %d threads, %d posts, want %d and %d",
+				slug, forum.NumThreads, forum.NumPosts, want.NumThreads, want.NumPosts)
+		}
+	}
+}
+
+func TestMergeThreadHandler(t *testing.T) {
+	srv := newTestServer(t)
+	source := srv.thread("jolly")
+	target := srv.thread("roger")
+	anchor := srv.post(target, 0, "alice", "anchor")
+	root := srv.post(source, 0, "bob", "root")
+	srv.must("POST", fmt.Sprintf("/api/thread/%d/vote", source), `{"nickname":"bob","voice":1}`, http.StatusOK)
+
+	srv.mustFail("POST", "/api/thread/jolly/merge", `{"moderator":"alice"}`, http.StatusUnprocessableEntity, "validation_failed")
+	srv.mustFail("POST", "/api/thread/jolly/merge", `{"moderator":"alice","target":"jolly"}`, http.StatusBadRequest, "thread_merge_self")
+	srv.mustFail("POST", "/api/thread/jolly/merge", `{"moderator":"alice","target":"99"}`, http.StatusNotFound, "thread_target_not_found")
+	srv.mustFail("POST", "/api/thread/jolly/merge", `{"moderator":"bob","target":"roger"}`, http.StatusForbidden, "not_moderator")
+
+	var thread models.Thread
+	body := fmt.Sprintf(`{"moderator":"alice","target":"%d","parent":%d}`, target, anchor)
+	srv.decode("POST", "/api/thread/jolly/merge", body, http.StatusOK, &thread)
+	if thread.ID != target || thread.NumVotes != 1 {
+		t.Errorf("merged thread = %+v, want %d with the source's vote", thread, target)
+	}
+
+	var posts []models.Post
+	srv.decode("GET", "/api/thread/roger/posts?sort=tree", "", http.StatusOK, &posts)
+	if got := postIDs(posts); !reflect.DeepEqual(got, []int64{anchor, root}) || posts[1].ParentID != anchor {
+		t.Errorf("merged posts = %+v, want %d under %d", posts, root, anchor)
+	}
+
+	var redirected models.Thread
+	srv.decode("GET", "/api/thread/jolly/details", "", http.StatusOK, &redirected)
+	if redirected.ID != target {
+		t.Errorf("old slug resolves to %d, want %d", redirected.ID, target)
+	}
+
+	// Regression: the redirecting slug can't be reused.
+	srv.must("POST", "/api/forum/pirate/create", `{"author":"bob","title":"title","message":"message","slug":"jolly"}`, http.StatusConflict)
+}
@@ -6,21 +6,27 @@ import (
 	"net/http"
 	"tp-project-db/models"
 	"tp-project-db/repositories"
+	"tp-project-db/validation"
 )
 
 func (srv *Server) createUser(ctx *fasthttp.RequestCtx) {
 	var user models.User
-	srv.ReadBody(ctx, &user)
+	if err := srv.ReadBody(ctx, &user); err != nil {
+		srv.WriteError(ctx, err)
+		return
+	}
 
 	user.Nickname = ctx.UserValue("nickname").(string)
+	if err := validation.ValidateUser(&user); err != nil {
+		srv.WriteError(ctx, err)
+		return
+	}
 
 	var existing string
-	status := srv.components.UserRepository.CreateUser(&user, &existing)
-
-	if status == http.StatusCreated {
-		srv.rwMtx.Lock()
-		srv.status.NumUsers++
-		srv.rwMtx.Unlock()
+	status, err := srv.components.UserRepository.CreateUser(requestContext(ctx), &user, &existing)
+	if err != nil {
+		srv.WriteError(ctx, err)
+		return
 	}
 
 	ctx.SetStatusCode(status)
@@ -32,8 +38,8 @@ func (srv *Server) findUser(ctx *fasthttp.RequestCtx) {
 	user := models.User{
 		Nickname: ctx.UserValue("nickname").(string),
 	}
-	if err := srv.components.UserRepository.FindUser(&user); err != nil {
-		srv.WriteError(ctx, err.HttpStatus)
+	if err := srv.components.UserRepository.FindUser(requestContext(ctx), &user); err != nil {
+		srv.WriteError(ctx, err)
 		return
 	}
 	srv.WriteJSON(ctx, http.StatusOK, &user)
@@ -46,28 +52,65 @@ func (srv *Server) findUsersByForum(ctx *fasthttp.RequestCtx) {
 		Desc:  ctx.QueryArgs().GetBool("desc"),
 		Limit: ctx.QueryArgs().GetUintOrZero("limit"),
 	}
-	users, err := srv.components.UserRepository.FindUsersByForum(&args)
+	users, err := srv.components.UserRepository.FindUsersByForum(requestContext(ctx), &args)
 	if err != nil {
-		srv.WriteError(ctx, err.HttpStatus)
+		srv.WriteError(ctx, err)
 		return
 	}
 	srv.WriteJSON(ctx, http.StatusOK, users)
 }
 
+func (srv *Server) findPostsByAuthor(ctx *fasthttp.RequestCtx) {
+	args := authorSearchArgs(ctx)
+	posts, err := srv.components.PostRepository.FindPostsByAuthor(requestContext(ctx), &args)
+	if err != nil {
+		srv.WriteError(ctx, err)
+		return
+	}
+	srv.WriteJSON(ctx, http.StatusOK, posts)
+}
+
+func (srv *Server) findThreadsByAuthor(ctx *fasthttp.RequestCtx) {
+	args := authorSearchArgs(ctx)
+	threads, err := srv.components.ThreadRepository.FindThreadsByAuthor(requestContext(ctx), &args)
+	if err != nil {
+		srv.WriteError(ctx, err)
+		return
+	}
+	srv.WriteJSON(ctx, http.StatusOK, threads)
+}
+
+func authorSearchArgs(ctx *fasthttp.RequestCtx) repositories.AuthorSearchArgs {
+	return repositories.AuthorSearchArgs{
+		Author: ctx.UserValue("nickname").(string),
+		Forum:  string(ctx.QueryArgs().Peek("forum")),
+		Since:  ctx.QueryArgs().GetUintOrZero("since"),
+		Desc:   ctx.QueryArgs().GetBool("desc"),
+		Limit:  ctx.QueryArgs().GetUintOrZero("limit"),
+	}
+}
+
 func (srv *Server) updateUser(ctx *fasthttp.RequestCtx) {
 	var user models.User
-	srv.ReadBody(ctx, &user)
+	if err := srv.ReadBody(ctx, &user); err != nil {
+		srv.WriteError(ctx, err)
+		return
+	}
 
 	user.Nickname = ctx.UserValue("nickname").(string)
+	if err := validation.ValidateUserUpdate(&user); err != nil {
+		srv.WriteError(ctx, err)
+		return
+	}
 
 	var existing sql.NullString
-	status := srv.components.UserRepository.UpdateUser(&user, &existing)
-
-	if existing.Valid {
-		ctx.SetStatusCode(status)
-		ctx.Response.Header.SetContentType(JsonType)
-		ctx.Response.SetBody([]byte(existing.String))
-	} else {
-		srv.WriteError(ctx, status)
+	status, err := srv.components.UserRepository.UpdateUser(requestContext(ctx), &user, &existing)
+	if err != nil {
+		srv.WriteError(ctx, err)
+		return
 	}
+
+	ctx.SetStatusCode(status)
+	ctx.Response.Header.SetContentType(JsonType)
+	ctx.Response.SetBody([]byte(existing.String))
 }
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"tp-project-db/models"
+)
+
+func TestErrorBodies(t *testing.T) {
+	srv := newTestServer(t)
+
+	tests := []struct {
+		method, uri, body string
+		status            int
+		code, entity      string
+		field             string
+	}{
+		{"GET", "/api/user/carol/profile", "", http.StatusNotFound, "user_not_found", "user", "nickname"},
+		{"POST", "/api/forum/create", `{"slug":"navy","title":"Navy","user":"carol"}`, http.StatusNotFound, "forum_admin_not_found", "user", "user"},
+		{"GET", "/api/forum/navy/details", "", http.StatusNotFound, "forum_not_found", "forum", "slug"},
+		{"GET", "/api/forum/navy/users", "", http.StatusNotFound, "forum_not_found", "forum", "slug"},
+		{"GET", "/api/thread/99/details", "", http.StatusNotFound, "thread_not_found", "thread", "slug_or_id"},
+		{"GET", "/api/post/99/details", "", http.StatusNotFound, "post_not_found", "post", "id"},
+	}
+	for _, tt := range tests {
+		var e errorBody
+		srv.decode(tt.method, tt.uri, tt.body, tt.status, &e)
+		if e.Code != tt.code || e.Entity != tt.entity || e.Field != tt.field || e.Message == "" {
+			t.Errorf("%s %s error = %+v, want code %s on %s.%s", tt.method, tt.uri, e, tt.code, tt.entity, tt.field)
+		}
+	}
+}
+
+func TestRequestValidation(t *testing.T) {
+	srv := newTestServer(t)
+	srv.must("POST", "/api/forum/pirate/create", `{"author":"bob","title":"t","message":"m","slug":"jolly"}`, http.StatusCreated)
+
+	tests := []struct {
+		method, uri, body string
+		status            int
+		code              string
+		fields            []string
+	}{
+		{"POST", "/api/user/carol/create", `{"email":`, http.StatusBadRequest, MalformedBodyErrCode, nil},
+		{"POST", "/api/user/carol/create", `{"fullname":"Carol","email":"not-an-email"}`,
+			http.StatusUnprocessableEntity, "validation_failed", []string{"email"}},
+		{"POST", "/api/user/carol/profile", `{"email":"carol"}`,
+			http.StatusUnprocessableEntity, "validation_failed", []string{"email"}},
+		{"POST", "/api/forum/create", `{"slug":"no spaces","user":"alice"}`,
+			http.StatusUnprocessableEntity, "validation_failed", []string{"title", "slug"}},
+		{"POST", "/api/thread/jolly/details", `{"title":"   "}`,
+			http.StatusUnprocessableEntity, "validation_failed", []string{"title"}},
+		{"POST", "/api/thread/jolly/vote", `{"nickname":"alice","voice":2}`,
+			http.StatusUnprocessableEntity, "validation_failed", []string{"voice"}},
+	}
+	for _, tt := range tests {
+		var e errorBody
+		srv.decode(tt.method, tt.uri, tt.body, tt.status, &e)
+		if e.Code != tt.code {
+			t.Errorf("%s %s error code = %q, want %q", tt.method, tt.uri, e.Code, tt.code)
+		}
+
+		fields := make(map[string]bool)
+		for _, d := range e.Details {
+			fields[d.Field] = true
+		}
+		for _, field := range tt.fields {
+			if !fields[field] {
+				t.Errorf("%s %s details = %+v, want %s reported", tt.method, tt.uri, e.Details, field)
+			}
+		}
+	}
+}
+
+func TestAuthorListingHandlers(t *testing.T) {
+	srv := newTestServer(t)
+	first := srv.thread("")
+	srv.must("POST", "/api/forum/pirate/create", `{"author":"alice","title":"title","message":"message"}`, http.StatusCreated)
+	second := srv.thread("")
+
+	var ids []int64
+	for i := 0; i < 3; i++ {
+		ids = append(ids, srv.post(first, 0, "alice", "hi"))
+	}
+	srv.post(second, 0, "bob", "not alice")
+
+	postTests := []struct {
+		query string
+		want  []int64
+	}{
+		{"limit=2", ids[:2]},
+		{fmt.Sprintf("limit=2&since=%d", ids[1]), ids[2:]},
+		{"limit=2&desc=true", []int64{ids[2], ids[1]}},
+		{fmt.Sprintf("desc=true&since=%d", ids[1]), ids[:1]},
+	}
+	for _, tt := range postTests {
+		var posts []models.Post
+		srv.decode("GET", "/api/user/ALICE/posts?"+tt.query, "", http.StatusOK, &posts)
+		if got := postIDs(posts); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("posts?%s = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+
+	var threads []models.Thread
+	srv.decode("GET", "/api/user/bob/threads?limit=1", "", http.StatusOK, &threads)
+	if len(threads) != 1 || threads[0].ID != first {
+		t.Errorf("threads?limit=1 = %+v, want [%d]", threads, first)
+	}
+	srv.decode("GET", fmt.Sprintf("/api/user/bob/threads?since=%d", first), "", http.StatusOK, &threads)
+	if len(threads) != 1 || threads[0].ID != second {
+		t.Errorf("threads?since=%d = %+v, want [%d]", first, threads, second)
+	}
+	srv.decode("GET", "/api/user/bob/threads?forum=navy", "", http.StatusOK, &threads)
+	if len(threads) != 0 {
+		t.Errorf("threads?forum=navy = %+v, want none", threads)
+	}
+
+	srv.mustFail("GET", "/api/user/dave/posts", "", http.StatusNotFound, "user_not_found")
+	srv.mustFail("GET", "/api/user/dave/threads", "", http.StatusNotFound, "user_not_found")
+}
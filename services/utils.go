@@ -2,15 +2,31 @@ package services
 
 import (
 	"github.com/mailru/easyjson"
+	"github.com/mailru/easyjson/jlexer"
 	"github.com/valyala/fasthttp"
+	"io"
+	"tp-project-db/errs"
+	"tp-project-db/logging"
 )
 
 const (
 	JsonType = "application/json"
 )
 
-func (srv *Server) ReadBody(ctx *fasthttp.RequestCtx, v easyjson.Unmarshaler) {
-	_ = easyjson.Unmarshal(ctx.PostBody(), v)
+const (
+	MalformedBodyErrCode = "malformed_body"
+	InvalidValueErrCode  = "invalid_value"
+)
+
+func (srv *Server) ReadBody(ctx *fasthttp.RequestCtx, v easyjson.Unmarshaler) *errs.Error {
+	err := easyjson.Unmarshal(ctx.PostBody(), v)
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*jlexer.LexerError); ok || err == io.EOF {
+		return errs.NewBadRequestError(err.Error()).WithCode(MalformedBodyErrCode)
+	}
+	return errs.NewInvalidFormatError(err.Error()).WithCode(InvalidValueErrCode)
 }
 
 func (srv *Server) WriteJSON(ctx *fasthttp.RequestCtx, status int, v easyjson.Marshaler) {
@@ -20,8 +36,13 @@ func (srv *Server) WriteJSON(ctx *fasthttp.RequestCtx, status int, v easyjson.Ma
 	ctx.Response.SetBody(b)
 }
 
-func (srv *Server) WriteError(ctx *fasthttp.RequestCtx, status int) {
-	ctx.SetStatusCode(status)
-	ctx.Response.Header.SetContentType(JsonType)
-	ctx.Response.SetBody(srv.commonErr)
+func (srv *Server) WriteError(ctx *fasthttp.RequestCtx, err *errs.Error) {
+	if err.Category == errs.CategoryInternal {
+		logging.Default.Error("internal error", logging.Fields{
+			logging.RequestIDField: requestID(ctx),
+			"error":                err.Message,
+		})
+		err = srv.internalErr
+	}
+	srv.WriteJSON(ctx, err.HttpStatus, err)
 }
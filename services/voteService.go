@@ -5,27 +5,35 @@ import (
 	"github.com/valyala/fasthttp"
 	"strconv"
 	"tp-project-db/models"
+	"tp-project-db/validation"
 )
 
 func (srv *Server) addVote(ctx *fasthttp.RequestCtx) {
 	var vote models.Vote
-	srv.ReadBody(ctx, &vote)
+	if err := srv.ReadBody(ctx, &vote); err != nil {
+		srv.WriteError(ctx, err)
+		return
+	}
+	if err := validation.ValidateVote(&vote); err != nil {
+		srv.WriteError(ctx, err)
+		return
+	}
 
 	vote.ThreadSlug = ctx.UserValue("slug_or_id").(string)
 
-	id, err := strconv.ParseInt(vote.ThreadSlug, 10, 32)
-	if err == nil {
+	id, parErr := strconv.ParseInt(vote.ThreadSlug, 10, 32)
+	if parErr == nil {
 		vote.ThreadID = int32(id)
 	}
 
 	var thread sql.NullString
-	status := srv.components.VoteRepository.AddVote(&vote, &thread)
-
-	if thread.Valid {
-		ctx.SetStatusCode(status)
-		ctx.Response.Header.SetContentType(JsonType)
-		ctx.Response.SetBody([]byte(thread.String))
-	} else {
-		srv.WriteError(ctx, status)
+	status, err := srv.components.VoteRepository.AddVote(requestContext(ctx), &vote, &thread)
+	if err != nil {
+		srv.WriteError(ctx, err)
+		return
 	}
+
+	ctx.SetStatusCode(status)
+	ctx.Response.Header.SetContentType(JsonType)
+	ctx.Response.SetBody([]byte(thread.String))
 }
@@ -0,0 +1,149 @@
+package validation
+
+import (
+	"fmt"
+	"time"
+	"tp-project-db/consts"
+	"tp-project-db/errs"
+	"tp-project-db/models"
+)
+
+func ValidateUser(user *models.User) *errs.Error {
+	var v Validator
+	v.Required("nickname", user.Nickname)
+	if v.Required("email", user.Email) {
+		v.Email("email", user.Email)
+	}
+	v.Required("fullname", user.FullName)
+	return v.Err()
+}
+
+func ValidateUserUpdate(user *models.User) *errs.Error {
+	var v Validator
+	if user.Email != consts.EmptyString {
+		v.Email("email", user.Email)
+	}
+	return v.Err()
+}
+
+func ValidateForum(forum *models.Forum) *errs.Error {
+	var v Validator
+	if v.Required("slug", forum.Slug) {
+		v.Slug("slug", forum.Slug)
+	}
+	v.Required("title", forum.Title)
+	v.Required("user", forum.Admin)
+	return v.Err()
+}
+
+func ValidateForumSearchConfig(config *models.ForumSearchConfig) *errs.Error {
+	var v Validator
+	v.Required("moderator", config.Moderator)
+	if config.Config != models.SearchConfigEnglish && config.Config != models.SearchConfigRussian {
+		v.Fail("config", fmt.Sprintf("must be one of [%s %s]", models.SearchConfigEnglish, models.SearchConfigRussian))
+	}
+	return v.Err()
+}
+
+func ValidateThread(thread *models.Thread) *errs.Error {
+	var v Validator
+	if thread.Slug.Valid && v.Required("slug", thread.Slug.String) {
+		v.Slug("slug", thread.Slug.String)
+	}
+	v.Required("title", thread.Title)
+	v.Required("author", thread.Author)
+	v.Required("message", thread.Message)
+	return v.Err()
+}
+
+func ValidateThreadUpdate(update *models.ThreadUpdate) *errs.Error {
+	var v Validator
+	if update.Title != consts.EmptyString {
+		v.Required("title", update.Title)
+	}
+	if update.Message != consts.EmptyString {
+		v.Required("message", update.Message)
+	}
+	return v.Err()
+}
+
+func ValidatePosts(posts *models.Posts) *errs.Error {
+	var v Validator
+	for i, post := range *posts {
+		v.Required(fmt.Sprintf("[%d].author", i), post.Author)
+		v.Required(fmt.Sprintf("[%d].message", i), post.Message)
+		if post.ParentID < 0 {
+			v.Fail(fmt.Sprintf("[%d].parent", i), "must not be negative")
+		}
+	}
+	return v.Err()
+}
+
+func ValidatePostUpdate(update *models.PostUpdate) *errs.Error {
+	var v Validator
+	if update.Message != consts.EmptyString {
+		v.Required("message", update.Message)
+	}
+	if update.Editor != consts.EmptyString {
+		v.Required("editor", update.Editor)
+	}
+	return v.Err()
+}
+
+func ValidateVote(vote *models.Vote) *errs.Error {
+	var v Validator
+	v.Required("nickname", vote.User)
+	v.OneOf("voice", vote.Voice, -1, 1)
+	return v.Err()
+}
+
+func ValidateModerator(moderator string) *errs.Error {
+	var v Validator
+	v.Required("moderator", moderator)
+	return v.Err()
+}
+
+func ValidatePostSplit(split *models.PostSplit) *errs.Error {
+	var v Validator
+	v.Required("moderator", split.Moderator)
+	v.Required("title", split.Title)
+	if split.Slug.Valid && v.Required("slug", split.Slug.String) {
+		v.Slug("slug", split.Slug.String)
+	}
+	return v.Err()
+}
+
+func ValidateThreadModeration(moderation *models.ThreadModeration) *errs.Error {
+	var v Validator
+	v.Required("moderator", moderation.Moderator)
+	if moderation.Closed {
+		v.Required("reason", moderation.Reason)
+	}
+	return v.Err()
+}
+
+func ValidateThreadMove(move *models.ThreadMove) *errs.Error {
+	var v Validator
+	v.Required("moderator", move.Moderator)
+	v.Required("forum", move.Forum)
+	return v.Err()
+}
+
+func ValidateThreadMerge(merge *models.ThreadMerge) *errs.Error {
+	var v Validator
+	v.Required("moderator", merge.Moderator)
+	v.Required("target", merge.Target)
+	if merge.Parent < 0 {
+		v.Fail("parent", "must not be negative")
+	}
+	return v.Err()
+}
+
+func ValidateThreadPin(pin *models.ThreadPin) *errs.Error {
+	var v Validator
+	v.Required("moderator", pin.Moderator)
+	if pin.Pinned && pin.Until.Valid && !time.Time(pin.Until.Timestamp).After(time.Now()) {
+		v.Fail("until", "must be in the future")
+	}
+	return v.Err()
+}
@@ -0,0 +1,137 @@
+package validation
+
+import (
+	"github.com/go-openapi/strfmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+	"tp-project-db/errs"
+	"tp-project-db/models"
+)
+
+func failedFields(err *errs.Error) []string {
+	if err == nil {
+		return nil
+	}
+	fields := make([]string, 0, len(err.Details))
+	for _, d := range err.Details {
+		fields = append(fields, d.Field)
+	}
+	return fields
+}
+
+func checkFields(t *testing.T, name string, err *errs.Error, want ...string) {
+	t.Helper()
+	if len(want) == 0 {
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", name, failedFields(err))
+		}
+		return
+	}
+	if err == nil {
+		t.Errorf("%s: expected failures on %v", name, want)
+		return
+	}
+	if err.HttpStatus != http.StatusUnprocessableEntity || err.Code != ValidationErrCode {
+		t.Errorf("%s: got %d %s, want 422 %s", name, err.HttpStatus, err.Code, ValidationErrCode)
+	}
+	if got := failedFields(err); !reflect.DeepEqual(got, want) {
+		t.Errorf("%s: failed fields = %v, want %v", name, got, want)
+	}
+}
+
+func slug(s string) models.NullString {
+	return models.NullString{Valid: true, String: s}
+}
+
+func TestValidateUser(t *testing.T) {
+	checkFields(t, "valid", ValidateUser(&models.User{Nickname: "a", Email: "a@b.c", FullName: "A"}))
+	checkFields(t, "empty", ValidateUser(&models.User{}), "nickname", "email", "fullname")
+	checkFields(t, "bad email", ValidateUser(&models.User{Nickname: "a", Email: "nope", FullName: "A"}), "email")
+
+	checkFields(t, "update empty", ValidateUserUpdate(&models.User{}))
+	checkFields(t, "update bad email", ValidateUserUpdate(&models.User{Email: "nope"}), "email")
+}
+
+func TestValidateForum(t *testing.T) {
+	checkFields(t, "valid", ValidateForum(&models.Forum{Slug: "pirate-1", Title: "t", Admin: "a"}))
+	checkFields(t, "empty", ValidateForum(&models.Forum{}), "slug", "title", "user")
+	checkFields(t, "bad slug", ValidateForum(&models.Forum{Slug: "a b", Title: "t", Admin: "a"}), "slug")
+
+	checkFields(t, "search config", ValidateForumSearchConfig(&models.ForumSearchConfig{
+		Moderator: "a", Config: models.SearchConfigRussian,
+	}))
+	checkFields(t, "unknown search config", ValidateForumSearchConfig(&models.ForumSearchConfig{
+		Config: "klingon",
+	}), "moderator", "config")
+}
+
+func TestValidateThread(t *testing.T) {
+	checkFields(t, "valid", ValidateThread(&models.Thread{Title: "t", Author: "a", Message: "m"}))
+	checkFields(t, "empty", ValidateThread(&models.Thread{}), "title", "author", "message")
+	checkFields(t, "blank slug", ValidateThread(&models.Thread{
+		Slug: slug(" "), Title: "t", Author: "a", Message: "m",
+	}), "slug")
+	checkFields(t, "bad slug", ValidateThread(&models.Thread{
+		Slug: slug("a/b"), Title: "t", Author: "a", Message: "m",
+	}), "slug")
+}
+
+func TestValidateThreadUpdate(t *testing.T) {
+	checkFields(t, "empty keeps fields", ValidateThreadUpdate(&models.ThreadUpdate{}))
+	checkFields(t, "valid", ValidateThreadUpdate(&models.ThreadUpdate{Title: "t", Message: "m"}))
+	checkFields(t, "blank", ValidateThreadUpdate(&models.ThreadUpdate{Title: " ", Message: "\n"}), "title", "message")
+}
+
+func TestValidatePosts(t *testing.T) {
+	checkFields(t, "valid", ValidatePosts(&models.Posts{{Author: "a", Message: "m"}}))
+	checkFields(t, "empty batch", ValidatePosts(&models.Posts{}))
+	checkFields(t, "invalid entries", ValidatePosts(&models.Posts{
+		{Author: "a", Message: "m"},
+		{Message: "m", ParentID: -1},
+	}), "[1].author", "[1].parent")
+}
+
+func TestValidatePostUpdate(t *testing.T) {
+	checkFields(t, "empty keeps message", ValidatePostUpdate(&models.PostUpdate{}))
+	checkFields(t, "valid", ValidatePostUpdate(&models.PostUpdate{Message: "m", Editor: "e"}))
+	checkFields(t, "blank", ValidatePostUpdate(&models.PostUpdate{Message: " ", Editor: " "}), "message", "editor")
+}
+
+func TestValidateVote(t *testing.T) {
+	checkFields(t, "up", ValidateVote(&models.Vote{User: "a", Voice: 1}))
+	checkFields(t, "down", ValidateVote(&models.Vote{User: "a", Voice: -1}))
+	checkFields(t, "zero", ValidateVote(&models.Vote{Voice: 0}), "nickname", "voice")
+}
+
+func TestValidateModeration(t *testing.T) {
+	checkFields(t, "moderator", ValidateModerator(""), "moderator")
+
+	checkFields(t, "split", ValidatePostSplit(&models.PostSplit{Moderator: "a", Title: "t"}))
+	checkFields(t, "split bad slug", ValidatePostSplit(&models.PostSplit{
+		Moderator: "a", Title: "t", Slug: slug("a b"),
+	}), "slug")
+
+	checkFields(t, "reopen", ValidateThreadModeration(&models.ThreadModeration{Moderator: "a"}))
+	checkFields(t, "close without reason", ValidateThreadModeration(&models.ThreadModeration{
+		Moderator: "a", Closed: true,
+	}), "reason")
+
+	checkFields(t, "move", ValidateThreadMove(&models.ThreadMove{}), "moderator", "forum")
+
+	checkFields(t, "merge", ValidateThreadMerge(&models.ThreadMerge{Moderator: "a", Target: "t"}))
+	checkFields(t, "merge negative parent", ValidateThreadMerge(&models.ThreadMerge{
+		Moderator: "a", Target: "t", Parent: -1,
+	}), "parent")
+}
+
+func TestValidateThreadPin(t *testing.T) {
+	future := models.NullTimestamp{Valid: true, Timestamp: strfmt.DateTime(time.Now().Add(time.Hour))}
+	past := models.NullTimestamp{Valid: true, Timestamp: strfmt.DateTime(time.Now().Add(-time.Hour))}
+
+	checkFields(t, "forever", ValidateThreadPin(&models.ThreadPin{Moderator: "a", Pinned: true}))
+	checkFields(t, "until future", ValidateThreadPin(&models.ThreadPin{Moderator: "a", Pinned: true, Until: future}))
+	checkFields(t, "until past", ValidateThreadPin(&models.ThreadPin{Moderator: "a", Pinned: true, Until: past}), "until")
+	checkFields(t, "unpin ignores until", ValidateThreadPin(&models.ThreadPin{Moderator: "a", Until: past}))
+}
@@ -0,0 +1,72 @@
+package validation
+
+import (
+	"fmt"
+	"github.com/asaskevich/govalidator"
+	"strings"
+	"tp-project-db/consts"
+	"tp-project-db/errs"
+)
+
+const (
+	ValidationErrMessage = "validation failed"
+	ValidationErrCode    = "validation_failed"
+)
+
+const (
+	SlugPattern = `^(\d|\w|-|_)*(\w|-|_)(\d|\w|-|_)*$`
+)
+
+const (
+	RequiredErrMessage = "is required"
+	EmailErrMessage    = "must be a valid email"
+	SlugErrMessage     = "must contain only letters, digits, '-' and '_'"
+)
+
+type Validator struct {
+	details []errs.FieldError
+}
+
+func (v *Validator) Fail(field, message string) {
+	v.details = append(v.details, errs.FieldError{
+		Field:   field,
+		Message: message,
+	})
+}
+
+func (v *Validator) Required(field, value string) bool {
+	if strings.TrimSpace(value) == consts.EmptyString {
+		v.Fail(field, RequiredErrMessage)
+		return false
+	}
+	return true
+}
+
+func (v *Validator) Email(field, value string) {
+	if !govalidator.IsEmail(value) {
+		v.Fail(field, EmailErrMessage)
+	}
+}
+
+func (v *Validator) Slug(field, value string) {
+	if !govalidator.Matches(value, SlugPattern) {
+		v.Fail(field, SlugErrMessage)
+	}
+}
+
+func (v *Validator) OneOf(field string, value int32, allowed ...int32) {
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	v.Fail(field, fmt.Sprintf("must be one of %v", allowed))
+}
+
+func (v *Validator) Err() *errs.Error {
+	if len(v.details) == 0 {
+		return nil
+	}
+	return errs.NewInvalidFormatError(ValidationErrMessage).
+		WithCode(ValidationErrCode).WithDetails(v.details)
+}